@@ -1055,6 +1055,17 @@ func (p *Parlia) NextInTurnValidator(chain consensus.ChainHeaderReader, header *
 	return snap.inturnValidator(), nil
 }
 
+// NextTurnBlock returns the first block after header which the validator is in-turn to propose,
+// assuming the validator set stays, 0 if it is not a validator.
+func (p *Parlia) NextTurnBlock(chain consensus.ChainHeaderReader, header *types.Header, validator common.Address) (uint64, error) {
+	snap, err := p.snapshot(chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	return snap.nextTurnBlock(validator), nil
+}
+
 // Prepare implements consensus.Engine, preparing all the consensus fields of the
 // header for running the transactions on top.
 func (p *Parlia) Prepare(chain consensus.ChainHeaderReader, header *types.Header) error {
@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"slices"
 	"sort"
 
 	lru "github.com/hashicorp/golang-lru"
@@ -411,6 +412,27 @@ func (s *Snapshot) inturnValidator() common.Address {
 	return validators[offset]
 }
 
+// nextTurnBlock returns the first block after the snapshot which the validator is in-turn to
+// propose, 0 if it is not a validator.
+func (s *Snapshot) nextTurnBlock(validator common.Address) uint64 {
+	validators := s.validators()
+	idx := slices.Index(validators, validator)
+	if idx < 0 {
+		return 0
+	}
+
+	var (
+		n     = uint64(len(validators))
+		next  = s.Number + 1
+		turn  = next / uint64(s.TurnLength)
+		ahead = (uint64(idx) + n - turn%n) % n
+	)
+	if ahead == 0 {
+		return next
+	}
+	return (turn + ahead) * uint64(s.TurnLength)
+}
+
 func (s *Snapshot) enoughDistance(validator common.Address, header *types.Header) bool {
 	idx := s.indexOfVal(validator)
 	if idx < 0 {
@@ -21,3 +21,32 @@ func TestValidatorSetSort(t *testing.T) {
 		assert.True(t, bytes.Compare(validators[i][:], validators[i+1][:]) < 0)
 	}
 }
+
+func TestNextTurnBlock(t *testing.T) {
+	validators := []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2"), common.HexToAddress("0x3")}
+	snap := &Snapshot{TurnLength: 4, Validators: make(map[common.Address]*ValidatorInfo)}
+	for _, val := range validators {
+		snap.Validators[val] = &ValidatorInfo{}
+	}
+
+	for number := uint64(0); number < 30; number++ {
+		snap.Number = number
+		for _, val := range validators {
+			next := snap.nextTurnBlock(val)
+
+			// walk the blocks one by one to the turn of the validator
+			want := number + 1
+			for {
+				snap.Number = want - 1
+				if snap.inturnValidator() == val {
+					break
+				}
+				want++
+			}
+			snap.Number = number
+			assert.Equal(t, want, next, "validator %v at block %d", val, number)
+		}
+	}
+
+	assert.Equal(t, uint64(0), snap.nextTurnBlock(common.HexToAddress("0x4")))
+}
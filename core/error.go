@@ -42,6 +42,9 @@ var (
 
 	// ErrKnownBadBlock is return when the block is a known bad block
 	ErrKnownBadBlock = errors.New("already known bad block")
+
+	// ErrExecutionAborted is returned when the execution of a transaction is aborted by its context.
+	ErrExecutionAborted = errors.New("execution aborted")
 )
 
 // List of evm-call-message pre-checking errors. All state transition messages will
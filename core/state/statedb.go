@@ -1870,6 +1870,19 @@ func (s *StateDB) AddressInAccessList(addr common.Address) bool {
 	return s.accessList.ContainsAddress(addr)
 }
 
+// AccessListAddresses returns the addresses in the access list, once a transaction is applied
+// they are the accounts it accessed. It returns nil if there is no access list, e.g. before Berlin.
+func (s *StateDB) AccessListAddresses() []common.Address {
+	if s.accessList == nil {
+		return nil
+	}
+	addrs := make([]common.Address, 0, len(s.accessList.addresses))
+	for addr := range s.accessList.addresses {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
 // SlotInAccessList returns true if the given (address, slot)-tuple is in the access list.
 func (s *StateDB) SlotInAccessList(addr common.Address, slot common.Hash) (addressPresent bool, slotPresent bool) {
 	if s.accessList == nil {
@@ -1,8 +1,10 @@
 package types
 
 import (
+	"crypto/ecdsa"
 	"fmt"
 	"math/big"
+	"slices"
 	"sync/atomic"
 	"time"
 
@@ -11,10 +13,24 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
 const TxDecodeConcurrencyForPerBid = 5
 
+// MaxBidParents is the maximum parents a bid may list at the fork-uncertain moments.
+const MaxBidParents = 2
+
+// The versions of the bid hash, the builders declare the one they sign in RawBid.HashVersion.
+const (
+	BidHashLegacy    uint8 = 0 // the RLP hash of the whole RawBid, accepted within the compatibility window
+	BidHashCanonical uint8 = 1 // the hash of the auction-relevant fields, see RawBid.CanonicalHash
+)
+
+// canonicalBidHashDomain separates the canonical bid hash from the other signed messages, it
+// changes with the version of the hash.
+const canonicalBidHashDomain = "mev_bid/v1"
+
 // BidArgs represents the arguments to submit a bid.
 type BidArgs struct {
 	// RawBid from builder directly
@@ -39,8 +55,25 @@ func (b *BidArgs) EcrecoverSender() (common.Address, error) {
 	return crypto.PubkeyToAddress(*pk), nil
 }
 
+// Sign signs the raw bid with the key of the builder, it is the counterpart of EcrecoverSender.
+func (b *BidArgs) Sign(key *ecdsa.PrivateKey) error {
+	sig, err := crypto.Sign(b.RawBid.Hash().Bytes(), key)
+	if err != nil {
+		return err
+	}
+
+	b.Signature = sig
+	return nil
+}
+
 func (b *BidArgs) ToBid(builder common.Address, signer Signer) (*Bid, error) {
-	txs, err := b.RawBid.DecodeTxs(signer)
+	return b.ToBidWithKnownTxs(builder, signer, nil)
+}
+
+// ToBidWithKnownTxs is like ToBid, the senders of the txs returned by known, e.g. the txs
+// in the txpool, are reused instead of recovered again.
+func (b *BidArgs) ToBidWithKnownTxs(builder common.Address, signer Signer, known func(common.Hash) *Transaction) (*Bid, error) {
+	txs, err := b.RawBid.DecodeTxsWithKnownTxs(signer, known)
 	if err != nil {
 		return nil, err
 	}
@@ -51,6 +84,15 @@ func (b *BidArgs) ToBid(builder common.Address, signer Signer) (*Bid, error) {
 	unRevertibleHashes := mapset.NewThreadUnsafeSetWithSize[common.Hash](len(b.RawBid.UnRevertible))
 	unRevertibleHashes.Append(b.RawBid.UnRevertible...)
 
+	if len(b.RawBid.Optional) > len(txs) {
+		return nil, fmt.Errorf("expect Optional no more than %d", len(txs))
+	}
+	optionalHashes := mapset.NewThreadUnsafeSetWithSize[common.Hash](len(b.RawBid.Optional))
+	optionalHashes.Append(b.RawBid.Optional...)
+	if optionalHashes.Intersect(unRevertibleHashes).Cardinality() > 0 {
+		return nil, fmt.Errorf("tx can not be both Optional and NonRevertible")
+	}
+
 	if len(b.PayBidTx) != 0 {
 		var payBidTx = new(Transaction)
 		err = payBidTx.UnmarshalBinary(b.PayBidTx)
@@ -77,6 +119,7 @@ func (b *BidArgs) ToBid(builder common.Address, signer Signer) (*Bid, error) {
 		ParentHash:   b.RawBid.ParentHash,
 		Txs:          txs,
 		UnRevertible: unRevertibleHashes,
+		Optional:     optionalHashes,
 		GasUsed:      b.RawBid.GasUsed + b.PayBidTxGasUsed,
 		GasFee:       b.RawBid.GasFee,
 		BuilderFee:   b.RawBid.BuilderFee,
@@ -103,10 +146,52 @@ type RawBid struct {
 	GasFee       *big.Int        `json:"gasFee"`
 	BuilderFee   *big.Int        `json:"builderFee"`
 
+	// Timestamp is the unix milliseconds when the builder sends the bid, Expiry is the unix
+	// milliseconds after which the bid is invalid, both in the clock of the builder.
+	Timestamp uint64 `json:"timestamp,omitempty" rlp:"optional"`
+	Expiry    uint64 `json:"expiry,omitempty" rlp:"optional"`
+
+	// Optional are the hashes of the txs which are skipped instead of failing the bid if they
+	// fail or revert.
+	Optional []common.Hash `json:"optional,omitempty" rlp:"optional"`
+
+	// ParentHashes are the acceptable parents of the bid if the builder is unsure which one the
+	// block will be built on, ParentHash must be one of them. The bid proceeds with the parent
+	// which turns out to be the head, the others are dropped.
+	ParentHashes []common.Hash `json:"parentHashes,omitempty" rlp:"optional"`
+
+	// HashVersion is the version of the hash signed by the builder, it is not covered by the
+	// hashes, a wrong version fails the signature anyway.
+	HashVersion uint8 `json:"hashVersion,omitempty" rlp:"-"`
+
 	hash atomic.Value
 }
 
+// Parents returns the acceptable parents of the bid.
+func (b *RawBid) Parents() []common.Hash {
+	if len(b.ParentHashes) == 0 {
+		return []common.Hash{b.ParentHash}
+	}
+	return b.ParentHashes
+}
+
+// HasParent reports whether the bid can be built on top of the parent.
+func (b *RawBid) HasParent(parentHash common.Hash) bool {
+	for _, parent := range b.Parents() {
+		if parent == parentHash {
+			return true
+		}
+	}
+	return false
+}
+
 func (b *RawBid) DecodeTxs(signer Signer) ([]*Transaction, error) {
+	return b.DecodeTxsWithKnownTxs(signer, nil)
+}
+
+// DecodeTxsWithKnownTxs decodes the txs and recovers their senders, the senders cached in the
+// same txs returned by known are copied, so only the unknown txs are recovered by the workers.
+func (b *RawBid) DecodeTxsWithKnownTxs(signer Signer, known func(common.Hash) *Transaction) ([]*Transaction, error) {
 	if len(b.Txs) == 0 {
 		return []*Transaction{}, nil
 	}
@@ -120,6 +205,10 @@ func (b *RawBid) DecodeTxs(signer Signer) ([]*Transaction, error) {
 			return nil, err
 		}
 
+		if known != nil && inheritSender(tx, known(tx.Hash()), signer) {
+			return tx, nil
+		}
+
 		_, err = Sender(signer, tx)
 		if err != nil {
 			return nil, err
@@ -166,18 +255,106 @@ func (b *RawBid) DecodeTxs(signer Signer) ([]*Transaction, error) {
 	return bidTxs, nil
 }
 
+// inheritSender copies the sender cached in the known tx with the same hash, it returns
+// false if the known tx is nil or its sender isn't cached with the signer.
+func inheritSender(tx, known *Transaction, signer Signer) bool {
+	if known == nil {
+		return false
+	}
+
+	sc, ok := known.from.Load().(sigCache)
+	if !ok || !sc.signer.Equal(signer) {
+		return false
+	}
+
+	tx.from.Store(sc)
+	return true
+}
+
+// CacheSenders caches the senders of the bid txs for the signer, so the simulations of the
+// bid with the signer skip the ECDSA recovery. Only the txs not cached with the signer yet are
+// recovered, it returns their number. The txs with invalid signatures are left to fail later.
+func (b *Bid) CacheSenders(signer Signer) int {
+	var recovered int
+	for _, tx := range b.Txs {
+		if sc, ok := tx.from.Load().(sigCache); ok && sc.signer.Equal(signer) {
+			continue
+		}
+		if _, err := Sender(signer, tx); err == nil {
+			recovered++
+		}
+	}
+
+	return recovered
+}
+
+// EncodeBidTxs encodes the txs for RawBid.Txs, it is the counterpart of DecodeTxs.
+func EncodeBidTxs(txs []*Transaction) ([]hexutil.Bytes, error) {
+	encoded := make([]hexutil.Bytes, len(txs))
+	for i, tx := range txs {
+		enc, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = enc
+	}
+
+	return encoded, nil
+}
+
 // Hash returns the hash of the bid.
 func (b *RawBid) Hash() common.Hash {
 	if hash := b.hash.Load(); hash != nil {
 		return hash.(common.Hash)
 	}
 
-	h := rlpHash(b)
+	var h common.Hash
+	if b.HashVersion == BidHashCanonical {
+		h = b.CanonicalHash()
+	} else {
+		h = rlpHash(b)
+	}
 	b.hash.Store(h)
 
 	return h
 }
 
+// CanonicalHash returns the hash of exactly the auction-relevant fields of the bid: the
+// parents, the block number, the txs, the unRevertible and optional txs, the declared gas and
+// fees and the expiry. The parents and the tx sets are sorted as their order doesn't matter,
+// the nil fees are zero, and the timestamp, a metadata of the sending, is excluded. It is the
+// keccak256 of the RLP list
+//
+//	["mev_bid/v1", blockNumber, sorted parents, txs, sorted unRevertible, sorted optional,
+//	 gasUsed, gasFee, builderFee, expiry]
+func (b *RawBid) CanonicalHash() common.Hash {
+	return rlpHash([]interface{}{
+		canonicalBidHashDomain,
+		b.BlockNumber,
+		sortedHashes(b.Parents()),
+		b.Txs,
+		sortedHashes(b.UnRevertible),
+		sortedHashes(b.Optional),
+		b.GasUsed,
+		bigOrZero(b.GasFee),
+		bigOrZero(b.BuilderFee),
+		b.Expiry,
+	})
+}
+
+func sortedHashes(hashes []common.Hash) []common.Hash {
+	sorted := slices.Clone(hashes)
+	slices.SortFunc(sorted, func(a, b common.Hash) int { return a.Cmp(b) })
+	return sorted
+}
+
+func bigOrZero(v *big.Int) *big.Int {
+	if v == nil {
+		return new(big.Int)
+	}
+	return v
+}
+
 // Bid represents a bid.
 type Bid struct {
 	Builder      common.Address
@@ -185,6 +362,7 @@ type Bid struct {
 	ParentHash   common.Hash
 	Txs          Transactions
 	UnRevertible mapset.Set[common.Hash]
+	Optional     mapset.Set[common.Hash]
 	GasUsed      uint64
 	GasFee       *big.Int
 	BuilderFee   *big.Int
@@ -200,19 +378,506 @@ func (b *Bid) Hash() common.Hash {
 	return b.rawBid.Hash()
 }
 
+// Parents returns the acceptable parents of the bid.
+func (b *Bid) Parents() []common.Hash {
+	return b.rawBid.Parents()
+}
+
+// WithParent returns a copy of the bid built on top of the parent, which must be one of the
+// acceptable parents. The copy shares the txs and the hash of the bid.
+func (b *Bid) WithParent(parentHash common.Hash) *Bid {
+	cpy := *b
+	cpy.ParentHash = parentHash
+	return &cpy
+}
+
 // BidIssue represents a bid issue.
 type BidIssue struct {
 	Validator common.Address
 	Builder   common.Address
 	BidHash   common.Hash
 	Message   string
+	Code      BidIssueCode `json:",omitempty"` // empty if the issue is not classified, older builders only read Message
+
+	// the failed tx of the bid, its index is in the txs of the bid with the payBidTx last,
+	// and the revert reason is set if the tx is an unRevertible one which reverted
+	TxHash       *common.Hash `json:",omitempty"`
+	TxIndex      *uint64      `json:",omitempty"`
+	RevertReason string       `json:",omitempty"`
+}
+
+// BidSealedResult is the result of a block sealed by the validator, reported to the builders
+// which bid for it.
+type BidSealedResult struct {
+	Validator       common.Address
+	Builder         common.Address
+	BlockNumber     uint64
+	BlockHash       common.Hash
+	Win             bool
+	BidHash         common.Hash `json:",omitempty"` // the sealed bid, only reported to its builder
+	ValidatorReward *big.Int    // the reward of the sealed block to the validator
+}
+
+// MevBeacon is the liveness beacon broadcast by the validator to the builders periodically, so
+// they can skip the validators whose MEV pipeline is down. Signature is the validator's EIP-191
+// personal signature of the BeaconPayload.
+type MevBeacon struct {
+	Validator  common.Address `json:"validator"`
+	HeadNumber uint64         `json:"headNumber"`
+	HeadHash   common.Hash    `json:"headHash"`
+	Receiving  bool           `json:"receiving"` // whether the validator receives bids, the beacons stop once it doesn't
+	NextTurn   uint64         `json:"nextTurn"`  // the next block the validator is in-turn to propose, 0 if unknown
+	Timestamp  uint64         `json:"timestamp"` // unix milliseconds
+	Signature  hexutil.Bytes  `json:"signature"`
+}
+
+// BeaconPayload returns the message signed by the validator, it is domain separated so that
+// the signature can't be reused for other messages.
+func (b *MevBeacon) BeaconPayload() []byte {
+	payload, _ := rlp.EncodeToBytes([]interface{}{"mev_beacon", b.Validator, b.HeadNumber, b.HeadHash,
+		b.Receiving, b.NextTurn, b.Timestamp})
+	return payload
+}
+
+// EcrecoverSender recovers the validator who signed the beacon.
+func (b *MevBeacon) EcrecoverSender() (common.Address, error) {
+	if len(b.Signature) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("invalid signature length %d", len(b.Signature))
+	}
+
+	// the wallets may return the legacy V of 27 or 28
+	sig := common.CopyBytes(b.Signature)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	payload := b.BeaconPayload()
+	hash := crypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(payload), payload)))
+	pk, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return crypto.PubkeyToAddress(*pk), nil
 }
 
+// BidIssueCode classifies a bid issue, so builders can handle it programmatically.
+type BidIssueCode string
+
+const (
+	ErrCodeGasExceeded     BidIssueCode = "gasExceeded"     // the declared gas used exceeds the gas limit
+	ErrCodeRewardTooLow    BidIssueCode = "rewardTooLow"    // the simulated reward doesn't achieve the declared one
+	ErrCodeInvalidTx       BidIssueCode = "invalidTx"       // a tx of the bid failed
+	ErrCodeNonceOrder      BidIssueCode = "nonceOrder"      // the txs of a sender in the bid are out of nonce order
+	ErrCodeUnderpriced     BidIssueCode = "underpriced"     // a tx of the bid pays less than the minimum gas price or the base fee
+	ErrCodeInvalidPayment  BidIssueCode = "invalidPayment"  // the payBidTx took more than the declared builder fee
+	ErrCodeInvalidPayBidTx BidIssueCode = "invalidPayBidTx" // the last tx of the bid is not a plain transfer as the payBidTx
+	ErrCodeInvalidSize     BidIssueCode = "invalidSize"     // the block of the bid exceeds the message size limit
+	ErrCodeAborted         BidIssueCode = "aborted"         // the simulation was aborted by a better bid or the validator, transient
+	ErrCodeTimeout         BidIssueCode = "timeout"         // the simulation ran over its deadline
+	ErrCodeInternal        BidIssueCode = "internal"        // the validator failed to simulate the bid
+	ErrCodeUnresolved      BidIssueCode = "unresolved"      // the bid got no outcome in time, the fault of the validator
+)
+
 type MevParams struct {
 	ValidatorCommission   uint64 // 100 means 1%
 	BidSimulationLeftOver time.Duration
 	GasCeil               uint64
 	GasPrice              *big.Int // Minimum avg gas price for bid block
 	BuilderFeeCeil        *big.Int
+	GasFeeCeil            *big.Int // Maximum declared gas fee of a bid on top of the current head
+	BidGasPriceCeil       *big.Int // Maximum effective gas price used to compute GasFeeCeil
+	NontaxableFeeCeil     *big.Int // Maximum declared nontaxable fee of a bid
 	Version               string
 }
+
+// BestBidInfo is the summary of the current best bid for a parent hash.
+type BestBidInfo struct {
+	BlockNumber    uint64          `json:"blockNumber"`
+	Builder        *common.Address `json:"builder,omitempty"` // nil if redacted
+	ExpectedReward *big.Int        `json:"expectedReward"`    // gas fee after BEP95 plus nontaxable fee
+	TxCount        int             `json:"txCount"`
+	FastPath       bool            `json:"fastPath"` // accepted by the fast path after the bid deadline
+}
+
+// BidDryRunResult is the result of the dry-run simulation of a bid, the rewards are nil
+// if the simulation failed before the reward check.
+type BidDryRunResult struct {
+	BidHash     common.Hash  `json:"bidHash"`
+	BlockReward *big.Int     `json:"blockReward,omitempty"` // block reward after BEP95
+	DirectBribe *big.Int     `json:"directBribe,omitempty"` // transferred to the bribe EOAs of the validator
+	TotalReward *big.Int     `json:"totalReward,omitempty"`
+	GasUsed     uint64       `json:"gasUsed"`
+	Error       string       `json:"error,omitempty"`
+	Code        BidIssueCode `json:"code,omitempty"` // the code the issue would be reported with
+
+	StateOverride BidStateOverride `json:"stateOverride,omitempty"` // the overrides applied to the parent state
+}
+
+// The limits of the state overrides of a dry run, the overrides only fake a few balances or
+// slots to test the bids against, they must not rebuild the state.
+const (
+	MaxBidOverrideAccounts = 16
+	MaxBidOverrideSlots    = 256 // the storage slots of all the accounts
+	MaxBidOverrideCode     = 64 * 1024
+)
+
+// BidOverrideAccount is the override of an account in the format of eth_call.
+type BidOverrideAccount struct {
+	Nonce     *hexutil.Uint64              `json:"nonce,omitempty"`
+	Code      *hexutil.Bytes               `json:"code,omitempty"`
+	Balance   *hexutil.Big                 `json:"balance,omitempty"`
+	State     *map[common.Hash]common.Hash `json:"state,omitempty"` // replaces the whole storage
+	StateDiff *map[common.Hash]common.Hash `json:"stateDiff,omitempty"`
+}
+
+// BidStateOverride is the state overrides of a dry run, they are applied to the parent state
+// of the bid, and never accepted for the bids sent to the auction.
+type BidStateOverride map[common.Address]BidOverrideAccount
+
+// Validate checks the overrides are within the limits and not ambiguous.
+func (o BidStateOverride) Validate() error {
+	if len(o) > MaxBidOverrideAccounts {
+		return fmt.Errorf("too many override accounts %d, expected no more than %d", len(o), MaxBidOverrideAccounts)
+	}
+
+	var slots, code int
+	for addr, account := range o {
+		if account.State != nil && account.StateDiff != nil {
+			return fmt.Errorf("account %s has both 'state' and 'stateDiff'", addr.Hex())
+		}
+		if account.Balance != nil && (*big.Int)(account.Balance).BitLen() > 256 {
+			return fmt.Errorf("account %s balance overflows 256 bits", addr.Hex())
+		}
+		if account.State != nil {
+			slots += len(*account.State)
+		}
+		if account.StateDiff != nil {
+			slots += len(*account.StateDiff)
+		}
+		if account.Code != nil {
+			code += len(*account.Code)
+		}
+	}
+	if slots > MaxBidOverrideSlots {
+		return fmt.Errorf("too many override slots %d, expected no more than %d", slots, MaxBidOverrideSlots)
+	}
+	if code > MaxBidOverrideCode {
+		return fmt.Errorf("override code too large %d, expected no more than %d", code, MaxBidOverrideCode)
+	}
+
+	return nil
+}
+
+// BuilderStats is the runtime statistics of a builder measured by the validator.
+type BuilderStats struct {
+	Builder   common.Address `json:"builder"`
+	ClockSkew time.Duration  `json:"clockSkew"` // our receive time minus the bid timestamp, including the network latency
+
+	Accepted      uint64 `json:"accepted"` // number of bids accepted into simulation
+	Errors        uint64 `json:"errors"`   // number of bids failed in simulation
+	LastSeenBlock uint64 `json:"lastSeenBlock"`
+
+	SimDuration       time.Duration `json:"simDuration"`       // moving average of the simulation duration
+	FailureRate       float64       `json:"failureRate"`       // moving average of the simulation failure rate, in [0, 1]
+	Accuracy          float64       `json:"accuracy"`          // moving average of the share of the declared reward and gas used realized by the simulations, in [0, 1]
+	RewardGap         *big.Int      `json:"rewardGap"`         // moving average of the declared reward minus the realized one, in wei, positive for the over-declaring builders
+	FeeCeilRejections uint64        `json:"feeCeilRejections"` // number of bids rejected for declaring fees over the ceiling, each sampled at 0 accuracy
+	Reputation        float64       `json:"reputation"`        // the success rate times the accuracy, in [0, 1], the expected value of the bids is scaled by it with both PenalizeFailingBuilders and PenalizeInaccurateBuilders on, each factor floored at 0.1
+
+	ConsecutiveFailures uint64     `json:"consecutiveFailures"`
+	MutedUntil          *time.Time `json:"mutedUntil,omitempty"` // the bids of the builder are rejected until then
+}
+
+// BidHistoryEntry is the outcome of a block sealed by the validator.
+type BidHistoryEntry struct {
+	BlockNumber  uint64          `json:"blockNumber"`
+	BlockHash    common.Hash     `json:"blockHash"`
+	ParentHash   common.Hash     `json:"parentHash"`
+	Builder      *common.Address `json:"builder,omitempty"` // nil if the block is built locally
+	BidHash      *common.Hash    `json:"bidHash,omitempty"`
+	Reward       *big.Int        `json:"reward"`       // total reward of the block to the validator
+	Bids         int             `json:"bids"`         // the bids received for the block
+	SimFailures  int             `json:"simFailures"`  // the bids failed in the simulation
+	GreedyMerged bool            `json:"greedyMerged"` // the txs merged from mempool added reward
+}
+
+// BlockOrigin tells whether a block sealed by the validator is built from a bid or locally.
+type BlockOrigin struct {
+	BlockNumber uint64          `json:"blockNumber"`
+	BlockHash   common.Hash     `json:"blockHash"`
+	FromBid     bool            `json:"fromBid"`
+	Builder     *common.Address `json:"builder,omitempty"` // nil if the block is built locally
+	BidHash     *common.Hash    `json:"bidHash,omitempty"`
+	Reward      *big.Int        `json:"reward"` // total reward of the block to the validator
+}
+
+// BidHistoryArgs is the arguments of mev_bidHistory.
+type BidHistoryArgs struct {
+	Builder *common.Address `json:"builder,omitempty"` // only the blocks won by the builder if given
+	Offset  hexutil.Uint64  `json:"offset"`
+	Limit   hexutil.Uint64  `json:"limit"` // 0 means 100
+}
+
+// BidHistoryPage is a page of the outcomes of the recent sealed blocks, newest first.
+type BidHistoryPage struct {
+	Entries []*BidHistoryEntry `json:"entries"`
+	Total   uint64             `json:"total"` // the entries matching the filter
+}
+
+// MevRevenueReportArgs represents the arguments to query the MEV revenue report,
+// either the block range or the time range should be given.
+type MevRevenueReportArgs struct {
+	FromBlock *hexutil.Uint64 `json:"fromBlock"`
+	ToBlock   *hexutil.Uint64 `json:"toBlock"`
+	FromTime  *hexutil.Uint64 `json:"fromTime"`
+	ToTime    *hexutil.Uint64 `json:"toTime"`
+	GroupBy   string          `json:"groupBy"` // "day" or "epoch", default "day"
+}
+
+// MevRevenueGroup is the aggregated MEV revenue of the blocks proposed in a day or an epoch.
+// All the rewards are in wei, TotalRewardBNB is only a convenience field.
+type MevRevenueGroup struct {
+	Key            string                                `json:"key"`
+	FromBlock      uint64                                `json:"fromBlock"`
+	ToBlock        uint64                                `json:"toBlock"`
+	Blocks         uint64                                `json:"blocks"`
+	MevBlocks      uint64                                `json:"mevBlocks"`
+	GasReward      *big.Int                              `json:"gasReward"`
+	Bribe          *big.Int                              `json:"bribe"`
+	MergeReward    *big.Int                              `json:"mergeReward"`
+	TotalRewardBNB float64                               `json:"totalRewardBNB"`
+	Builders       map[common.Address]*MevBuilderRevenue `json:"builders"`
+}
+
+// MevBuilderRevenue is the aggregated MEV revenue attributed to a builder.
+type MevBuilderRevenue struct {
+	Blocks      uint64   `json:"blocks"`
+	GasReward   *big.Int `json:"gasReward"`
+	Bribe       *big.Int `json:"bribe"`
+	MergeReward *big.Int `json:"mergeReward"`
+}
+
+// BidDecision is the record of how the validator decided on a bid.
+type BidDecision struct {
+	BidHash      common.Hash    `json:"bidHash"`
+	Builder      common.Address `json:"builder"`
+	BlockNumber  uint64         `json:"blockNumber"`
+	ReceivedAt   time.Time      `json:"receivedAt"`
+	IntakeChecks []string       `json:"intakeChecks"`          // the intake checks passed by the bid
+	IntakeError  string         `json:"intakeError,omitempty"` // set if the bid was rejected before simulation
+
+	PreFilter       *BidComparison       `json:"preFilter,omitempty"` // comparison of the expected rewards before simulation
+	Simulation      *BidSimulationResult `json:"simulation,omitempty"`
+	FinalComparison *BidComparison       `json:"finalComparison,omitempty"` // comparison of the simulated rewards
+	Latency         *BidLatency          `json:"latency,omitempty"`
+	Outcome         string               `json:"outcome,omitempty"` // win, lose, fail or unresolved, set once the bid is resolved
+}
+
+// BidLatency is the breakdown of the time a bid spent in the validator, so that the
+// transport latency can be told apart from the simulation latency.
+type BidLatency struct {
+	Decode     time.Duration `json:"decode"`               // from receiving the request to decoding its arguments
+	Queue      time.Duration `json:"queue"`                // from decoding to the start of the simulation or the rejection
+	Simulation time.Duration `json:"simulation,omitempty"` // zero if the bid is not simulated
+}
+
+// BidComparison is a comparison between a bid and the one it competed with.
+type BidComparison struct {
+	Against      string       `json:"against"` // "none", "simulatingBid", "bestBid" or "debouncedBid"
+	AgainstBid   *common.Hash `json:"againstBid,omitempty"`
+	Value        *big.Int     `json:"value"`
+	AgainstValue *big.Int     `json:"againstValue,omitempty"`
+	PenaltyBps   uint64       `json:"penaltyBps,omitempty"` // discount applied to Value, in basis points
+	Won          bool         `json:"won"`
+}
+
+// BidSimulationResult is the result of the simulation of a bid.
+type BidSimulationResult struct {
+	Duration    time.Duration `json:"duration"`
+	Error       string        `json:"error,omitempty"`
+	BlockReward *big.Int      `json:"blockReward,omitempty"` // after BEP95, including the greedy merge
+	Bribe       *big.Int      `json:"bribe,omitempty"`
+	TotalReward *big.Int      `json:"totalReward,omitempty"`
+}
+
+// BidOutcome explains the outcome of a bid.
+type BidOutcome struct {
+	Decision *BidDecision      `json:"decision"`
+	Won      bool              `json:"won"`
+	Winner   *BidOutcomeWinner `json:"winner,omitempty"` // nil if the block is not sealed by the validator yet
+	Quota    *BidQuota         `json:"quota,omitempty"`  // nil once the bids of the block are cleared
+}
+
+// BidQuota is the number of the bids a builder could still send for a block, the bids
+// released by the validator, e.g. the ones never queued, don't count.
+type BidQuota struct {
+	Remaining int `json:"remaining"`
+	Max       int `json:"max"`
+}
+
+// BidOutcomeWinner is the figures of the block sealed by the validator.
+type BidOutcomeWinner struct {
+	BidHash     *common.Hash    `json:"bidHash,omitempty"` // nil if the block is built locally
+	Builder     *common.Address `json:"builder,omitempty"` // nil if built locally or redacted
+	TotalReward *big.Int        `json:"totalReward"`
+}
+
+// ExplainOutcomeArgs is the arguments of mev_explainOutcome, Signature is the builder's
+// signature of ExplainOutcomeHash.
+type ExplainOutcomeArgs struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BidHash     common.Hash    `json:"bidHash"`
+	Signature   hexutil.Bytes  `json:"signature"`
+}
+
+// ExplainOutcomeHash returns the hash to be signed by the builder to query the outcome,
+// it is domain separated from the bid hash so that a bid signature can't be reused.
+func (args *ExplainOutcomeArgs) ExplainOutcomeHash() common.Hash {
+	return rlpHash([]interface{}{"mev_explainOutcome", uint64(args.BlockNumber), args.BidHash})
+}
+
+// EcrecoverSender recovers the builder who signed the arguments.
+func (args *ExplainOutcomeArgs) EcrecoverSender() (common.Address, error) {
+	pk, err := crypto.SigToPub(args.ExplainOutcomeHash().Bytes(), args.Signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return crypto.PubkeyToAddress(*pk), nil
+}
+
+// Sign signs the arguments with the key of the builder, it is the counterpart of EcrecoverSender.
+func (args *ExplainOutcomeArgs) Sign(key *ecdsa.PrivateKey) error {
+	sig, err := crypto.Sign(args.ExplainOutcomeHash().Bytes(), key)
+	if err != nil {
+		return err
+	}
+
+	args.Signature = sig
+	return nil
+}
+
+// BidResult is the result of the comparison between a simulated bid and the best bid.
+type BidResult struct {
+	Builder    common.Address `json:"builder"`
+	BidHash    common.Hash    `json:"bidHash"`
+	Win        bool           `json:"win"`
+	Contribute *big.Int       `json:"contribute"` // total reward of the bid to the validator
+	Elapsed    time.Duration  `json:"elapsed"`    // from the start of the simulation
+}
+
+// BuilderHealth is the connectivity status of a builder endpoint.
+type BuilderHealth struct {
+	Builder   common.Address `json:"builder"`
+	ViaSentry bool           `json:"viaSentry"` // the builder is reached through the sentry
+	Healthy   bool           `json:"healthy"`
+	Failures  uint64         `json:"failures"` // consecutive failures
+	LastCheck time.Time      `json:"lastCheck"`
+	LastError string         `json:"lastError,omitempty"`
+}
+
+// BidQueueStatus is the depth of the queue of the bids waiting to be compared with the best
+// bid, builders could throttle their bids as it fills up.
+type BidQueueStatus struct {
+	Depth    int `json:"depth"`
+	Capacity int `json:"capacity"` // the bids are rejected as busy once the depth reaches it
+}
+
+// The status of a bid of the blocks not sealed yet.
+const (
+	BidStatusQueued     = "queued"     // waiting for the comparison or the simulation
+	BidStatusSimulating = "simulating" // in the simulation
+	BidStatusSimulated  = "simulated"  // simulated, but not the best bid
+	BidStatusBest       = "best"       // the best bid of its parent
+	BidStatusFailed     = "failed"     // the simulation failed
+	BidStatusRejected   = "rejected"   // rejected before the simulation
+)
+
+// BidStatus is the status of a pending bid in the bid simulator.
+type BidStatus struct {
+	BidHash     common.Hash `json:"bidHash"`
+	BlockNumber uint64      `json:"blockNumber"`
+	Status      string      `json:"status"`
+	Reason      string      `json:"reason,omitempty"` // set if failed or rejected
+}
+
+// BidStatusArgs is the arguments of mev_bidStatus, Signature is the builder's signature
+// of BidStatusHash.
+type BidStatusArgs struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BidHash     common.Hash    `json:"bidHash"`
+	Signature   hexutil.Bytes  `json:"signature"`
+}
+
+// BidStatusHash returns the hash to be signed by the builder to query the status.
+func (args *BidStatusArgs) BidStatusHash() common.Hash {
+	return rlpHash([]interface{}{"mev_bidStatus", uint64(args.BlockNumber), args.BidHash})
+}
+
+// EcrecoverSender recovers the builder who signed the arguments.
+func (args *BidStatusArgs) EcrecoverSender() (common.Address, error) {
+	pk, err := crypto.SigToPub(args.BidStatusHash().Bytes(), args.Signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return crypto.PubkeyToAddress(*pk), nil
+}
+
+// Sign signs the arguments with the key of the builder, it is the counterpart of EcrecoverSender.
+func (args *BidStatusArgs) Sign(key *ecdsa.PrivateKey) error {
+	sig, err := crypto.Sign(args.BidStatusHash().Bytes(), key)
+	if err != nil {
+		return err
+	}
+
+	args.Signature = sig
+	return nil
+}
+
+// PendingBidsArgs selects the bids of mev_pendingBids, either by the parent hash or by the
+// number of the block, whose parent is the canonical one then.
+type PendingBidsArgs struct {
+	BlockNumber *hexutil.Uint64 `json:"blockNumber,omitempty"`
+	ParentHash  *common.Hash    `json:"parentHash,omitempty"`
+}
+
+// PendingBids is the snapshot of the bids of a parent in the bid simulator.
+type PendingBids struct {
+	BlockNumber   uint64                          `json:"blockNumber"`
+	ParentHash    common.Hash                     `json:"parentHash"`
+	Bids          map[common.Address][]*BidStatus `json:"bids"` // builder -> the pending bids of the block
+	SimulatingBid *common.Hash                    `json:"simulatingBid,omitempty"`
+	BestBid       *BestBidInfo                    `json:"bestBid,omitempty"`
+}
+
+// PendingBidStats is the timing of the bids of a builder for a block.
+type PendingBidStats struct {
+	Builder   common.Address `json:"builder"`
+	Count     uint64         `json:"count"`
+	FirstSeen time.Time      `json:"firstSeen"`
+	LastSeen  time.Time      `json:"lastSeen"`
+}
+
+// BuilderInfo is a builder in the builder list of the validator.
+type BuilderInfo struct {
+	Address   common.Address `json:"address"`
+	ViaSentry bool           `json:"viaSentry"` // the builder is reached through the shared sentry client
+	Dialed    bool           `json:"dialed"`    // the builder has a client, either the sentry or a dedicated one
+	URL       string         `json:"url,omitempty"`
+}
+
+// MevStoreDump is the JSON dump of a persistent store of the miner, used to move the
+// data between nodes.
+type MevStoreDump struct {
+	Store   string            `json:"store"`
+	Version uint64            `json:"version"` // schema version of the records
+	Records []*MevStoreRecord `json:"records"`
+}
+
+// MevStoreRecord is a raw key-value record of a persistent store of the miner.
+type MevStoreRecord struct {
+	Key   hexutil.Bytes `json:"key"`
+	Value hexutil.Bytes `json:"value"`
+}
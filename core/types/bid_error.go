@@ -1,6 +1,14 @@
 package types
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
 
 const (
 	InvalidBidParamError = -38001
@@ -8,12 +16,27 @@ const (
 	MevNotRunningError   = -38003
 	MevBusyError         = -38004
 	MevNotInTurnError    = -38005
+	BidDiscardedError    = -38006
+	TooManyBidsError     = -38007
+	BidExistsError       = -38008
+	UnknownParentError   = -38009
+	BlockNumberError     = -38010
+	BidTooLateError      = -38011
+	TooManyBlobsError    = -38012
+	FeeTooHighError      = -38013
 )
 
 var (
 	ErrMevNotRunning = newBidError(errors.New("the validator stop accepting bids for now, try again later"), MevNotRunningError)
 	ErrMevBusy       = newBidError(errors.New("the validator is working on too many bids, try again later"), MevBusyError)
 	ErrMevNotInTurn  = newBidError(errors.New("the validator is not in-turn to propose currently, try again later"), MevNotInTurnError)
+
+	// ErrMevShuttingDown shares the code of ErrMevNotRunning, the builders retry it the same way
+	ErrMevShuttingDown = newBidError(errors.New("the validator is shutting down, try again later"), MevNotRunningError)
+
+	ErrBidDiscardedWorse = newBidError(errors.New("bid is discarded"), BidDiscardedError)
+	ErrTooManyBids       = newBidError(errors.New("too many bids"), TooManyBidsError)
+	ErrBidAlreadyExists  = newBidError(errors.New("bid already exists"), BidExistsError)
 )
 
 // bidError is an API error that encompasses an invalid bid with JSON error
@@ -37,9 +60,99 @@ func NewInvalidPayBidTxError(message string) *bidError {
 	return newBidError(errors.New(message), InvalidPayBidTxError)
 }
 
+// NewUnknownParentError rejects the bid whose parent is not known by the validator.
+func NewUnknownParentError(parentHash common.Hash) *bidError {
+	return newBidError(fmt.Errorf("unknown parent %v", parentHash), UnknownParentError)
+}
+
+// NewBlockNumberError rejects the bid whose block number is not the next of its parent.
+func NewBlockNumberError(blockNumber, expected uint64) *bidError {
+	return newBidError(fmt.Errorf("block number %d mismatches the parent, expected %d", blockNumber, expected), BlockNumberError)
+}
+
+// NewBidTooLateError rejects the bid arriving after the cutoff of the simulations for its parent,
+// the cutoff is told so the builders can calibrate their send timing.
+func NewBidTooLateError(cutoff time.Time, late time.Duration) *bidError {
+	return newBidError(fmt.Errorf("bid too late, the cutoff is %s, appeared %s later",
+		cutoff.Format(time.RFC3339Nano), common.PrettyDuration(late)), BidTooLateError)
+}
+
+// NewTooManyBlobsError rejects the bid whose blob txs carry more blobs than a block can take.
+func NewTooManyBlobsError(blobs, maxBlobs int) *bidError {
+	return newBidError(fmt.Errorf("too many blobs %d, a block takes %d at most", blobs, maxBlobs), TooManyBlobsError)
+}
+
+// NewFeeTooHighError rejects the bid declaring a fee over the ceiling of the validator, the fee
+// names the declared one, gas or nontaxable.
+func NewFeeTooHighError(fee string, declared, ceil *big.Int) *bidError {
+	return newBidError(fmt.Errorf("declared %s fee %v exceeds the ceiling %v", fee, declared, ceil), FeeTooHighError)
+}
+
 func newBidError(err error, code int) *bidError {
 	return &bidError{
 		error: err,
 		code:  code,
 	}
 }
+
+// BidRejectionError is a structured rejection of a bid, its fields are returned as the
+// error data over RPC, so builders needn't parse the message. It matches the sentinel
+// error with the same code with errors.Is.
+type BidRejectionError struct {
+	Code              int
+	CurrentBestReward *big.Int // reward of the current best bid after BEP95, the bid must beat it
+	Message           string
+	Latency           *BidLatency // time spent by the bid before the rejection, nil if unknown
+	Quota             *BidQuota   // the bids the builder could still send for the block, nil if unknown
+}
+
+func NewBidDiscardedWorseError(currentBestReward *big.Int, message string) *BidRejectionError {
+	return &BidRejectionError{
+		Code:              BidDiscardedError,
+		CurrentBestReward: currentBestReward,
+		Message:           message,
+	}
+}
+
+// NewTooManyBidsError rejects a bid of the builder which used up its quota of the block.
+func NewTooManyBidsError(quota *BidQuota) *BidRejectionError {
+	return &BidRejectionError{
+		Code:    TooManyBidsError,
+		Message: ErrTooManyBids.Error(),
+		Quota:   quota,
+	}
+}
+
+func (e *BidRejectionError) Error() string {
+	return e.Message
+}
+
+// ErrorCode returns the JSON error code for a rejected bid.
+func (e *BidRejectionError) ErrorCode() int {
+	return e.Code
+}
+
+// ErrorData returns the machine-readable fields of the rejection.
+func (e *BidRejectionError) ErrorData() interface{} {
+	return &BidRejectionData{
+		Code:              e.Code,
+		CurrentBestReward: (*hexutil.Big)(e.CurrentBestReward),
+		Message:           e.Message,
+		Latency:           e.Latency,
+		Quota:             e.Quota,
+	}
+}
+
+func (e *BidRejectionError) Is(target error) bool {
+	t, ok := target.(*bidError)
+	return ok && t.code == e.Code
+}
+
+// BidRejectionData is the JSON error data of BidRejectionError.
+type BidRejectionData struct {
+	Code              int          `json:"code"`
+	CurrentBestReward *hexutil.Big `json:"currentBestReward,omitempty"`
+	Message           string       `json:"message"`
+	Latency           *BidLatency  `json:"latency,omitempty"`
+	Quota             *BidQuota    `json:"quota,omitempty"`
+}
@@ -0,0 +1,29 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestBidRejectionError(t *testing.T) {
+	err := error(NewBidDiscardedWorseError(big.NewInt(1000), "bid is discarded"))
+
+	if !errors.Is(err, ErrBidDiscardedWorse) {
+		t.Fatalf("expected to match ErrBidDiscardedWorse")
+	}
+	if errors.Is(err, ErrTooManyBids) {
+		t.Fatalf("unexpected match with ErrTooManyBids")
+	}
+
+	var rejection *BidRejectionError
+	if !errors.As(err, &rejection) || rejection.ErrorCode() != BidDiscardedError {
+		t.Fatalf("unexpected rejection: %v", err)
+	}
+
+	enc, _ := json.Marshal(rejection.ErrorData())
+	if want := `{"code":-38006,"currentBestReward":"0x3e8","message":"bid is discarded"}`; string(enc) != want {
+		t.Fatalf("unexpected error data %s, want %s", enc, want)
+	}
+}
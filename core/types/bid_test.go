@@ -0,0 +1,299 @@
+package types
+
+import (
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// newTestRawBid returns a raw bid of n txs, and the known copies of the first known txs
+// with the senders cached, as they are in the txpool.
+func newTestRawBid(tb testing.TB, n, known int, signer Signer) (*RawBid, map[common.Hash]*Transaction) {
+	key, _ := crypto.GenerateKey()
+
+	var (
+		to      = common.HexToAddress("0x2")
+		rawBid  = &RawBid{Txs: make([]hexutil.Bytes, n)}
+		knownTx = make(map[common.Hash]*Transaction, known)
+	)
+	for i := 0; i < n; i++ {
+		tx := MustSignNewTx(key, signer, &DynamicFeeTx{
+			ChainID:   signer.ChainID(),
+			Nonce:     uint64(i),
+			Gas:       21000,
+			GasFeeCap: big.NewInt(1),
+			To:        &to,
+		})
+		enc, err := tx.MarshalBinary()
+		if err != nil {
+			tb.Fatalf("failed to encode tx: %v", err)
+		}
+		rawBid.Txs[i] = enc
+
+		if i < known {
+			if _, err = Sender(signer, tx); err != nil {
+				tb.Fatalf("failed to recover sender: %v", err)
+			}
+			knownTx[tx.Hash()] = tx
+		}
+	}
+
+	return rawBid, knownTx
+}
+
+func TestDecodeTxsWithKnownTxs(t *testing.T) {
+	signer := LatestSignerForChainID(big.NewInt(1))
+	rawBid, known := newTestRawBid(t, 10, 5, signer)
+
+	txs, err := rawBid.DecodeTxsWithKnownTxs(signer, func(hash common.Hash) *Transaction { return known[hash] })
+	if err != nil {
+		t.Fatalf("failed to decode txs: %v", err)
+	}
+
+	for i, tx := range txs {
+		sc, ok := tx.from.Load().(sigCache)
+		if !ok {
+			t.Fatalf("sender of tx %d is not cached", i)
+		}
+		if knownTx := known[tx.Hash()]; knownTx != nil && sc != knownTx.from.Load().(sigCache) {
+			t.Fatalf("sender of known tx %d is not inherited", i)
+		}
+	}
+
+	// the sender cached with another signer is recovered again
+	other := NewLondonSigner(big.NewInt(1))
+	if inheritSender(new(Transaction), txs[0], other) {
+		t.Fatalf("sender cached with another signer should not be inherited")
+	}
+}
+
+// BenchmarkDecodeTxs measures the decoding of a 150-tx bid, in which the senders are recovered,
+// with 90% of the txs in the txpool or none.
+func BenchmarkDecodeTxs(b *testing.B) {
+	signer := LatestSignerForChainID(big.NewInt(1))
+
+	for _, bench := range []struct {
+		name  string
+		known int
+	}{
+		{"known=0%", 0},
+		{"known=90%", 135},
+	} {
+		rawBid, known := newTestRawBid(b, 150, bench.known, signer)
+		lookup := func(hash common.Hash) *Transaction { return known[hash] }
+
+		b.Run(bench.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := rawBid.DecodeTxsWithKnownTxs(signer, lookup); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestRawBidOptional(t *testing.T) {
+	signer := LatestSignerForChainID(big.NewInt(1))
+	rawBid, _ := newTestRawBid(t, 2, 0, signer)
+	rawBid.GasFee, rawBid.BuilderFee = big.NewInt(1), big.NewInt(0)
+
+	// the bids without optional txs keep the hash signed by the builders unaware of the field
+	legacy := struct {
+		BlockNumber  uint64
+		ParentHash   common.Hash
+		Txs          []hexutil.Bytes
+		UnRevertible []common.Hash
+		GasUsed      uint64
+		GasFee       *big.Int
+		BuilderFee   *big.Int
+		Timestamp    uint64 `rlp:"optional"`
+		Expiry       uint64 `rlp:"optional"`
+	}{Txs: rawBid.Txs, GasFee: rawBid.GasFee, BuilderFee: rawBid.BuilderFee}
+	if rawBid.Hash() != rlpHash(&legacy) {
+		t.Fatalf("hash of the bid without optional txs changed")
+	}
+
+	txs, err := rawBid.DecodeTxs(signer)
+	if err != nil {
+		t.Fatalf("failed to decode txs: %v", err)
+	}
+	rawBid.hash = atomic.Value{}
+	rawBid.Optional = []common.Hash{txs[0].Hash()}
+	if rawBid.Hash() == rlpHash(&legacy) {
+		t.Fatalf("optional txs are not covered by the hash")
+	}
+
+	args := &BidArgs{RawBid: rawBid}
+	bid, err := args.ToBid(common.Address{}, signer)
+	if err != nil {
+		t.Fatalf("failed to convert bid: %v", err)
+	}
+	if !bid.Optional.Contains(txs[0].Hash()) || bid.Optional.Contains(txs[1].Hash()) {
+		t.Fatalf("optional txs mismatch, have %v", bid.Optional)
+	}
+
+	rawBid.UnRevertible = []common.Hash{txs[0].Hash()}
+	if _, err = args.ToBid(common.Address{}, signer); err == nil {
+		t.Fatalf("tx both optional and unRevertible is accepted")
+	}
+}
+
+func TestRawBidParents(t *testing.T) {
+	signer := LatestSignerForChainID(big.NewInt(1))
+	rawBid, _ := newTestRawBid(t, 1, 0, signer)
+	rawBid.GasFee, rawBid.BuilderFee = big.NewInt(1), big.NewInt(0)
+
+	var (
+		parent  = common.Hash{0x01}
+		sibling = common.Hash{0x02}
+	)
+	rawBid.ParentHash = parent
+	if parents := rawBid.Parents(); len(parents) != 1 || parents[0] != parent {
+		t.Fatalf("unexpected parents %v, want %v", parents, parent)
+	}
+	single := rawBid.Hash()
+
+	rawBid.hash = atomic.Value{}
+	rawBid.ParentHashes = []common.Hash{parent, sibling}
+	if rawBid.Hash() == single {
+		t.Fatalf("parent list is not covered by the hash")
+	}
+	if !rawBid.HasParent(sibling) || rawBid.HasParent(common.Hash{0x03}) {
+		t.Fatalf("unexpected parents %v", rawBid.Parents())
+	}
+
+	bid, err := (&BidArgs{RawBid: rawBid}).ToBid(common.Address{}, signer)
+	if err != nil {
+		t.Fatalf("failed to convert bid: %v", err)
+	}
+	variant := bid.WithParent(sibling)
+	if variant.ParentHash != sibling || bid.ParentHash != parent {
+		t.Fatalf("unexpected parents %v and %v", variant.ParentHash, bid.ParentHash)
+	}
+	if variant.Hash() != bid.Hash() {
+		t.Fatalf("variant hash %v differs from the bid %v", variant.Hash(), bid.Hash())
+	}
+}
+
+func TestBidCacheSenders(t *testing.T) {
+	signer := LatestSignerForChainID(big.NewInt(1))
+	rawBid, _ := newTestRawBid(t, 3, 0, signer)
+	rawBid.GasFee = big.NewInt(1)
+
+	bid, err := (&BidArgs{RawBid: rawBid}).ToBid(common.Address{}, signer)
+	if err != nil {
+		t.Fatalf("failed to convert bid: %v", err)
+	}
+
+	// the senders recovered at the decoding are reused
+	if recovered := bid.CacheSenders(signer); recovered != 0 {
+		t.Fatalf("unexpected recovered senders %d, want 0", recovered)
+	}
+
+	// the simulation signer differing from the decoding one recovers the senders once
+	simSigner := NewLondonSigner(big.NewInt(1))
+	if recovered := bid.CacheSenders(simSigner); recovered != 3 {
+		t.Fatalf("unexpected recovered senders %d, want 3", recovered)
+	}
+	if recovered := bid.CacheSenders(simSigner); recovered != 0 {
+		t.Fatalf("senders recovered again: %d", recovered)
+	}
+}
+
+// TestRawBidCanonicalHash pins the canonical hash to golden vectors, so the builders in other
+// languages can check their implementations against them.
+func TestRawBidCanonicalHash(t *testing.T) {
+	tests := []struct {
+		rawBid *RawBid
+		want   common.Hash
+	}{
+		{
+			rawBid: &RawBid{
+				BlockNumber: 1,
+				ParentHash:  common.HexToHash("0x01"),
+				GasUsed:     21000,
+				GasFee:      big.NewInt(1),
+			},
+			want: common.HexToHash("0x84f1686927547c82947324738fd0ec5626bb357ddb6348422655c7eb5f012166"),
+		},
+		{
+			// the parents and the tx sets are sorted, the timestamp is excluded
+			rawBid: &RawBid{
+				BlockNumber:  100,
+				ParentHash:   common.HexToHash("0x02"),
+				ParentHashes: []common.Hash{common.HexToHash("0x02"), common.HexToHash("0x01")},
+				Txs:          []hexutil.Bytes{{0x01, 0x02}, {0x03}},
+				UnRevertible: []common.Hash{common.HexToHash("0xbb"), common.HexToHash("0xaa")},
+				Optional:     []common.Hash{common.HexToHash("0xcc")},
+				GasUsed:      42000,
+				GasFee:       big.NewInt(1000000),
+				BuilderFee:   big.NewInt(1000),
+				Timestamp:    1699999999000,
+				Expiry:       1700000000000,
+			},
+			want: common.HexToHash("0xd4efa85463cbf61df94ce577fa1f48f7395d63e09ce852590c78c1579effae13"),
+		},
+	}
+
+	for i, tt := range tests {
+		if have := tt.rawBid.CanonicalHash(); have != tt.want {
+			t.Errorf("test %d: canonical hash mismatch, have %v want %v", i, have, tt.want)
+		}
+
+		// the declared version selects the hash identifying the bid
+		if tt.rawBid.Hash() == tt.want {
+			t.Errorf("test %d: legacy bid identified by the canonical hash", i)
+		}
+		versioned := *tt.rawBid
+		versioned.hash, versioned.HashVersion = atomic.Value{}, BidHashCanonical
+		if versioned.Hash() != tt.want {
+			t.Errorf("test %d: canonical bid identified by %v", i, versioned.Hash())
+		}
+	}
+}
+
+func TestBidStateOverrideValidate(t *testing.T) {
+	slots := func(n int) *map[common.Hash]common.Hash {
+		m := make(map[common.Hash]common.Hash, n)
+		for i := 0; i < n; i++ {
+			m[common.BigToHash(big.NewInt(int64(i)))] = common.Hash{0x01}
+		}
+		return &m
+	}
+	code := hexutil.Bytes(make([]byte, MaxBidOverrideCode+1))
+
+	tooManyAccounts := make(BidStateOverride)
+	for i := 0; i <= MaxBidOverrideAccounts; i++ {
+		tooManyAccounts[common.BigToAddress(big.NewInt(int64(i)))] = BidOverrideAccount{}
+	}
+
+	for name, overrides := range map[string]BidStateOverride{
+		"too many accounts": tooManyAccounts,
+		"too many slots": {
+			common.HexToAddress("0x1"): {State: slots(MaxBidOverrideSlots / 2)},
+			common.HexToAddress("0x2"): {StateDiff: slots(MaxBidOverrideSlots/2 + 1)},
+		},
+		"code too large":      {common.HexToAddress("0x1"): {Code: &code}},
+		"state and stateDiff": {common.HexToAddress("0x1"): {State: slots(1), StateDiff: slots(1)}},
+		"balance overflow":    {common.HexToAddress("0x1"): {Balance: (*hexutil.Big)(new(big.Int).Lsh(common.Big1, 256))}},
+	} {
+		if err := overrides.Validate(); err == nil {
+			t.Errorf("%s: expected the overrides to be rejected", name)
+		}
+	}
+
+	overrides := BidStateOverride{
+		common.HexToAddress("0x1"): {State: slots(MaxBidOverrideSlots / 2)},
+		common.HexToAddress("0x2"): {StateDiff: slots(MaxBidOverrideSlots / 2), Balance: (*hexutil.Big)(common.Big1)},
+	}
+	if err := overrides.Validate(); err != nil {
+		t.Fatalf("failed to validate the overrides within the limits: %v", err)
+	}
+	if err := BidStateOverride(nil).Validate(); err != nil {
+		t.Fatalf("failed to validate no overrides: %v", err)
+	}
+}
@@ -507,6 +507,74 @@ func (b *EthAPIBackend) BestBidGasFee(parentHash common.Hash) *big.Int {
 	return b.Miner().BestPackedBlockReward(parentHash)
 }
 
-func (b *EthAPIBackend) MinerInTurn() bool {
-	return b.Miner().InTurn()
+func (b *EthAPIBackend) BidHistory(args *types.BidHistoryArgs) (*types.BidHistoryPage, error) {
+	return b.Miner().BidHistory(args)
+}
+
+func (b *EthAPIBackend) BlockOrigin(blockHash common.Hash) (*types.BlockOrigin, error) {
+	return b.Miner().BlockOrigin(blockHash)
+}
+
+func (b *EthAPIBackend) MevRevenueReport(args *types.MevRevenueReportArgs) ([]*types.MevRevenueGroup, error) {
+	return b.Miner().MevRevenueReport(args)
+}
+
+func (b *EthAPIBackend) BestBidInfo(parentHash common.Hash) *types.BestBidInfo {
+	return b.Miner().BestBidInfo(parentHash)
+}
+
+func (b *EthAPIBackend) BuilderStats(builder common.Address) *types.BuilderStats {
+	return b.Miner().BuilderStats(builder)
+}
+
+func (b *EthAPIBackend) BuilderStatsSnapshot() []*types.BuilderStats {
+	return b.Miner().BuilderStatsSnapshot()
+}
+
+func (b *EthAPIBackend) ExplainOutcome(blockNumber uint64, bidHash common.Hash) (*types.BidOutcome, error) {
+	return b.Miner().ExplainOutcome(blockNumber, bidHash)
+}
+
+func (b *EthAPIBackend) BidStatus(blockNumber uint64, builder common.Address, bidHash common.Hash) (*types.BidStatus, error) {
+	return b.Miner().BidStatus(blockNumber, builder, bidHash)
+}
+
+func (b *EthAPIBackend) PendingBids(args *types.PendingBidsArgs) (*types.PendingBids, error) {
+	return b.Miner().PendingBids(args)
+}
+
+func (b *EthAPIBackend) PendingStats(blockNumber uint64) []*types.PendingBidStats {
+	return b.Miner().PendingStats(blockNumber)
+}
+
+func (b *EthAPIBackend) MuteBuilder(builder common.Address, duration time.Duration) error {
+	return b.Miner().MuteBuilder(builder, duration)
+}
+
+func (b *EthAPIBackend) UnmuteBuilder(builder common.Address) error {
+	return b.Miner().UnmuteBuilder(builder)
+}
+
+func (b *EthAPIBackend) BuilderHealth() []*types.BuilderHealth {
+	return b.Miner().BuilderHealth()
+}
+
+func (b *EthAPIBackend) ExportMevStore(name string) (*types.MevStoreDump, error) {
+	return b.Miner().ExportMevStore(name)
+}
+
+func (b *EthAPIBackend) ImportMevStore(dump *types.MevStoreDump) error {
+	return b.Miner().ImportMevStore(dump)
+}
+
+func (b *EthAPIBackend) Builders() []*types.BuilderInfo {
+	return b.Miner().Builders()
+}
+
+func (b *EthAPIBackend) SimulateBid(ctx context.Context, bidArgs *types.BidArgs, overrides types.BidStateOverride) (*types.BidDryRunResult, error) {
+	return b.Miner().SimulateBid(ctx, bidArgs, overrides)
+}
+
+func (b *EthAPIBackend) BidQueueStatus() *types.BidQueueStatus {
+	return b.Miner().BidQueueStatus()
 }
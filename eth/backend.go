@@ -591,6 +591,7 @@ func (s *Ethereum) StartMining() error {
 				return fmt.Errorf("signer missing: %v", err)
 			}
 			parlia.Authorize(eb, wallet.SignData, wallet.SignTx)
+			s.miner.AuthorizeMev(eb, wallet.SignText)
 
 			minerInfo := metrics.Get("miner-info")
 			if minerInfo != nil {
@@ -2,12 +2,17 @@ package ethapi
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
+	"slices"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 // MevAPI implements the interfaces that defined in the BEP-322.
@@ -29,64 +34,195 @@ func (m *MevAPI) SendBid(ctx context.Context, args types.BidArgs) (common.Hash,
 		return common.Hash{}, types.ErrMevNotRunning
 	}
 
-	if !m.b.MinerInTurn() {
-		return common.Hash{}, types.ErrMevNotInTurn
+	if err := m.checkBidArgs(&args); err != nil {
+		return common.Hash{}, err
 	}
 
+	return m.b.SendBid(ctx, &args)
+}
+
+// SimulateBid simulates the bid without sending it to the auction, the bid is checked like
+// SendBid, and the simulation error is returned in the result. The optional state overrides,
+// in the format of eth_call, are applied to the parent state and echoed in the result if the
+// validator enables DryRunStateOverride, SendBid has no such argument so the real bids never
+// carry them.
+func (m *MevAPI) SimulateBid(ctx context.Context, args types.BidArgs, overrides *types.BidStateOverride) (*types.BidDryRunResult, error) {
+	if !m.b.MevRunning() {
+		return nil, types.ErrMevNotRunning
+	}
+
+	if err := m.checkBidArgs(&args); err != nil {
+		return nil, err
+	}
+
+	var override types.BidStateOverride
+	if overrides != nil {
+		override = *overrides
+	}
+
+	return m.b.SimulateBid(ctx, &args, override)
+}
+
+// checkBidArgs checks the arguments of a bid for the next block.
+func (m *MevAPI) checkBidArgs(args *types.BidArgs) error {
 	var (
 		rawBid        = args.RawBid
 		currentHeader = m.b.CurrentHeader()
 	)
 
 	if rawBid == nil {
-		return common.Hash{}, types.NewInvalidBidError("rawBid should not be nil")
+		return types.NewInvalidBidError("rawBid should not be nil")
 	}
 
 	// only support bidding for the next block not for the future block
 	if rawBid.BlockNumber != currentHeader.Number.Uint64()+1 {
-		return common.Hash{}, types.NewInvalidBidError("stale block number or block in future")
+		return types.NewInvalidBidError("stale block number or block in future")
+	}
+
+	if rawBid.HashVersion > types.BidHashCanonical {
+		return types.NewInvalidBidError(fmt.Sprintf("unknown hash version %d", rawBid.HashVersion))
+	}
+
+	if len(rawBid.ParentHashes) > types.MaxBidParents {
+		return types.NewInvalidBidError(
+			fmt.Sprintf("too many parent hashes, expected no more than %d", types.MaxBidParents))
+	}
+
+	if len(rawBid.ParentHashes) > 0 && !slices.Contains(rawBid.ParentHashes, rawBid.ParentHash) {
+		return types.NewInvalidBidError("parent hash is not in parent hashes")
 	}
 
-	if rawBid.ParentHash != currentHeader.Hash() {
-		return common.Hash{}, types.NewInvalidBidError(
+	if !rawBid.HasParent(currentHeader.Hash()) {
+		return types.NewInvalidBidError(
 			fmt.Sprintf("non-aligned parent hash: %v", currentHeader.Hash()))
 	}
 
 	if rawBid.GasFee == nil || rawBid.GasFee.Cmp(common.Big0) == 0 || rawBid.GasUsed == 0 {
-		return common.Hash{}, types.NewInvalidBidError("empty gasFee or empty gasUsed")
+		return types.NewInvalidBidError("empty gasFee or empty gasUsed")
 	}
 
 	if rawBid.BuilderFee != nil {
 		builderFee := rawBid.BuilderFee
 		if builderFee.Cmp(common.Big0) < 0 {
-			return common.Hash{}, types.NewInvalidBidError("builder fee should not be less than 0")
+			return types.NewInvalidBidError("builder fee should not be less than 0")
 		}
 
 		if builderFee.Cmp(rawBid.GasFee) >= 0 {
-			return common.Hash{}, types.NewInvalidBidError("builder fee must be less than gas fee")
+			return types.NewInvalidBidError("builder fee must be less than gas fee")
 		}
 	}
 
 	if len(args.PayBidTx) == 0 || args.PayBidTxGasUsed == 0 {
-		return common.Hash{}, types.NewInvalidPayBidTxError("payBidTx and payBidTxGasUsed are must-have")
+		return types.NewInvalidPayBidTxError("payBidTx and payBidTxGasUsed are must-have")
 	}
 
 	if args.PayBidTxGasUsed > params.PayBidTxGasLimit {
-		return common.Hash{}, types.NewInvalidBidError(
+		return types.NewInvalidBidError(
 			fmt.Sprintf("transfer tx gas used must be no more than %v", params.PayBidTxGasLimit))
 	}
 
-	return m.b.SendBid(ctx, &args)
+	return nil
 }
 
 func (m *MevAPI) BestBidGasFee(_ context.Context, parentHash common.Hash) *big.Int {
 	return m.b.BestBidGasFee(parentHash)
 }
 
+// BestBid returns the summary of the current best bid for the given parent hash,
+// or nil if there is no best bid yet.
+func (m *MevAPI) BestBid(_ context.Context, parentHash common.Hash) *types.BestBidInfo {
+	return m.b.BestBidInfo(parentHash)
+}
+
 func (m *MevAPI) Params() *types.MevParams {
 	return m.b.MevParams()
 }
 
+// RevenueReport returns the MEV revenue of the proposed blocks within the block range
+// or the time range, grouped by day or epoch.
+func (m *MevAPI) RevenueReport(args types.MevRevenueReportArgs) ([]*types.MevRevenueGroup, error) {
+	return m.b.MevRevenueReport(&args)
+}
+
+// BidHistory returns the outcomes of the recent sealed blocks newest first, including the
+// winner, the reward and the competition of each block, optionally filtered by the builder.
+func (m *MevAPI) BidHistory(args types.BidHistoryArgs) (*types.BidHistoryPage, error) {
+	return m.b.BidHistory(&args)
+}
+
+// BlockOrigin returns whether the block is built from a bid, with the builder, the bid and
+// the reward, or locally. It returns nil if the block is not sealed by the validator within
+// the retained window.
+func (m *MevAPI) BlockOrigin(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.BlockOrigin, error) {
+	header, err := m.b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if header == nil || err != nil {
+		return nil, err
+	}
+
+	return m.b.BlockOrigin(header.Hash())
+}
+
+// BuilderStats returns the runtime statistics of the builder measured by the validator,
+// or nil if the validator has not received any bid from it.
+func (m *MevAPI) BuilderStats(builder common.Address) *types.BuilderStats {
+	return m.b.BuilderStats(builder)
+}
+
+// AllBuilderStats returns the runtime statistics of all the builders known by the validator.
+func (m *MevAPI) AllBuilderStats() []*types.BuilderStats {
+	return m.b.BuilderStatsSnapshot()
+}
+
+// ExplainOutcome explains why the bid won or lost, the arguments must be signed by the
+// builder of the bid. Admins could query any bid with mev_adminExplainOutcome instead.
+func (m *MevAPI) ExplainOutcome(args types.ExplainOutcomeArgs) (*types.BidOutcome, error) {
+	builder, err := args.EcrecoverSender()
+	if err != nil {
+		return nil, types.NewInvalidBidError(fmt.Sprintf("invalid signature:%v", err))
+	}
+
+	outcome, err := m.b.ExplainOutcome(uint64(args.BlockNumber), args.BidHash)
+	if err != nil {
+		return nil, err
+	}
+
+	// don't reveal the existence of the bid to others
+	if outcome.Decision.Builder != builder {
+		return nil, errors.New("no decision record of the bid")
+	}
+
+	return outcome, nil
+}
+
+// BidStatus returns the status of a pending bid of the block not sealed yet, the arguments
+// must be signed by the builder of the bid. The bids of the sealed blocks are explained by
+// mev_explainOutcome instead.
+func (m *MevAPI) BidStatus(args types.BidStatusArgs) (*types.BidStatus, error) {
+	builder, err := args.EcrecoverSender()
+	if err != nil {
+		return nil, types.NewInvalidBidError(fmt.Sprintf("invalid signature:%v", err))
+	}
+
+	return m.b.BidStatus(uint64(args.BlockNumber), builder, args.BidHash)
+}
+
+// BuilderHealth returns the connectivity status of the builders with endpoints.
+func (m *MevAPI) BuilderHealth() []*types.BuilderHealth {
+	return m.b.BuilderHealth()
+}
+
+// Builders returns the builders in the builder list, along with whether each one is
+// reached through the sentry or a dedicated client, and its configured url.
+func (m *MevAPI) Builders() []*types.BuilderInfo {
+	return m.b.Builders()
+}
+
+// BidQueue returns the depth of the queue of the bids waiting to be compared with the best
+// bid, the bids are rejected as busy once it is full.
+func (m *MevAPI) BidQueue() *types.BidQueueStatus {
+	return m.b.BidQueueStatus()
+}
+
 func (m *MevAPI) HasBuilder(builder common.Address) bool {
 	return m.b.HasBuilder(builder)
 }
@@ -95,3 +231,59 @@ func (m *MevAPI) HasBuilder(builder common.Address) bool {
 func (m *MevAPI) Running() bool {
 	return m.b.MevRunning()
 }
+
+// MevAdminAPI offers the mev methods for the validator operators, it is only served
+// on the authenticated endpoint.
+type MevAdminAPI struct {
+	b Backend
+}
+
+// NewMevAdminAPI creates a new MevAdminAPI.
+func NewMevAdminAPI(b Backend) *MevAdminAPI {
+	return &MevAdminAPI{b}
+}
+
+// AdminExplainOutcome explains why the bid of any builder won or lost.
+func (m *MevAdminAPI) AdminExplainOutcome(blockNumber hexutil.Uint64, bidHash common.Hash) (*types.BidOutcome, error) {
+	return m.b.ExplainOutcome(uint64(blockNumber), bidHash)
+}
+
+// PendingBids returns the pending bids of each builder for the block selected by the parent
+// hash or the block number, along with the simulating bid and the best bid of the parent.
+// It defaults to the block on top of the current head.
+func (m *MevAdminAPI) PendingBids(args types.PendingBidsArgs) (*types.PendingBids, error) {
+	return m.b.PendingBids(&args)
+}
+
+// PendingStats returns the count of the bids and the time the first and the last one was
+// seen for each builder of the block, to tell the latency of the builders.
+func (m *MevAdminAPI) PendingStats(blockNumber hexutil.Uint64) []*types.PendingBidStats {
+	return m.b.PendingStats(uint64(blockNumber))
+}
+
+// MuteBuilder rejects the bids of the builder for the duration, like "10m", the builder is
+// kept along with its endpoint and enabled again once the duration is over.
+func (m *MevAdminAPI) MuteBuilder(builder common.Address, duration string) error {
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return err
+	}
+	return m.b.MuteBuilder(builder, d)
+}
+
+// UnmuteBuilder enables the builder muted by mev_muteBuilder or for its simulation failures.
+func (m *MevAdminAPI) UnmuteBuilder(builder common.Address) error {
+	return m.b.UnmuteBuilder(builder)
+}
+
+// ExportStore dumps the records of the persistent mev store ("history" or "builders") as JSON,
+// so that operators can move the data to another node.
+func (m *MevAdminAPI) ExportStore(name string) (*types.MevStoreDump, error) {
+	return m.b.ExportMevStore(name)
+}
+
+// ImportStore imports the records dumped by mev_exportStore, the records of an older
+// schema version are migrated before written.
+func (m *MevAdminAPI) ImportStore(dump types.MevStoreDump) error {
+	return m.b.ImportMevStore(&dump)
+}
@@ -663,7 +663,60 @@ func (b *testBackend) RemoveBuilder(builder common.Address) error
 func (b *testBackend) SendBid(ctx context.Context, bid *types.BidArgs) (common.Hash, error) {
 	panic("implement me")
 }
-func (b *testBackend) MinerInTurn() bool { return false }
+func (b *testBackend) ImportMevStore(dump *types.MevStoreDump) error {
+	return nil
+}
+func (b *testBackend) ExportMevStore(name string) (*types.MevStoreDump, error) {
+	return nil, nil
+}
+func (b *testBackend) BuilderHealth() []*types.BuilderHealth {
+	return nil
+}
+func (b *testBackend) BidQueueStatus() *types.BidQueueStatus {
+	return nil
+}
+func (b *testBackend) SimulateBid(ctx context.Context, bidArgs *types.BidArgs, overrides types.BidStateOverride) (*types.BidDryRunResult, error) {
+	panic("implement me")
+}
+func (b *testBackend) Builders() []*types.BuilderInfo {
+	return nil
+}
+func (b *testBackend) ExplainOutcome(blockNumber uint64, bidHash common.Hash) (*types.BidOutcome, error) {
+	return nil, nil
+}
+func (b *testBackend) BidStatus(blockNumber uint64, builder common.Address, bidHash common.Hash) (*types.BidStatus, error) {
+	return nil, nil
+}
+func (b *testBackend) PendingBids(args *types.PendingBidsArgs) (*types.PendingBids, error) {
+	return nil, nil
+}
+func (b *testBackend) PendingStats(blockNumber uint64) []*types.PendingBidStats {
+	return nil
+}
+func (b *testBackend) MuteBuilder(builder common.Address, duration time.Duration) error {
+	return nil
+}
+func (b *testBackend) UnmuteBuilder(builder common.Address) error {
+	return nil
+}
+func (b *testBackend) BuilderStatsSnapshot() []*types.BuilderStats {
+	return nil
+}
+func (b *testBackend) BuilderStats(builder common.Address) *types.BuilderStats {
+	return nil
+}
+func (b *testBackend) BestBidInfo(parentHash common.Hash) *types.BestBidInfo {
+	return nil
+}
+func (b *testBackend) BidHistory(args *types.BidHistoryArgs) (*types.BidHistoryPage, error) {
+	return nil, nil
+}
+func (b *testBackend) BlockOrigin(blockHash common.Hash) (*types.BlockOrigin, error) {
+	return nil, nil
+}
+func (b *testBackend) MevRevenueReport(args *types.MevRevenueReportArgs) ([]*types.MevRevenueGroup, error) {
+	return nil, nil
+}
 func (b *testBackend) BestBidGasFee(parentHash common.Hash) *big.Int {
 	//TODO implement me
 	panic("implement me")
@@ -123,8 +123,42 @@ type Backend interface {
 	SendBid(ctx context.Context, bid *types.BidArgs) (common.Hash, error)
 	// BestBidGasFee returns the gas fee of the best bid for the given parent hash.
 	BestBidGasFee(parentHash common.Hash) *big.Int
-	// MinerInTurn returns true if the validator is in turn to propose the block.
-	MinerInTurn() bool
+	// BidHistory returns the outcomes of the recent sealed blocks.
+	BidHistory(args *types.BidHistoryArgs) (*types.BidHistoryPage, error)
+	// BlockOrigin returns whether the recent sealed block is built from a bid or locally.
+	BlockOrigin(blockHash common.Hash) (*types.BlockOrigin, error)
+	// MevRevenueReport returns the MEV revenue of the proposed blocks grouped by day or epoch.
+	MevRevenueReport(args *types.MevRevenueReportArgs) ([]*types.MevRevenueGroup, error)
+	// BestBidInfo returns the summary of the best bid for the given parent hash.
+	BestBidInfo(parentHash common.Hash) *types.BestBidInfo
+	// BuilderStats returns the runtime statistics of the builder.
+	BuilderStats(builder common.Address) *types.BuilderStats
+	// BuilderStatsSnapshot returns the runtime statistics of all the known builders.
+	BuilderStatsSnapshot() []*types.BuilderStats
+	// ExplainOutcome returns the decision records of the bid and the winner of its block.
+	ExplainOutcome(blockNumber uint64, bidHash common.Hash) (*types.BidOutcome, error)
+	// BidStatus returns the status of the pending bid of the builder.
+	BidStatus(blockNumber uint64, builder common.Address, bidHash common.Hash) (*types.BidStatus, error)
+	// PendingBids returns the snapshot of the pending bids of a block.
+	PendingBids(args *types.PendingBidsArgs) (*types.PendingBids, error)
+	// PendingStats returns the count and the timing of the pending bids of each builder for the block.
+	PendingStats(blockNumber uint64) []*types.PendingBidStats
+	// MuteBuilder rejects the bids of the builder for the duration without removing it.
+	MuteBuilder(builder common.Address, duration time.Duration) error
+	// UnmuteBuilder enables the muted builder at once.
+	UnmuteBuilder(builder common.Address) error
+	// BuilderHealth returns the connectivity status of the builders.
+	BuilderHealth() []*types.BuilderHealth
+	// ExportMevStore dumps the records of the persistent mev store with the name.
+	ExportMevStore(name string) (*types.MevStoreDump, error)
+	// ImportMevStore imports the dumped records into the persistent mev store.
+	ImportMevStore(dump *types.MevStoreDump) error
+	// Builders returns the builders in the builder list with their endpoints.
+	Builders() []*types.BuilderInfo
+	// SimulateBid simulates the bid without sending it to the auction, on the parent state with the overrides
+	SimulateBid(ctx context.Context, bidArgs *types.BidArgs, overrides types.BidStateOverride) (*types.BidDryRunResult, error)
+	// BidQueueStatus returns the depth of the queue of the bids waiting to be compared
+	BidQueueStatus() *types.BidQueueStatus
 }
 
 func GetAPIs(apiBackend Backend) []rpc.API {
@@ -154,6 +188,10 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 		}, {
 			Namespace: "mev",
 			Service:   NewMevAPI(apiBackend),
+		}, {
+			Namespace:     "mev",
+			Service:       NewMevAdminAPI(apiBackend),
+			Authenticated: true,
 		},
 	}
 }
@@ -430,7 +430,60 @@ func (b *backendMock) RemoveBuilder(builder common.Address) error
 func (b *backendMock) SendBid(ctx context.Context, bid *types.BidArgs) (common.Hash, error) {
 	panic("implement me")
 }
-func (b *backendMock) MinerInTurn() bool { return false }
+func (b *backendMock) ImportMevStore(dump *types.MevStoreDump) error {
+	return nil
+}
+func (b *backendMock) ExportMevStore(name string) (*types.MevStoreDump, error) {
+	return nil, nil
+}
+func (b *backendMock) BuilderHealth() []*types.BuilderHealth {
+	return nil
+}
+func (b *backendMock) BidQueueStatus() *types.BidQueueStatus {
+	return nil
+}
+func (b *backendMock) SimulateBid(ctx context.Context, bidArgs *types.BidArgs, overrides types.BidStateOverride) (*types.BidDryRunResult, error) {
+	panic("implement me")
+}
+func (b *backendMock) Builders() []*types.BuilderInfo {
+	return nil
+}
+func (b *backendMock) ExplainOutcome(blockNumber uint64, bidHash common.Hash) (*types.BidOutcome, error) {
+	return nil, nil
+}
+func (b *backendMock) BidStatus(blockNumber uint64, builder common.Address, bidHash common.Hash) (*types.BidStatus, error) {
+	return nil, nil
+}
+func (b *backendMock) PendingBids(args *types.PendingBidsArgs) (*types.PendingBids, error) {
+	return nil, nil
+}
+func (b *backendMock) PendingStats(blockNumber uint64) []*types.PendingBidStats {
+	return nil
+}
+func (b *backendMock) MuteBuilder(builder common.Address, duration time.Duration) error {
+	return nil
+}
+func (b *backendMock) UnmuteBuilder(builder common.Address) error {
+	return nil
+}
+func (b *backendMock) BuilderStatsSnapshot() []*types.BuilderStats {
+	return nil
+}
+func (b *backendMock) BuilderStats(builder common.Address) *types.BuilderStats {
+	return nil
+}
+func (b *backendMock) BestBidInfo(parentHash common.Hash) *types.BestBidInfo {
+	return nil
+}
+func (b *backendMock) BidHistory(args *types.BidHistoryArgs) (*types.BidHistoryPage, error) {
+	return nil, nil
+}
+func (b *backendMock) BlockOrigin(blockHash common.Hash) (*types.BlockOrigin, error) {
+	return nil, nil
+}
+func (b *backendMock) MevRevenueReport(args *types.MevRevenueReportArgs) ([]*types.MevRevenueGroup, error) {
+	return nil, nil
+}
 func (b *backendMock) BestBidGasFee(parentHash common.Hash) *big.Int {
 	panic("implement me")
 }
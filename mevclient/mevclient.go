@@ -0,0 +1,201 @@
+// Package mevclient provides a client for the builders to interact with the mev namespace
+// of the validators, as defined in the BEP-322.
+package mevclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Client defines typed wrappers for the mev RPC API.
+type Client struct {
+	c *rpc.Client
+}
+
+// Dial connects a client to the given URL.
+func Dial(rawurl string) (*Client, error) {
+	return DialContext(context.Background(), rawurl)
+}
+
+// DialContext connects a client to the given URL with context.
+func DialContext(ctx context.Context, rawurl string) (*Client, error) {
+	return DialOptions(ctx, rawurl)
+}
+
+// DialOptions creates a new RPC client for the given URL. You can supply any of the
+// pre-defined client options to configure the underlying transport.
+func DialOptions(ctx context.Context, rawurl string, opts ...rpc.ClientOption) (*Client, error) {
+	c, err := rpc.DialOptions(ctx, rawurl, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(c), nil
+}
+
+// NewClient creates a client that uses the given RPC client.
+func NewClient(c *rpc.Client) *Client {
+	return &Client{c}
+}
+
+// Close closes the underlying RPC connection.
+func (mc *Client) Close() {
+	mc.c.Close()
+}
+
+// NewBidArgs creates the arguments of SendBid for the txs and signs the bid with the key
+// of the builder. The payBidTx is created by the sentry, it's empty if the bid is sent to
+// the validator directly. The hash signed is the one of rawBid.HashVersion, set it to
+// types.BidHashCanonical for the validators supporting the canonical hash.
+func NewBidArgs(rawBid *types.RawBid, txs []*types.Transaction, payBidTx []byte, payBidTxGasUsed uint64,
+	nontaxableFee *big.Int, key *ecdsa.PrivateKey) (*types.BidArgs, error) {
+	encoded, err := types.EncodeBidTxs(txs)
+	if err != nil {
+		return nil, err
+	}
+	rawBid.Txs = encoded
+	if rawBid.UnRevertible == nil {
+		rawBid.UnRevertible = []common.Hash{}
+	}
+
+	args := &types.BidArgs{
+		RawBid:          rawBid,
+		PayBidTx:        payBidTx,
+		PayBidTxGasUsed: payBidTxGasUsed,
+		NontaxableFee:   nontaxableFee,
+	}
+	if err = args.Sign(key); err != nil {
+		return nil, err
+	}
+
+	return args, nil
+}
+
+// SendBid sends a bid, the hash of the bid is returned if it is accepted.
+func (mc *Client) SendBid(ctx context.Context, args *types.BidArgs) (common.Hash, error) {
+	var hash common.Hash
+	err := mc.c.CallContext(ctx, &hash, "mev_sendBid", args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return hash, nil
+}
+
+// SimulateBid simulates a bid without sending it to the auction, the simulation error is
+// returned in the result. The state overrides are applied to the parent state of the bid
+// and echoed in the result, they may be nil.
+func (mc *Client) SimulateBid(ctx context.Context, args *types.BidArgs, overrides types.BidStateOverride) (*types.BidDryRunResult, error) {
+	var (
+		result types.BidDryRunResult
+		err    error
+	)
+	if len(overrides) == 0 {
+		err = mc.c.CallContext(ctx, &result, "mev_simulateBid", args)
+	} else {
+		err = mc.c.CallContext(ctx, &result, "mev_simulateBid", args, overrides)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// BidStatus returns the outcome of a bid of the builder, the query is signed with the key
+// of the builder.
+func (mc *Client) BidStatus(ctx context.Context, blockNumber uint64, bidHash common.Hash, key *ecdsa.PrivateKey) (*types.BidOutcome, error) {
+	args := &types.ExplainOutcomeArgs{
+		BlockNumber: hexutil.Uint64(blockNumber),
+		BidHash:     bidHash,
+	}
+	if err := args.Sign(key); err != nil {
+		return nil, err
+	}
+
+	var outcome types.BidOutcome
+	err := mc.c.CallContext(ctx, &outcome, "mev_explainOutcome", args)
+	if err != nil {
+		return nil, err
+	}
+	return &outcome, nil
+}
+
+// PendingBidStatus returns the status of a pending bid of the builder before the block is
+// sealed, the query is signed with the key of the builder.
+func (mc *Client) PendingBidStatus(ctx context.Context, blockNumber uint64, bidHash common.Hash, key *ecdsa.PrivateKey) (*types.BidStatus, error) {
+	args := &types.BidStatusArgs{
+		BlockNumber: hexutil.Uint64(blockNumber),
+		BidHash:     bidHash,
+	}
+	if err := args.Sign(key); err != nil {
+		return nil, err
+	}
+
+	var status types.BidStatus
+	err := mc.c.CallContext(ctx, &status, "mev_bidStatus", args)
+	if err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Params returns the static params of mev.
+func (mc *Client) Params(ctx context.Context) (*types.MevParams, error) {
+	var params types.MevParams
+	err := mc.c.CallContext(ctx, &params, "mev_params")
+	if err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
+
+// Running returns whether mev is running.
+func (mc *Client) Running(ctx context.Context) (bool, error) {
+	var result bool
+	err := mc.c.CallContext(ctx, &result, "mev_running")
+	return result, err
+}
+
+// BidQueue returns the depth of the bid queue of the validator, to throttle the bids.
+func (mc *Client) BidQueue(ctx context.Context) (*types.BidQueueStatus, error) {
+	var status types.BidQueueStatus
+	if err := mc.c.CallContext(ctx, &status, "mev_bidQueue"); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// HasBuilder returns whether the builder is registered.
+func (mc *Client) HasBuilder(ctx context.Context, builder common.Address) (bool, error) {
+	var result bool
+	err := mc.c.CallContext(ctx, &result, "mev_hasBuilder", builder)
+	return result, err
+}
+
+// BestBidGasFee returns the reward of the best bid for the parent hash.
+func (mc *Client) BestBidGasFee(ctx context.Context, parentHash common.Hash) (*big.Int, error) {
+	var fee *big.Int
+	err := mc.c.CallContext(ctx, &fee, "mev_bestBidGasFee", parentHash)
+	if err != nil {
+		return nil, err
+	}
+	return fee, nil
+}
+
+// BestBid returns the summary of the best bid for the parent hash, nil if there is none.
+func (mc *Client) BestBid(ctx context.Context, parentHash common.Hash) (*types.BestBidInfo, error) {
+	var info *types.BestBidInfo
+	err := mc.c.CallContext(ctx, &info, "mev_bestBid", parentHash)
+	return info, err
+}
+
+// BuilderStats returns the runtime statistics of the builder.
+func (mc *Client) BuilderStats(ctx context.Context, builder common.Address) (*types.BuilderStats, error) {
+	var stats *types.BuilderStats
+	err := mc.c.CallContext(ctx, &stats, "mev_builderStats", builder)
+	return stats, err
+}
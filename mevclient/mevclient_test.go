@@ -0,0 +1,127 @@
+package mevclient
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testBuilder = crypto.PubkeyToAddress(testKey.PublicKey)
+)
+
+// newTestNode starts an in-process node with mev enabled and the test builder registered,
+// the miner is not started, so mev is not running.
+func newTestNode(t *testing.T) (*node.Node, *eth.Ethereum) {
+	n, err := node.New(&node.Config{})
+	if err != nil {
+		t.Fatalf("can't create new node: %v", err)
+	}
+
+	config := &ethconfig.Config{
+		Genesis: &core.Genesis{
+			Config:  params.AllEthashProtocolChanges,
+			BaseFee: big.NewInt(params.InitialBaseFeeForBSC),
+		},
+		Miner: miner.DefaultConfig,
+	}
+	config.SnapshotCache = 256
+	config.TriesInMemory = 128
+	config.Miner.Mev.Enabled = true
+	config.Miner.Mev.BuilderFeeCeil = "0"
+	config.Miner.Mev.Builders = []miner.BuilderConfig{{Address: testBuilder}}
+
+	ethservice, err := eth.New(n, config)
+	if err != nil {
+		t.Fatalf("can't create new ethereum service: %v", err)
+	}
+	if err = n.Start(); err != nil {
+		t.Fatalf("can't start test node: %v", err)
+	}
+	t.Cleanup(func() { n.Close() })
+
+	return n, ethservice
+}
+
+func TestClient(t *testing.T) {
+	n, ethservice := newTestNode(t)
+
+	client := NewClient(n.Attach())
+	defer client.Close()
+
+	ctx := context.Background()
+
+	if ok, err := client.HasBuilder(ctx, testBuilder); err != nil || !ok {
+		t.Fatalf("expected the builder to be registered, got %v %v", ok, err)
+	}
+	if running, err := client.Running(ctx); err != nil || running {
+		t.Fatalf("expected mev not running, got %v %v", running, err)
+	}
+
+	mevParams, err := client.Params(ctx)
+	if err != nil {
+		t.Fatalf("failed to get params: %v", err)
+	}
+	if mevParams.Version != params.Version {
+		t.Fatalf("unexpected params %+v", mevParams)
+	}
+
+	// the bid is signed by the shared helper, and recovered as the server does
+	genesis := ethservice.BlockChain().Genesis()
+	tx := types.MustSignNewTx(testKey, types.LatestSigner(params.AllEthashProtocolChanges), &types.LegacyTx{
+		Gas:      params.TxGas,
+		GasPrice: big.NewInt(params.GWei),
+		To:       &testBuilder,
+	})
+	args, err := NewBidArgs(&types.RawBid{
+		BlockNumber: genesis.NumberU64() + 1,
+		ParentHash:  genesis.Hash(),
+		GasUsed:     params.TxGas,
+		GasFee:      new(big.Int).SetUint64(params.TxGas * params.GWei),
+	}, []*types.Transaction{tx}, nil, 0, big.NewInt(0), testKey)
+	if err != nil {
+		t.Fatalf("failed to create bid: %v", err)
+	}
+	if builder, err := args.EcrecoverSender(); err != nil || builder != testBuilder {
+		t.Fatalf("unexpected builder %v %v", builder, err)
+	}
+	txs, err := args.RawBid.DecodeTxs(types.LatestSigner(params.AllEthashProtocolChanges))
+	if err != nil || len(txs) != 1 || txs[0].Hash() != tx.Hash() {
+		t.Fatalf("unexpected txs %v %v", txs, err)
+	}
+
+	var rpcErr rpc.Error
+	if _, err = client.SendBid(ctx, args); !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != types.MevNotRunningError {
+		t.Fatalf("expected mev not running error, got %v", err)
+	}
+
+	// the state overrides are only for the dry runs, the real bids can't carry them
+	overrides := types.BidStateOverride{testBuilder: {Balance: (*hexutil.Big)(big.NewInt(params.Ether))}}
+	if err = client.c.CallContext(ctx, nil, "mev_sendBid", args, overrides); !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32602 {
+		t.Fatalf("expected the overrides to be rejected by sendBid, got %v", err)
+	}
+	if _, err = client.SimulateBid(ctx, args, overrides); !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != types.MevNotRunningError {
+		t.Fatalf("expected the overrides to be taken by simulateBid, got %v", err)
+	}
+
+	if _, err = client.BidStatus(ctx, genesis.NumberU64()+1, args.RawBid.Hash(), testKey); err == nil {
+		t.Fatalf("expected error for the bid never accepted")
+	}
+
+	if info, err := client.BestBid(ctx, genesis.Hash()); err != nil || info != nil {
+		t.Fatalf("unexpected best bid %v %v", info, err)
+	}
+}
@@ -0,0 +1,138 @@
+package miner
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// bidAuditChanSize is the size of the channel buffering the audit events to write
+	bidAuditChanSize = 4096
+
+	// defaultBidAuditLogMaxSize is the default size in megabytes the audit log is rotated at
+	defaultBidAuditLogMaxSize = 100
+)
+
+// The lifecycle events of the bids written to the audit log.
+const (
+	bidAuditReceived    = "received"
+	bidAuditRejected    = "rejected" // by the pre-check
+	bidAuditSimStarted  = "simulationStarted"
+	bidAuditInterrupted = "interrupted"
+	bidAuditFailed      = "failed"
+	bidAuditBest        = "best"
+	bidAuditDisplaced   = "displaced"
+	bidAuditSealed      = "sealed"
+)
+
+// bidAuditDropCounter counts the audit events dropped, it grows if the writes fall behind
+var bidAuditDropCounter = metrics.NewRegisteredCounter("bid/audit/drop", nil)
+
+// bidAuditEvent is a line of the audit log.
+type bidAuditEvent struct {
+	Time        time.Time          `json:"time"`
+	Event       string             `json:"event"`
+	BlockNumber uint64             `json:"blockNumber"`
+	BidHash     common.Hash        `json:"bidHash"`
+	Builder     common.Address     `json:"builder"`
+	ParentHash  common.Hash        `json:"parentHash"`
+	GasFee      *big.Int           `json:"gasFee,omitempty"`
+	BuilderFee  *big.Int           `json:"builderFee,omitempty"`
+	Reward      *big.Int           `json:"reward,omitempty"` // the validator reward, once simulated
+	Code        types.BidIssueCode `json:"code,omitempty"`
+	Error       string             `json:"error,omitempty"`
+}
+
+// bidAuditLog writes the lifecycle events of the bids as JSON lines to a file rotated by size.
+// The events are queued and written in the background, so the writes never stall newBidLoop,
+// the events are dropped instead if the queue is full.
+type bidAuditLog struct {
+	eventCh chan *bidAuditEvent
+	file    *lumberjack.Logger
+}
+
+func newBidAuditLog(path string, maxSize int) *bidAuditLog {
+	if maxSize <= 0 {
+		maxSize = defaultBidAuditLogMaxSize
+	}
+
+	return &bidAuditLog{
+		eventCh: make(chan *bidAuditEvent, bidAuditChanSize),
+		file:    &lumberjack.Logger{Filename: path, MaxSize: maxSize},
+	}
+}
+
+// add queues the event, it is a no-op if the audit log is disabled.
+func (l *bidAuditLog) add(event *bidAuditEvent) {
+	if l == nil {
+		return
+	}
+
+	select {
+	case l.eventCh <- event:
+	default:
+		bidAuditDropCounter.Inc(1)
+	}
+}
+
+// loop writes the queued events until the exit, the events queued by then are written before
+// the file is closed.
+func (l *bidAuditLog) loop(exitCh <-chan struct{}) {
+	enc := json.NewEncoder(l.file)
+	write := func(event *bidAuditEvent) {
+		if err := enc.Encode(event); err != nil {
+			log.Warn("BidSimulator: failed to write bid audit log", "err", err)
+		}
+	}
+
+	defer l.file.Close()
+
+	for {
+		select {
+		case event := <-l.eventCh:
+			write(event)
+		case <-exitCh:
+			for {
+				select {
+				case event := <-l.eventCh:
+					write(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// auditBid queues the lifecycle event of the bid, reward is nil before the bid is simulated.
+func (b *bidSimulator) auditBid(event string, bid *types.Bid, reward *big.Int, err error) {
+	if b.audit == nil {
+		return
+	}
+
+	e := &bidAuditEvent{
+		Time:        time.Now(),
+		Event:       event,
+		BlockNumber: bid.BlockNumber,
+		BidHash:     bid.Hash(),
+		Builder:     bid.Builder,
+		ParentHash:  bid.ParentHash,
+		GasFee:      bid.GasFee,
+		BuilderFee:  bid.BuilderFee,
+		Reward:      reward,
+	}
+	if err != nil {
+		e.Code = bidIssueCode(err)
+		e.Error = err.Error()
+	}
+
+	b.audit.add(e)
+}
@@ -0,0 +1,58 @@
+package miner
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestBidAuditLog(t *testing.T) {
+	var (
+		path   = filepath.Join(t.TempDir(), "audit.jsonl")
+		l      = newBidAuditLog(path, 0)
+		b      = &bidSimulator{audit: l}
+		bid    = newTestBid(t, 1, 21000)
+		exitCh = make(chan struct{})
+	)
+
+	b.auditBid(bidAuditReceived, bid, nil, nil)
+	b.auditBid(bidAuditFailed, bid, nil, newBidSimError(types.ErrCodeInvalidTx, errors.New("reverted")))
+
+	// the events queued before the exit are written
+	close(exitCh)
+	l.loop(exitCh)
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open audit log: %v", err)
+	}
+	defer f.Close()
+
+	var events []bidAuditEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event bidAuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Event != bidAuditReceived || events[0].BidHash != bid.Hash() || events[0].Code != "" {
+		t.Fatalf("unexpected received event: %+v", events[0])
+	}
+	if events[1].Event != bidAuditFailed || events[1].Code != types.ErrCodeInvalidTx {
+		t.Fatalf("unexpected failed event: %+v", events[1])
+	}
+
+	// a disabled audit log drops the events silently
+	(&bidSimulator{}).auditBid(bidAuditReceived, bid, nil, nil)
+}
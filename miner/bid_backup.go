@@ -0,0 +1,87 @@
+package miner
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	bidBackupRetainCounter  = metrics.NewRegisteredCounter("bid/backup/retain", nil)
+	bidBackupPromoteCounter = metrics.NewRegisteredCounter("bid/backup/promote", nil)
+)
+
+// retainBackupBidLocked keeps the dethroned best bid as the runner-up of the parent if its
+// block is small enough, the environment of the replaced runner-up is discarded. It returns
+// false if the bid is not kept, so the caller discards its environment.
+// The caller must hold bestBidMu.
+func (b *bidSimulator) retainBackupBidLocked(prevBlockHash common.Hash, bid *BidRuntime) bool {
+	if b.config.BackupBidMaxSize == 0 || bid.env == nil || bid.env.size+bid.sidecarSize > b.config.BackupBidMaxSize {
+		return false
+	}
+
+	if last := b.backupBid[prevBlockHash]; last != nil {
+		last.discardEnv()
+	}
+	b.backupBid[prevBlockHash] = bid
+	bidBackupRetainCounter.Inc(1)
+
+	return true
+}
+
+// clearBackupBidsLocked discards the runner-ups of the sealed parent and the blocks up to the
+// horizon. The caller must hold bestBidMu.
+func (b *bidSimulator) clearBackupBidsLocked(parentHash common.Hash, horizon uint64) {
+	for k, v := range b.backupBid {
+		if k == parentHash || v.bid.BlockNumber <= horizon {
+			v.discardEnv()
+			delete(b.backupBid, k)
+		}
+	}
+}
+
+// PromoteBackupBid replaces the best bid of the parent, which failed the verification before
+// sealing, with its runner-up. The runner-up is revalidated within timeout, and nil is returned
+// if there is no valid one.
+func (b *bidSimulator) PromoteBackupBid(prevBlockHash common.Hash, timeout time.Duration) *BidRuntime {
+	b.bestBidMu.Lock()
+	backup := b.backupBid[prevBlockHash]
+	delete(b.backupBid, prevBlockHash)
+	b.bestBidMu.Unlock()
+
+	if backup == nil {
+		return nil
+	}
+
+	err := backup.waitVerified(timeout)
+	if errors.Is(err, errBidNotVerified) {
+		log.Warn("BidSimulator: backup bid not verified in time, sealing it unverified", "builder", backup.bid.Builder,
+			"bidHash", backup.bid.Hash().TerminalString())
+		err = nil
+	}
+	if err == nil && !b.ExistBuilder(backup.bid.Builder) {
+		err = errors.New("builder is removed")
+	}
+	if err != nil {
+		log.Warn("BidSimulator: backup bid is invalid", "builder", backup.bid.Builder,
+			"bidHash", backup.bid.Hash().TerminalString(), "err", err)
+		backup.discardEnv()
+		return nil
+	}
+
+	b.bestBidMu.Lock()
+	if last := b.bestBid[prevBlockHash]; last != nil {
+		last.discardEnv()
+	}
+	b.bestBid[prevBlockHash] = backup
+	b.bestBidMu.Unlock()
+
+	bidBackupPromoteCounter.Inc(1)
+	log.Info("BidSimulator: backup bid promoted", "block", backup.bid.BlockNumber, "builder", backup.bid.Builder,
+		"bidHash", backup.bid.Hash().TerminalString(), "totalReward", weiToEtherStringF6(backup.totalReward()))
+
+	return backup
+}
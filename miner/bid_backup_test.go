@@ -0,0 +1,81 @@
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/miner/builderclient"
+	"github.com/holiman/uint256"
+)
+
+func newTestBackupBidRuntime(t *testing.T, gasUsed uint64, reward uint64, size uint32) *BidRuntime {
+	r := newBidRuntime(newTestBid(t, 1, gasUsed))
+	r.env = &environment{header: &types.Header{Number: big.NewInt(1)}, size: size}
+	r.packedBlockRewardPreBEP95Final = uint256.NewInt(reward)
+
+	// verified by the paranoid mode
+	r.verified = make(chan struct{})
+	close(r.verified)
+
+	return r
+}
+
+func TestPromoteBackupBid(t *testing.T) {
+	b := newTestBidSimulator(t)
+	b.builders = map[common.Address]*builderclient.Client{testBuilder: nil}
+
+	config := DefaultMevConfig
+	config.BackupBidMaxSize = 1024
+	b.config = &config
+
+	var (
+		parentHash = newTestBid(t, 1, 21000).ParentHash
+		runnerUp   = newTestBackupBidRuntime(t, 21000, 100, 512)
+		winner     = newTestBackupBidRuntime(t, 42000, 200, 512)
+	)
+
+	b.SetBestBid(parentHash, runnerUp)
+	b.SetBestBid(parentHash, winner)
+
+	// the payBidTx of the winner is invalidated between the simulation and the sealing
+	winner.verifyErr = errors.New("payBidTx reverted")
+
+	w := &worker{
+		bidFetcher: b,
+		config:     &Config{Mev: config, DelayLeftOver: 50 * time.Millisecond},
+	}
+	header := &types.Header{ParentHash: parentHash, Number: big.NewInt(1), Time: uint64(time.Now().Add(time.Second).Unix()) + 1}
+
+	if err := winner.waitVerified(time.Second); err == nil {
+		t.Fatalf("expected the winner to fail the verification")
+	}
+	if backup := w.backupBid(header, big.NewInt(0)); backup != runnerUp {
+		t.Fatalf("expected the runner-up to be promoted, got %v", backup)
+	}
+	if best := b.GetBestBid(parentHash); best != runnerUp {
+		t.Fatalf("the runner-up is not the best bid")
+	}
+
+	// the runner-up is promoted only once
+	if backup := w.backupBid(header, big.NewInt(0)); backup != nil {
+		t.Fatalf("unexpected second promotion")
+	}
+
+	// the local block rewards more than the runner-up
+	b.SetBestBid(parentHash, newTestBackupBidRuntime(t, 63000, 300, 512))
+	if backup := w.backupBid(header, big.NewInt(1000)); backup != nil {
+		t.Fatalf("expected the local block to win over the runner-up")
+	}
+
+	// the large runner-up is not retained
+	otherParent := common.Hash{0x02}
+	b.SetBestBid(otherParent, newTestBackupBidRuntime(t, 84000, 400, 2048))
+	b.SetBestBid(otherParent, newTestBackupBidRuntime(t, 105000, 500, 512))
+	if backup := b.PromoteBackupBid(otherParent, time.Second); backup != nil {
+		t.Fatalf("unexpected large runner-up %v", backup)
+	}
+}
@@ -0,0 +1,159 @@
+package miner
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/miner/builderclient"
+)
+
+const (
+	// beaconTimeout is the timeout to send the beacon to a builder
+	beaconTimeout = time.Second
+
+	// beaconFailLogInterval is the minimum interval between the warnings of undelivered beacons
+	beaconFailLogInterval = time.Minute
+)
+
+var (
+	bidBeaconSentCounter = metrics.NewRegisteredCounter("bid/beacon/sent", nil)
+	bidBeaconFailCounter = metrics.NewRegisteredCounter("bid/beacon/fail", nil)
+)
+
+// beaconSigner signs the liveness beacons with the key of the validator.
+type beaconSigner struct {
+	validator  common.Address
+	signTextFn func(accounts.Account, []byte) ([]byte, error)
+}
+
+// nextTurnReader is implemented by the engines telling the next turn of a validator.
+type nextTurnReader interface {
+	NextTurnBlock(chain consensus.ChainHeaderReader, header *types.Header, validator common.Address) (uint64, error)
+}
+
+func (b *bidSimulator) authorizeBeacon(validator common.Address, signTextFn func(accounts.Account, []byte) ([]byte, error)) {
+	b.beaconMu.Lock()
+	defer b.beaconMu.Unlock()
+
+	b.beaconSigner = &beaconSigner{validator: validator, signTextFn: signTextFn}
+}
+
+// beaconActive reports whether the beacons are sent, they stop as soon as the validator
+// stops receiving bids.
+func (b *bidSimulator) beaconActive() bool {
+	return b.isRunning() && b.receivingBid()
+}
+
+// beaconLoop broadcasts the liveness beacon to the builders periodically. The builders are
+// sent one after another from this loop only, so the beacons never compete with the bids,
+// the issue reports or the bid results for the connections.
+func (b *bidSimulator) beaconLoop() {
+	interval := b.config.LivenessBeaconInterval
+	if interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failLog := rate.NewLimiter(rate.Every(beaconFailLogInterval), 1)
+
+	for {
+		select {
+		case <-ticker.C:
+			if !b.beaconActive() {
+				continue
+			}
+
+			beacon, err := b.newBeacon()
+			if err != nil {
+				if failLog.Allow() {
+					log.Warn("BidSimulator: failed to create liveness beacon", "err", err)
+				}
+				continue
+			}
+			if beacon == nil {
+				continue
+			}
+
+			b.broadcastBeacon(beacon, failLog)
+		case <-b.exitCh:
+			return
+		}
+	}
+}
+
+// newBeacon creates the signed beacon of the current head, nil if no validator key is
+// authorized yet.
+func (b *bidSimulator) newBeacon() (*types.MevBeacon, error) {
+	b.beaconMu.RLock()
+	signer := b.beaconSigner
+	b.beaconMu.RUnlock()
+
+	if signer == nil {
+		return nil, nil
+	}
+
+	head := b.chain.CurrentHeader()
+	beacon := &types.MevBeacon{
+		Validator:  signer.validator,
+		HeadNumber: head.Number.Uint64(),
+		HeadHash:   head.Hash(),
+		Receiving:  b.beaconActive(),
+		Timestamp:  uint64(time.Now().UnixMilli()),
+	}
+
+	// the next turn is left unknown if the engine can't tell
+	if reader, ok := b.engine.(nextTurnReader); ok {
+		if next, err := reader.NextTurnBlock(b.chain, head, signer.validator); err == nil {
+			beacon.NextTurn = next
+		}
+	}
+
+	sig, err := signer.signTextFn(accounts.Account{Address: signer.validator}, beacon.BeaconPayload())
+	if err != nil {
+		return nil, err
+	}
+	beacon.Signature = sig
+
+	return beacon, nil
+}
+
+// broadcastBeacon sends the beacon to the registered builders, the undelivered ones are
+// counted and warned at most once per beaconFailLogInterval.
+func (b *bidSimulator) broadcastBeacon(beacon *types.MevBeacon, failLog *rate.Limiter) {
+	b.buildersMu.RLock()
+	builders := make(map[common.Address]*builderclient.Client, len(b.builders))
+	for builder, cli := range b.builders {
+		builders[builder] = cli
+	}
+	b.buildersMu.RUnlock()
+
+	for builder, cli := range builders {
+		// the beacon must not outlive the receiving of bids
+		if !b.beaconActive() {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), beaconTimeout)
+		err := cli.SendBeacon(ctx, beacon)
+		cancel()
+
+		if err != nil {
+			bidBeaconFailCounter.Inc(1)
+			if failLog.Allow() {
+				log.Warn("BidSimulator: failed to send liveness beacon", "builder", builder, "err", err)
+			}
+			continue
+		}
+		bidBeaconSentCounter.Inc(1)
+	}
+}
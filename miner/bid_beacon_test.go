@@ -0,0 +1,52 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestNewBeacon(t *testing.T) {
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, &core.Genesis{Config: params.TestChainConfig},
+		nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	b := newTestBidSimulator(t)
+	b.chain, b.engine = chain, chain.Engine()
+
+	if beacon, err := b.newBeacon(); beacon != nil || err != nil {
+		t.Fatalf("beacon created without the validator key: %v, %v", beacon, err)
+	}
+
+	key, _ := crypto.GenerateKey()
+	validator := crypto.PubkeyToAddress(key.PublicKey)
+	b.authorizeBeacon(validator, func(_ accounts.Account, text []byte) ([]byte, error) {
+		return crypto.Sign(accounts.TextHash(text), key)
+	})
+
+	beacon, err := b.newBeacon()
+	if err != nil {
+		t.Fatalf("failed to create beacon: %v", err)
+	}
+	if beacon.HeadHash != chain.Genesis().Hash() || beacon.NextTurn != 0 {
+		t.Fatalf("unexpected beacon %+v", beacon)
+	}
+	if signer, err := beacon.EcrecoverSender(); err != nil || signer != validator {
+		t.Fatalf("unexpected beacon signer %v, want %v: %v", signer, validator, err)
+	}
+
+	// the signed fields can't be altered
+	beacon.Receiving = !beacon.Receiving
+	if signer, _ := beacon.EcrecoverSender(); signer == validator {
+		t.Fatal("altered beacon is still signed by the validator")
+	}
+}
@@ -0,0 +1,115 @@
+package miner
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// bidDebounceDiscardCounter counts the bids discarded for a better one of the same burst
+var bidDebounceDiscardCounter = metrics.NewRegisteredCounter("bid/debounce/discard", nil)
+
+// debounceBids collects the bids of the parent of the first bid arriving within BidDebounceWindow,
+// so a burst of bids interrupts the simulation once only. The window never goes beyond
+// bidBetterBefore of the parent, so the late bids, e.g. of the fast path, are not delayed. The
+// bids of other parents are passed to handleOther as they arrive.
+func (b *bidSimulator) debounceBids(first newBidPackage, handleOther func(newBidPackage)) newBidPackage {
+	if b.config.BidDebounceWindow <= 0 || !b.isRunning() {
+		return first
+	}
+
+	deadline := time.Now().Add(b.config.BidDebounceWindow)
+	if betterBefore := b.bidBetterBefore(first.bid.ParentHash); betterBefore.Before(deadline) {
+		deadline = betterBefore
+	}
+
+	return b.collectBids(first, deadline, handleOther)
+}
+
+// debouncedBid is a bid of a burst with its expected value after the penalty of its builder.
+type debouncedBid struct {
+	newBidPackage
+	value      *big.Int
+	penaltyBps uint64
+}
+
+func (b *bidSimulator) newDebouncedBid(pkg newBidPackage) debouncedBid {
+	r := newBidRuntime(pkg.bid)
+	r.penaltyBps = b.builderPenaltyBps(pkg.bid.Builder)
+
+	return debouncedBid{newBidPackage: pkg, value: r.penalizedExpectedValue(b.valuator), penaltyBps: r.penaltyBps}
+}
+
+// collectBids returns the bid with the highest expected value after the penalty of its builder,
+// as newBidLoop compares them, among the first bid and the bids of the same parent received
+// before the deadline. The others are discarded with the reward of the chosen one. The bids of
+// other parents are not held by the burst, they are passed to handleOther right away.
+func (b *bidSimulator) collectBids(first newBidPackage, deadline time.Time, handleOther func(newBidPackage)) newBidPackage {
+	var (
+		best   = b.newDebouncedBid(first)
+		losers []debouncedBid
+	)
+
+	if wait := time.Until(deadline); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+	collect:
+		for {
+			select {
+			case newBid := <-b.newBidCh:
+				if newBid.bid.ParentHash != best.bid.ParentHash {
+					handleOther(newBid)
+					continue
+				}
+				if bid := b.newDebouncedBid(newBid); bid.value.Cmp(best.value) > 0 {
+					losers = append(losers, best)
+					best = bid
+				} else {
+					losers = append(losers, bid)
+				}
+			case <-timer.C:
+				break collect
+			case <-b.exitCh:
+				break collect
+			}
+		}
+		bidQueueDepthGauge.Update(int64(len(b.newBidCh)))
+	}
+
+	bestReward := newBidRuntime(best.bid).expectedRewardFromBuilder()
+	for _, loser := range losers {
+		b.discardDebouncedBid(loser, best, bestReward)
+	}
+
+	return best.newBidPackage
+}
+
+// discardDebouncedBid replies to the bid discarded for the better bid of the same burst, the
+// builder is told the reward of the better bid.
+func (b *bidSimulator) discardDebouncedBid(loser, best debouncedBid, bestReward *big.Int) {
+	bidDebounceDiscardCounter.Inc(1)
+
+	err := newBidDiscardedWorseError(bestReward, loser.timing.latency(time.Now()),
+		b.BidQuota(loser.bid.BlockNumber, loser.bid.Builder))
+	b.recordBidStatus(loser.bid, types.BidStatusRejected, err)
+
+	if loser.feedback == nil {
+		return
+	}
+
+	b.decisions.preFilter(loser.bid, &types.BidComparison{
+		Against:      againstDebouncedBid,
+		AgainstBid:   bidHashRef(best.bid),
+		Value:        loser.value,
+		AgainstValue: best.value,
+		PenaltyBps:   loser.penaltyBps,
+	}, loser.timing.latency(time.Now()))
+	loser.feedback <- err
+
+	log.Debug("BidSimulator: bid discarded by debounce", "builder", loser.bid.Builder,
+		"bidHash", loser.bid.Hash().Hex(), "best", best.bid.Hash().Hex())
+}
@@ -0,0 +1,134 @@
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestCollectBids(t *testing.T) {
+	var (
+		b = &bidSimulator{
+			config:      &DefaultMevConfig,
+			valuator:    rewardValuator{},
+			exitCh:      make(chan struct{}),
+			newBidCh:    make(chan newBidPackage, 10),
+			decisions:   newBidDecisions(0),
+			bidStatuses: make(map[uint64]map[common.Hash]*types.BidStatus),
+		}
+		first  = newTestBid(t, 1, 21000)
+		better = newTestBid(t, 1, 42000)
+		other  = newTestBid(t, 1, 63000)
+
+		firstReply = make(chan error, 1)
+	)
+	better.GasFee = big.NewInt(2)
+	other.ParentHash = common.Hash{0x02}
+
+	b.newBidCh <- newBidPackage{bid: other}
+	b.newBidCh <- newBidPackage{bid: better}
+
+	var (
+		handled = make(chan *types.Bid, 1)
+		result  = make(chan newBidPackage, 1)
+	)
+	go func() {
+		result <- b.collectBids(newBidPackage{bid: first, feedback: firstReply}, time.Now().Add(5*time.Second),
+			func(pkg newBidPackage) { handled <- pkg.bid })
+	}()
+
+	// the bid of the other parent is not held until the deadline
+	select {
+	case bid := <-handled:
+		if bid != other {
+			t.Fatalf("unexpected bid of the other parent %v", bid)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("the bid of the other parent is held by the burst")
+	}
+	for len(b.newBidCh) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(b.exitCh)
+
+	if best := <-result; best.bid != better {
+		t.Fatalf("unexpected collected bid: %v", best.bid)
+	}
+
+	select {
+	case err := <-firstReply:
+		var rejection *types.BidRejectionError
+		if !errors.As(err, &rejection) || rejection.CurrentBestReward.Cmp(newBidRuntime(better).expectedRewardFromBuilder()) != 0 {
+			t.Fatalf("expected the rejection with the reward of the chosen bid, got %v", err)
+		}
+	default:
+		t.Fatalf("the discarded bid got no reply")
+	}
+
+	// nothing is collected after the deadline
+	b.newBidCh <- newBidPackage{bid: better}
+	if best := b.collectBids(newBidPackage{bid: first}, time.Now(), nil); best.bid != first {
+		t.Fatalf("unexpected collected bid after the deadline: %v", best.bid)
+	}
+}
+
+func TestCollectBidsPenalized(t *testing.T) {
+	var (
+		config = DefaultMevConfig
+		b      = &bidSimulator{
+			config:      &config,
+			valuator:    rewardValuator{},
+			exitCh:      make(chan struct{}),
+			newBidCh:    make(chan newBidPackage, 10),
+			decisions:   newBidDecisions(16),
+			bidStatuses: make(map[uint64]map[common.Hash]*types.BidStatus),
+			stats:       make(map[common.Address]*builderStats),
+		}
+		flaky    = common.HexToAddress("0x2000000000000000000000000000000000000002")
+		reliable = newTestBid(t, 1, 21000)
+		higher   = newTestBid(t, 1, 42000)
+
+		higherReply = make(chan error, 1)
+	)
+	config.PenalizeFailingBuilders = true
+	higher.Builder, higher.GasFee = flaky, big.NewInt(3*params.GWei)
+	reliable.GasFee = big.NewInt(2 * params.GWei)
+
+	// the low reputation builder is discounted by the capped penalty
+	b.recordSimResult(flaky, time.Millisecond, true)
+	if reputation := b.getOrNewStats(flaky).toTypes(flaky).Reputation; reputation != 0 {
+		t.Fatalf("unexpected reputation %v", reputation)
+	}
+
+	b.newBidCh <- newBidPackage{bid: higher, feedback: higherReply}
+	if best := b.collectBids(newBidPackage{bid: reliable}, time.Now().Add(20*time.Millisecond), nil); best.bid != reliable {
+		t.Fatalf("the higher bid of the low reputation builder should be ordered behind")
+	}
+
+	select {
+	case err := <-higherReply:
+		if !errors.Is(err, types.ErrBidDiscardedWorse) {
+			t.Fatalf("expected the bid discarded, got %v", err)
+		}
+	default:
+		t.Fatalf("the discarded bid got no reply")
+	}
+
+	// the discarded bid is recorded with the values it was ranked by
+	outcome, err := b.decisions.explain(higher.BlockNumber, higher.Hash())
+	if err != nil {
+		t.Fatalf("failed to explain the discarded bid: %v", err)
+	}
+	comparison := outcome.Decision.PreFilter
+	if comparison.PenaltyBps != maxFailurePenaltyBps || comparison.Value.Cmp(newBidRuntime(higher).expectedRewardFromBuilder()) >= 0 {
+		t.Fatalf("the value of the discarded bid is not penalized: %+v", comparison)
+	}
+	if comparison.AgainstValue.Cmp(comparison.Value) <= 0 {
+		t.Fatalf("the discarded bid is not worth less than the chosen one: %+v", comparison)
+	}
+}
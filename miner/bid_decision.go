@@ -0,0 +1,206 @@
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	againstNone          = "none"
+	againstSimulatingBid = "simulatingBid"
+	againstBestBid       = "bestBid"
+	againstDebouncedBid  = "debouncedBid"
+)
+
+// bidIntakeChecks are the checks passed by a bid before it is sent to the bid simulator.
+var bidIntakeChecks = []string{"signature", "builder", "bidTime", "feeCeil", "pending", "txs", "preCheck", "deadline"}
+
+var errBidDecisionNotFound = errors.New("no decision record of the bid")
+
+// blockDecisions is the decision records of the bids for a block.
+type blockDecisions struct {
+	bids   map[common.Hash]*types.BidDecision
+	winner *types.BidOutcomeWinner // nil until the block is sealed
+}
+
+// bidDecisions keeps the decision records of the bids of the recent blocks,
+// so that the outcome of a bid can be explained to its builder.
+type bidDecisions struct {
+	retain uint64 // number of recent blocks to retain, 0 means disabled
+
+	mu     sync.Mutex
+	head   uint64
+	blocks map[uint64]*blockDecisions
+}
+
+func newBidDecisions(retain uint64) *bidDecisions {
+	return &bidDecisions{
+		retain: retain,
+		blocks: make(map[uint64]*blockDecisions),
+	}
+}
+
+// getOrNewBlock must be called with mu held, it prunes the records out of retention.
+func (d *bidDecisions) getOrNewBlock(number uint64) *blockDecisions {
+	if number > d.head {
+		d.head = number
+		for n := range d.blocks {
+			if n+d.retain <= d.head {
+				delete(d.blocks, n)
+			}
+		}
+	}
+
+	block, ok := d.blocks[number]
+	if !ok {
+		block = &blockDecisions{bids: make(map[common.Hash]*types.BidDecision)}
+		d.blocks[number] = block
+	}
+
+	return block
+}
+
+// update applies fn to the decision record of the bid, the record is created if absent.
+func (d *bidDecisions) update(bid *types.Bid, fn func(decision *types.BidDecision)) {
+	if d.retain == 0 {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// don't resurrect records out of retention
+	if bid.BlockNumber+d.retain <= d.head {
+		return
+	}
+
+	block := d.getOrNewBlock(bid.BlockNumber)
+	decision, ok := block.bids[bid.Hash()]
+	if !ok {
+		decision = &types.BidDecision{
+			BidHash:     bid.Hash(),
+			Builder:     bid.Builder,
+			BlockNumber: bid.BlockNumber,
+		}
+		block.bids[bid.Hash()] = decision
+	}
+
+	fn(decision)
+}
+
+// intake records the bid reached the bid simulator, err is set if it is rejected there.
+func (d *bidDecisions) intake(bid *types.Bid, timing bidTiming, err error) {
+	latency := timing.latency(time.Now())
+
+	d.update(bid, func(decision *types.BidDecision) {
+		decision.ReceivedAt = timing.receivedAt
+		decision.IntakeChecks = bidIntakeChecks
+		decision.Latency = latency
+		if err != nil {
+			decision.IntakeError = err.Error()
+		}
+	})
+}
+
+func (d *bidDecisions) preFilter(bid *types.Bid, comparison *types.BidComparison, latency *types.BidLatency) {
+	d.update(bid, func(decision *types.BidDecision) {
+		decision.PreFilter = comparison
+		if latency != nil {
+			decision.Latency = latency
+		}
+	})
+}
+
+// simulated records the latest simulation of the bid, bidRuntime.env is nil if it failed to prepare.
+func (d *bidDecisions) simulated(bidRuntime *BidRuntime, duration time.Duration, err error) {
+	result := &types.BidSimulationResult{Duration: duration}
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.BlockReward = bidRuntime.blockReward()
+		result.Bribe = bidRuntime.directBribeBNB()
+		result.TotalReward = bidRuntime.totalReward()
+	}
+
+	latency := bidRuntime.timing.latency(time.Now().Add(-duration))
+	if latency != nil {
+		latency.Simulation = duration
+	}
+
+	d.update(bidRuntime.bid, func(decision *types.BidDecision) {
+		decision.Simulation = result
+		if latency != nil {
+			decision.Latency = latency
+		}
+	})
+}
+
+func (d *bidDecisions) finalComparison(bid *types.Bid, comparison *types.BidComparison) {
+	d.update(bid, func(decision *types.BidDecision) {
+		decision.FinalComparison = comparison
+	})
+}
+
+// resolved records the outcome of the bid, one of win, lose, fail and unresolved.
+func (d *bidDecisions) resolved(bid *types.Bid, outcome string) {
+	d.update(bid, func(decision *types.BidDecision) {
+		decision.Outcome = outcome
+	})
+}
+
+// sealed records the winner of the block, bid is nil if the block is built locally.
+func (d *bidDecisions) sealed(number uint64, bid *types.Bid, totalReward *big.Int, redactBuilder bool) {
+	if d.retain == 0 {
+		return
+	}
+
+	winner := &types.BidOutcomeWinner{TotalReward: totalReward}
+	if bid != nil {
+		bidHash, builder := bid.Hash(), bid.Builder
+		winner.BidHash = &bidHash
+		if !redactBuilder {
+			winner.Builder = &builder
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.getOrNewBlock(number).winner = winner
+}
+
+// explain returns the outcome of the bid, the decision record is copied so that it won't
+// be changed afterwards.
+func (d *bidDecisions) explain(number uint64, bidHash common.Hash) (*types.BidOutcome, error) {
+	if d.retain == 0 {
+		return nil, errors.New("bid decision records are disabled")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	block, ok := d.blocks[number]
+	if !ok {
+		return nil, errBidDecisionNotFound
+	}
+
+	decision, ok := block.bids[bidHash]
+	if !ok {
+		return nil, errBidDecisionNotFound
+	}
+
+	cpy := *decision
+	outcome := &types.BidOutcome{Decision: &cpy}
+	if block.winner != nil {
+		winner := *block.winner
+		outcome.Winner = &winner
+		outcome.Won = winner.BidHash != nil && *winner.BidHash == bidHash
+	}
+
+	return outcome, nil
+}
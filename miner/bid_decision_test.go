@@ -0,0 +1,52 @@
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestBidDecisionsExplain(t *testing.T) {
+	var (
+		d     = newBidDecisions(2)
+		bid   = newTestBid(t, 1, 21000)
+		other = newTestBid(t, 1, 42000)
+
+		now    = time.Now()
+		timing = bidTiming{receivedAt: now.Add(-30 * time.Millisecond), decodedAt: now.Add(-20 * time.Millisecond)}
+	)
+
+	d.intake(bid, timing, nil)
+	d.preFilter(bid, &types.BidComparison{Against: againstNone, Value: big.NewInt(10), Won: true}, timing.latency(now))
+	d.finalComparison(bid, &types.BidComparison{Against: againstBestBid, Value: big.NewInt(9), AgainstValue: big.NewInt(12)})
+	d.intake(other, bidTiming{}, nil)
+	d.sealed(1, other, big.NewInt(12), true)
+
+	outcome, err := d.explain(1, bid.Hash())
+	if err != nil {
+		t.Fatalf("failed to explain: %v", err)
+	}
+	if outcome.Won || outcome.Decision.PreFilter == nil || outcome.Decision.FinalComparison.AgainstValue.Int64() != 12 {
+		t.Fatalf("unexpected outcome: %+v", outcome)
+	}
+	if latency := outcome.Decision.Latency; latency == nil || latency.Decode != 10*time.Millisecond || latency.Queue != 20*time.Millisecond {
+		t.Fatalf("unexpected latency: %+v", latency)
+	}
+	if outcome.Winner == nil || *outcome.Winner.BidHash != other.Hash() || outcome.Winner.Builder != nil {
+		t.Fatalf("unexpected winner: %+v", outcome.Winner)
+	}
+
+	outcome, _ = d.explain(1, other.Hash())
+	if !outcome.Won {
+		t.Fatalf("expected the winner to be won")
+	}
+
+	// records out of retention are pruned
+	d.intake(newTestBid(t, 3, 21000), bidTiming{}, nil)
+	if _, err = d.explain(1, bid.Hash()); !errors.Is(err, errBidDecisionNotFound) {
+		t.Fatalf("expected pruned record, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package miner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// maxConcurrentDryRuns is the number of the dry-run simulations run at the same time, the
+// dry runs are only for the builders to test their bids, so they must not crowd out the auction
+const maxConcurrentDryRuns = 2
+
+// DryRunBid simulates the bid on a fresh environment of its parent through the same commit and
+// reward checks as simBid, but the bid doesn't compete for the block: the best and simulating
+// bids, the builder stats and the issue reports are left untouched. The overrides are rejected
+// unless DryRunStateOverride is enabled, they are validated and applied to the parent state
+// before the bid txs.
+func (b *bidSimulator) DryRunBid(ctx context.Context, bid *types.Bid, overrides types.BidStateOverride) (*types.BidDryRunResult, error) {
+	if len(overrides) > 0 {
+		if !b.config.DryRunStateOverride {
+			return nil, types.NewInvalidBidError("state overrides are disabled")
+		}
+		if err := overrides.Validate(); err != nil {
+			return nil, types.NewInvalidBidError(fmt.Sprintf("invalid state override, %v", err))
+		}
+	}
+
+	select {
+	case b.dryRunSem <- struct{}{}:
+		defer func() { <-b.dryRunSem }()
+	default:
+		return nil, types.ErrMevBusy
+	}
+
+	env, err := b.bidWorker.prepareWork(&generateParams{
+		parentHash: bid.ParentHash,
+		coinbase:   b.bidWorker.etherbase(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bidRuntime := newBidRuntime(bid)
+	bidRuntime.env = env
+	// the env may be replaced by a copy to roll an optional tx back
+	defer func() { bidRuntime.env.discard() }()
+
+	applyStateOverride(env.state, overrides)
+
+	if b.config.BidSimulationMaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.config.BidSimulationMaxDuration)
+		defer cancel()
+	}
+
+	err = b.commitBidTxs(ctx, nil, bidRuntime)
+	if err == nil {
+		err = b.commitPayBidTx(ctx, bidRuntime)
+	}
+
+	result := &types.BidDryRunResult{
+		BidHash:       bid.Hash(),
+		GasUsed:       bidRuntime.env.header.GasUsed,
+		StateOverride: overrides,
+	}
+	if bidRuntime.packedBlockRewardPreBEP95Final != nil {
+		result.BlockReward = bidRuntime.blockReward()
+		result.DirectBribe = bidRuntime.directBribeBNB()
+		result.TotalReward = bidRuntime.totalReward()
+	}
+	if err != nil {
+		result.Error = err.Error()
+		result.Code = bidIssueCode(err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,127 @@
+package miner
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// chainTestWorker prepares the env of the bids on top of the chain, the fees are paid to the
+// system address like in Parlia.
+type chainTestWorker struct {
+	testMergeWorker
+	chain *core.BlockChain
+}
+
+func (w *chainTestWorker) etherbase() common.Address { return consensus.SystemAddress }
+
+func (w *chainTestWorker) prepareWork(genParams *generateParams) (*environment, error) {
+	parent := w.chain.GetHeaderByHash(genParams.parentHash)
+	statedb, err := w.chain.StateAt(parent.Root)
+	if err != nil {
+		return nil, err
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   parent.GasLimit,
+		Time:       parent.Time + 1,
+		Difficulty: common.Big1,
+		BaseFee:    eip1559.CalcBaseFee(w.chain.Config(), parent),
+	}
+
+	return &environment{
+		signer:   types.MakeSigner(w.chain.Config(), header.Number, header.Time),
+		state:    statedb,
+		header:   header,
+		coinbase: genParams.coinbase,
+	}, nil
+}
+
+func TestDryRunBidStateOverride(t *testing.T) {
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  types.GenesisAlloc{testBankAddress: {Balance: testBankFunds}},
+	}
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	config := DefaultMevConfig
+	config.DryRunStateOverride = true
+
+	b := newTestBidSimulator(t)
+	b.config = &config
+	b.chain = chain
+	b.chainConfig = chain.Config()
+	b.bidWorker = &chainTestWorker{chain: chain}
+
+	var (
+		genesis = chain.CurrentBlock()
+		signer  = types.LatestSigner(chain.Config())
+		price   = big.NewInt(10 * params.InitialBaseFee)
+	)
+	// the user holds no funds in the parent state, only in the overridden one
+	bid := newTestBid(t, genesis.Number.Uint64()+1, 2*params.TxGas)
+	bid.ParentHash = genesis.Hash()
+	bid.BuilderFee = big.NewInt(0)
+	bid.Txs = types.Transactions{
+		types.MustSignNewTx(testUserKey, signer, &types.LegacyTx{Nonce: 0, To: &testBankAddress, Value: big.NewInt(1), Gas: params.TxGas, GasPrice: price}),
+		types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: 0, To: &testBuilder, Gas: params.TxGas, GasPrice: price}),
+	}
+
+	result, err := b.DryRunBid(context.Background(), bid, nil)
+	if err != nil {
+		t.Fatalf("failed to dry run: %v", err)
+	}
+	if result.Error == "" || result.StateOverride != nil {
+		t.Fatalf("expected the unfunded bid to fail without overrides: %+v", result)
+	}
+
+	overrides := types.BidStateOverride{
+		testUserAddress: {Balance: (*hexutil.Big)(big.NewInt(params.Ether))},
+	}
+
+	// the overrides are only accepted by the validators enabling them
+	config.DryRunStateOverride = false
+	if _, err = b.DryRunBid(context.Background(), bid, overrides); err == nil {
+		t.Fatalf("expected the overrides to be rejected while disabled")
+	}
+	config.DryRunStateOverride = true
+
+	result, err = b.DryRunBid(context.Background(), bid, overrides)
+	if err != nil {
+		t.Fatalf("failed to dry run: %v", err)
+	}
+	if result.Error != "" || result.TotalReward == nil || result.TotalReward.Sign() <= 0 {
+		t.Fatalf("unexpected result with overrides: %+v", result)
+	}
+	if balance := result.StateOverride[testUserAddress].Balance; len(result.StateOverride) != 1 || balance == nil || balance.ToInt().Cmp(big.NewInt(params.Ether)) != 0 {
+		t.Fatalf("the applied overrides are not echoed: %+v", result.StateOverride)
+	}
+
+	// the overrides only live in the env of the dry run
+	statedb, err := chain.State()
+	if err != nil {
+		t.Fatalf("failed to get state: %v", err)
+	}
+	if balance := statedb.GetBalance(testUserAddress); !balance.IsZero() {
+		t.Fatalf("the overrides leaked into the chain state: %v", balance)
+	}
+	if best := b.GetBestBid(bid.ParentHash); best != nil {
+		t.Fatalf("unexpected best bid of the dry run %s", best.bid.Hash())
+	}
+}
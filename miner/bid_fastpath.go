@@ -0,0 +1,148 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/bidutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// fastPathMinSamples is the minimum number of simulations to measure the delivery ratio of a builder
+	fastPathMinSamples = 20
+)
+
+var (
+	bidFastPathAcceptCounter = metrics.NewRegisteredCounter("bid/fastpath/accept", nil)
+	bidFastPathRejectCounter = metrics.NewRegisteredCounter("bid/fastpath/reject", nil)
+	bidFastPathFailCounter   = metrics.NewRegisteredCounter("bid/fastpath/fail", nil)
+)
+
+// fastPathEligible returns nil if the builder delivered its bids reliably enough
+// for the fast path.
+func (b *bidSimulator) fastPathEligible(bidRuntime *BidRuntime) error {
+	b.statsMu.RLock()
+	defer b.statsMu.RUnlock()
+
+	stats, ok := b.stats[bidRuntime.bid.Builder]
+	if !ok || stats.simSamples < fastPathMinSamples {
+		return errors.New("not enough history of the builder")
+	}
+
+	if ratio := 1 - stats.failureRate; ratio < b.config.FastPathMinDeliveryRatio {
+		return fmt.Errorf("delivery ratio %.3f is below %.3f", ratio, b.config.FastPathMinDeliveryRatio)
+	}
+
+	return nil
+}
+
+// sendFastPathBid accepts the bid arrived after bidBetterBefore if it is obviously superior
+// to the best bid and its payment is confirmed by a partial verification. The accepted bid
+// is simulated as usual, and the worker waits for the simulation before sealing.
+func (b *bidSimulator) sendFastPathBid(ctx context.Context, bid *types.Bid) error {
+	parentHeader := b.chain.GetHeaderByHash(bid.ParentHash)
+	if parentHeader == nil {
+		return types.NewUnknownParentError(bid.ParentHash)
+	}
+
+	if bidMustBefore := bidutil.BidMustBefore(parentHeader, b.chainConfig.Parlia.Period, b.delayLeftOver); time.Now().After(bidMustBefore) {
+		return fmt.Errorf("too late even for fast path, must before %s", bidMustBefore)
+	}
+
+	bidRuntime := newBidRuntime(bid)
+	if err := b.fastPathEligible(bidRuntime); err != nil {
+		bidFastPathRejectCounter.Inc(1)
+		return err
+	}
+
+	bestBid := b.GetBestBid(bid.ParentHash)
+	if bestBid == nil || bestBid.preMergeEnv == nil {
+		bidFastPathRejectCounter.Inc(1)
+		return errors.New("no best bid to verify against")
+	}
+
+	threshold := new(big.Int).Mul(bestBid.totalRewardFromBuilder(), new(big.Int).SetUint64(b.config.FastPathValueMultiplier))
+	if bidRuntime.expectedRewardFromBuilder().Cmp(threshold) < 0 {
+		bidFastPathRejectCounter.Inc(1)
+		return fmt.Errorf("expected reward is less than %d times of the best", b.config.FastPathValueMultiplier)
+	}
+
+	if err := b.verifyPayment(bestBid, bid); err != nil {
+		bidFastPathRejectCounter.Inc(1)
+		return fmt.Errorf("payment is not confirmed, %v", err)
+	}
+
+	if err := b.enqueueBid(ctx, bid, true); err != nil {
+		return err
+	}
+
+	bidFastPathAcceptCounter.Inc(1)
+	log.Info("BidSimulator: fast path accepted", "block", bid.BlockNumber, "builder", bid.Builder,
+		"bidHash", bid.Hash().TerminalString(), "expected", weiToEtherStringF6(bidRuntime.expectedRewardFromBuilder()),
+		"best", weiToEtherStringF6(bestBid.totalRewardFromBuilder()))
+
+	return nil
+}
+
+// verifyPayment executes only the payBidTx and the transfers to the bribe EOAs of the bid
+// on a copy of the pre-merge environment of the best bid, to confirm the payment is real.
+func (b *bidSimulator) verifyPayment(bestBid *BidRuntime, bid *types.Bid) error {
+	// the snapshot is shared by the concurrent verifications
+	b.fastPathMu.Lock()
+	env := bestBid.preMergeEnv.copy()
+	b.fastPathMu.Unlock()
+
+	var (
+		r        = &BidRuntime{bid: bid, env: env, directBribe: big.NewInt(0)}
+		eoas     = b.bribeEOAs(r)
+		payBidTx = bid.Txs[len(bid.Txs)-1]
+		txs      = make([]*types.Transaction, 0, 2)
+	)
+
+	for _, tx := range bid.Txs[:len(bid.Txs)-1] {
+		if tx.To() != nil && slices.Contains(eoas, *tx.To()) {
+			txs = append(txs, tx)
+		}
+	}
+	txs = append(txs, payBidTx)
+
+	for _, tx := range txs {
+		balances := r.bribeBalances(eoas)
+
+		receipt, err := r.commitTransaction(context.Background(), b.chain, b.chainConfig, tx, true)
+		if err != nil {
+			return fmt.Errorf("tx %s, %v", tx.Hash().TerminalString(), err)
+		}
+		r.checkValidatorBribe(eoas, balances, receipt)
+	}
+
+	if r.directBribeBNB().Cmp(bid.NontaxableFee) < 0 {
+		return fmt.Errorf("bribe %s is less than declared %s",
+			weiToEtherStringF6(r.directBribeBNB()), weiToEtherStringF6(bid.NontaxableFee))
+	}
+
+	return nil
+}
+
+// recordFastPathFailure penalizes the builder whose fast path bid proved undeliverable,
+// the failure rate is set to the maximum, so the builder loses the fast path eligibility
+// and its bids are discounted if PenalizeFailingBuilders is on.
+func (b *bidSimulator) recordFastPathFailure(builder common.Address) {
+	bidFastPathFailCounter.Inc(1)
+
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	stats := b.getOrNewStats(builder)
+	stats.failureRate = 1
+
+	log.Warn("BidSimulator: fast path bid undeliverable", "builder", builder)
+}
@@ -0,0 +1,36 @@
+package miner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestFastPathEligible(t *testing.T) {
+	config := DefaultMevConfig
+	config.FastPathEnabled = true
+
+	b := &bidSimulator{
+		config: &config,
+		stats:  make(map[common.Address]*builderStats),
+	}
+	bidRuntime := newBidRuntime(newTestBid(t, 1, 21000))
+
+	if err := b.fastPathEligible(bidRuntime); err == nil {
+		t.Fatalf("expected unknown builder to be ineligible")
+	}
+
+	for i := 0; i < fastPathMinSamples; i++ {
+		b.recordSimResult(testBuilder, time.Millisecond, false)
+	}
+	if err := b.fastPathEligible(bidRuntime); err != nil {
+		t.Fatalf("expected reliable builder to be eligible: %v", err)
+	}
+
+	// an undeliverable fast path bid revokes the eligibility at once
+	b.recordFastPathFailure(testBuilder)
+	if err := b.fastPathEligible(bidRuntime); err == nil {
+		t.Fatalf("expected builder to be ineligible after fast path failure")
+	}
+}
@@ -0,0 +1,184 @@
+package miner
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	// bidFutureHeldCounter counts the bids held for the block being sealed
+	bidFutureHeldCounter = metrics.NewRegisteredCounter("bid/future/held", nil)
+
+	// bidFutureReleasedCounter counts the held bids sent to the simulation once their parents are the head
+	bidFutureReleasedCounter = metrics.NewRegisteredCounter("bid/future/released", nil)
+
+	// bidFutureExpiredCounter counts the held bids dropped for the timeout or a conflicting head
+	bidFutureExpiredCounter = metrics.NewRegisteredCounter("bid/future/expired", nil)
+)
+
+// futureBid is a bid built on top of the block the validator is sealing, it waits for the block
+// to become the head.
+type futureBid struct {
+	bid     *types.Bid
+	signer  types.Signer
+	timing  bidTiming
+	expires time.Time
+}
+
+// futureBidQueue holds the future bids by their parents, bounded per builder.
+type futureBidQueue struct {
+	mu     sync.Mutex
+	bids   map[common.Hash][]*futureBid // parentHash -> the bids held for it
+	counts map[common.Address]int       // builder -> the number of the bids held
+}
+
+// add holds the bid unless the builder holds limit bids already.
+func (q *futureBidQueue) add(fb *futureBid, limit int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.bids == nil {
+		q.bids = make(map[common.Hash][]*futureBid)
+		q.counts = make(map[common.Address]int)
+	}
+
+	bid := fb.bid
+	for _, held := range q.bids[bid.ParentHash] {
+		if held.bid.Hash() == bid.Hash() {
+			return types.ErrBidAlreadyExists
+		}
+	}
+
+	if q.counts[bid.Builder] >= limit {
+		return types.NewUnknownParentError(bid.ParentHash)
+	}
+
+	q.bids[bid.ParentHash] = append(q.bids[bid.ParentHash], fb)
+	q.counts[bid.Builder]++
+
+	return nil
+}
+
+// take returns the bids held for the head, and drops the ones expired or whose parents are
+// conflicting with the head.
+func (q *futureBidQueue) take(head *types.Header, now time.Time) (ready []*futureBid, dropped int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for parentHash, bids := range q.bids {
+		if parentHash == head.Hash() {
+			ready = append(ready, bids...)
+		} else {
+			kept := bids[:0]
+			for _, fb := range bids {
+				// the parent of the bid is of the height of the head or lower, it lost to the head
+				if fb.bid.BlockNumber <= head.Number.Uint64()+1 || now.After(fb.expires) {
+					dropped++
+					q.counts[fb.bid.Builder]--
+					continue
+				}
+				kept = append(kept, fb)
+			}
+
+			if len(kept) > 0 {
+				q.bids[parentHash] = kept
+				continue
+			}
+		}
+
+		delete(q.bids, parentHash)
+	}
+
+	for _, fb := range ready {
+		q.counts[fb.bid.Builder]--
+	}
+	for builder, count := range q.counts {
+		if count <= 0 {
+			delete(q.counts, builder)
+		}
+	}
+
+	return ready, dropped
+}
+
+// isSealingParent reports whether the unknown parent of the bid is the block the validator is
+// sealing, that is the bid is of two blocks after the head and the validator is in turn for the
+// next one. The hash of the block being sealed is unknown until it is signed, so it isn't checked.
+func (b *bidSimulator) isSealingParent(rawBid *types.RawBid) bool {
+	if b.config.FutureBidsPerBuilder <= 0 || !b.isRunning() {
+		return false
+	}
+
+	head := b.chain.CurrentHeader()
+	if head == nil || rawBid.BlockNumber != head.Number.Uint64()+2 {
+		return false
+	}
+
+	validator, err := b.engine.NextInTurnValidator(b.chain, head)
+	return err == nil && validator != (common.Address{}) && validator == b.bidWorker.etherbase()
+}
+
+// holdFutureBid holds the bid built on top of the block being sealed for up to one slot, it is
+// sent to the simulation by releaseFutureBids once the block is the head.
+func (b *bidSimulator) holdFutureBid(bid *types.Bid, signer types.Signer, timing bidTiming) error {
+	fb := &futureBid{
+		bid:     bid,
+		signer:  signer,
+		timing:  timing,
+		expires: time.Now().Add(time.Duration(b.chainConfig.Parlia.Period) * time.Second),
+	}
+	if err := b.futureBids.add(fb, b.config.FutureBidsPerBuilder); err != nil {
+		return err
+	}
+
+	bidFutureHeldCounter.Inc(1)
+	return nil
+}
+
+// releaseFutureBids sends the bids held for the head to newBidCh, the bids which can't be
+// built on top of the head any more are dropped.
+func (b *bidSimulator) releaseFutureBids(head *types.Block) {
+	ready, dropped := b.futureBids.take(head.Header(), time.Now())
+	bidFutureExpiredCounter.Inc(int64(dropped))
+
+	for _, fb := range ready {
+		if err := b.injectFutureBid(fb); err != nil {
+			log.Debug("BidSimulator: future bid dropped", "block", fb.bid.BlockNumber,
+				"builder", fb.bid.Builder, "hash", fb.bid.Hash().TerminalString(), "err", err)
+		}
+	}
+}
+
+// injectFutureBid runs the checks skipped while the parent of the bid was unknown, and queues
+// it without waiting, nobody waits for the feedback.
+func (b *bidSimulator) injectFutureBid(fb *futureBid) error {
+	bid := fb.bid
+	if err := b.preCheckBid(bid, fb.signer, true); err != nil {
+		b.auditBid(bidAuditRejected, bid, nil, err)
+		b.decisions.intake(bid, fb.timing, err)
+		return err
+	}
+
+	if err := b.CheckAndAddPending(bid.BlockNumber, bid.Builder, bid.Hash()); err != nil {
+		b.decisions.intake(bid, fb.timing, err)
+		return err
+	}
+
+	select {
+	case b.newBidCh <- newBidPackage{bid: bid, timing: fb.timing}:
+		bidFutureReleasedCounter.Inc(1)
+		bidQueueDepthGauge.Update(int64(len(b.newBidCh)))
+		b.decisions.intake(bid, fb.timing, nil)
+		return nil
+	default:
+		bidQueueRejectCounter.Inc(1)
+		b.RemovePending(bid.BlockNumber, bid.Builder, bid.Hash())
+		b.decisions.intake(bid, fb.timing, types.ErrMevBusy)
+		return types.ErrMevBusy
+	}
+}
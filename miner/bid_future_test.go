@@ -0,0 +1,64 @@
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestFutureBidQueue(t *testing.T) {
+	var (
+		q       futureBidQueue
+		now     = time.Now()
+		sealing = &types.Header{Number: big.NewInt(10), Extra: []byte("sealing")}
+	)
+	newFutureBid := func(gasUsed uint64, parentHash common.Hash, expires time.Time) *futureBid {
+		bid := newTestBid(t, 11, gasUsed)
+		bid.ParentHash = parentHash
+		return &futureBid{bid: bid, expires: expires}
+	}
+
+	held := newFutureBid(21000, sealing.Hash(), now.Add(time.Second))
+	if err := q.add(held, 2); err != nil {
+		t.Fatalf("failed to hold the bid: %v", err)
+	}
+	if err := q.add(held, 2); !errors.Is(err, types.ErrBidAlreadyExists) {
+		t.Fatalf("unexpected error for the duplicate bid: %v", err)
+	}
+	if err := q.add(newFutureBid(21001, sealing.Hash(), now.Add(time.Second)), 2); err != nil {
+		t.Fatalf("failed to hold the bid: %v", err)
+	}
+	if err := q.add(newFutureBid(21002, common.Hash{0x02}, now.Add(time.Second)), 2); err == nil {
+		t.Fatalf("expected the bid beyond the limit of the builder to be rejected")
+	}
+
+	// the bids of another parent of the same height lose to the head
+	q = futureBidQueue{}
+	other := newFutureBid(21003, common.Hash{0x02}, now.Add(time.Second))
+	q.add(held, 2)
+	q.add(other, 2)
+	ready, dropped := q.take(sealing, now)
+	if len(ready) != 1 || ready[0] != held || dropped != 1 {
+		t.Fatalf("unexpected take, ready %d, dropped %d", len(ready), dropped)
+	}
+	if len(q.bids) != 0 || len(q.counts) != 0 {
+		t.Fatalf("expected the queue to be empty, bids %d, counts %d", len(q.bids), len(q.counts))
+	}
+
+	// the bids of the block being sealed are kept over an older head until they expire
+	older := &types.Header{Number: big.NewInt(9)}
+	q.add(held, 2)
+	if ready, dropped = q.take(older, now); len(ready) != 0 || dropped != 0 {
+		t.Fatalf("unexpected take of the older head, ready %d, dropped %d", len(ready), dropped)
+	}
+	if ready, dropped = q.take(older, now.Add(2*time.Second)); len(ready) != 0 || dropped != 1 {
+		t.Fatalf("expected the bid to expire, ready %d, dropped %d", len(ready), dropped)
+	}
+	if len(q.counts) != 0 {
+		t.Fatalf("expected the count of the builder to be released")
+	}
+}
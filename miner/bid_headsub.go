@@ -0,0 +1,79 @@
+package miner
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// chainHeadResubscribeBackoff is the delay before the first resubscription to the chain head
+	// events, it doubles for each consecutive failure up to maxChainHeadResubscribeBackoff
+	chainHeadResubscribeBackoff    = 100 * time.Millisecond
+	maxChainHeadResubscribeBackoff = 10 * time.Second
+
+	// maxChainHeadSubFailures is the consecutive failures of the subscription after which the
+	// bids are no longer received, as they wouldn't be cleared with the heads
+	maxChainHeadSubFailures = 3
+
+	// chainHeadSubStablePeriod is the lifetime after which a subscription failing again counts
+	// as the first failure
+	chainHeadSubStablePeriod = time.Minute
+)
+
+var bidHeadSubFailCounter = metrics.NewRegisteredCounter("bid/headsub/fail", nil)
+
+// headSubState is the state of the chain head subscription kept by mainLoop.
+type headSubState struct {
+	failures     int
+	subscribedAt time.Time
+	retry        <-chan time.Time // fires to resubscribe, nil while subscribed
+	stopped      bool             // the receiving of bids is stopped by the failures
+}
+
+func newHeadSubState() *headSubState {
+	return &headSubState{subscribedAt: time.Now()}
+}
+
+// chainHeadSubFailed schedules the resubscription after the failure of the subscription with
+// backoff. The receiving of bids is stopped once it keeps failing, so the builders are rejected
+// instead of sending the bids that wouldn't be simulated.
+func (b *bidSimulator) chainHeadSubFailed(s *headSubState, err error) {
+	bidHeadSubFailCounter.Inc(1)
+	b.chainHeadSub.Unsubscribe()
+
+	if time.Since(s.subscribedAt) > chainHeadSubStablePeriod {
+		s.failures = 0
+	}
+	s.failures++
+
+	backoff := chainHeadResubscribeBackoff << (s.failures - 1)
+	if s.failures > 8 || backoff > maxChainHeadResubscribeBackoff {
+		backoff = maxChainHeadResubscribeBackoff
+	}
+	s.retry = time.After(backoff)
+
+	log.Warn("BidSimulator: chain head subscription failed, resubscribing", "failures", s.failures,
+		"backoff", backoff, "err", err)
+
+	if s.failures >= maxChainHeadSubFailures && b.receivingBid() {
+		log.Error("BidSimulator: chain head subscription keeps failing, stop receiving bids",
+			"failures", s.failures, "err", err)
+		b.stopReceivingBid()
+		s.stopped = true
+	}
+}
+
+// resubscribeChainHead subscribes to the chain head events again, the receiving of bids is
+// resumed if it was stopped by the failures.
+func (b *bidSimulator) resubscribeChainHead(s *headSubState) {
+	b.chainHeadSub = b.subscribeChainHead(b.chainHeadCh)
+	s.subscribedAt, s.retry = time.Now(), nil
+
+	if s.stopped {
+		log.Warn("BidSimulator: chain head resubscribed, resume receiving bids", "failures", s.failures)
+		b.bidReceiving.Store(true)
+		s.stopped = false
+	}
+}
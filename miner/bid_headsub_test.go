@@ -0,0 +1,81 @@
+package miner
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// newTestHeadSubSimulator runs mainLoop with the subscriptions failing at once the given
+// times, it returns the number of the subscriptions made.
+func newTestHeadSubSimulator(t *testing.T, failures int32) (*bidSimulator, *atomic.Int32) {
+	var subscribed atomic.Int32
+
+	b := newTestBidSimulator(t)
+	b.chainHeadCh = make(chan core.ChainHeadEvent, chainHeadChanSize)
+	b.simBidCh = make(chan *simBidReq)
+	b.subscribeChainHead = func(chan<- core.ChainHeadEvent) event.Subscription {
+		n := subscribed.Add(1)
+		return event.NewSubscription(func(quit <-chan struct{}) error {
+			if n <= failures {
+				return errors.New("subscription failed")
+			}
+			<-quit
+			return nil
+		})
+	}
+	b.chainHeadSub = b.subscribeChainHead(b.chainHeadCh)
+	b.bidReceiving.Store(true)
+
+	b.simWg.Add(1)
+	go b.mainLoop()
+
+	return b, &subscribed
+}
+
+func TestChainHeadResubscribe(t *testing.T) {
+	b, subscribed := newTestHeadSubSimulator(t, 1)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for subscribed.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("chain head is not resubscribed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !b.receivingBid() {
+		t.Fatal("bids are no longer received after a single failure")
+	}
+}
+
+func TestChainHeadResubscribeKeepsFailing(t *testing.T) {
+	b, subscribed := newTestHeadSubSimulator(t, maxChainHeadSubFailures)
+
+	// the receiving is stopped at the last failure, and resumed once resubscribed
+	deadline := time.Now().Add(5 * time.Second)
+	for subscribed.Load() < maxChainHeadSubFailures {
+		if time.Now().After(deadline) {
+			t.Fatal("chain head is not resubscribed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for b.receivingBid() {
+		if time.Now().After(deadline) {
+			t.Fatal("bids are still received while the subscription keeps failing")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for !b.receivingBid() {
+		if time.Now().After(deadline) {
+			t.Fatal("bids are not received again once resubscribed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := subscribed.Load(); n != maxChainHeadSubFailures+1 {
+		t.Fatalf("unexpected subscriptions %d, want %d", n, maxChainHeadSubFailures+1)
+	}
+}
@@ -0,0 +1,347 @@
+package miner
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	revenueGroupByDay   = "day"
+	revenueGroupByEpoch = "epoch"
+
+	secondsPerDay = 24 * 60 * 60
+)
+
+// bidHistoryPrefix + num (uint64 big endian) -> bidHistoryRecord
+var bidHistoryPrefix = []byte("mev-history-")
+
+// bidHistoryRecord is the record of a block proposed by the validator.
+type bidHistoryRecord struct {
+	Number      uint64
+	Hash        common.Hash
+	Time        uint64
+	Builder     common.Address // empty if the block is built locally
+	BidHash     common.Hash
+	GasReward   *big.Int // block reward after BEP95, including the contribution of greedy merge
+	Bribe       *big.Int
+	MergeReward *big.Int // contribution of the txs merged from mempool
+}
+
+func newBidHistoryRecord(block *types.Block, bid *BidRuntime, fees *big.Int) *bidHistoryRecord {
+	record := &bidHistoryRecord{
+		Number:      block.NumberU64(),
+		Hash:        block.Hash(),
+		Time:        block.Time(),
+		GasReward:   calcRewardAfterBEP95(fees),
+		Bribe:       new(big.Int),
+		MergeReward: new(big.Int),
+	}
+
+	if bid != nil {
+		record.Builder = bid.bid.Builder
+		record.BidHash = bid.bid.Hash()
+		record.GasReward = bid.blockReward()
+		record.Bribe = bid.directBribeBNB()
+		record.MergeReward = new(big.Int).Sub(bid.blockReward(), calcRewardAfterBEP95(bid.packedBlockRewardPreBEP95Builder.ToBig()))
+	}
+
+	return record
+}
+
+func (r *bidHistoryRecord) fromBid() bool {
+	return r.Builder != (common.Address{})
+}
+
+func bidHistoryKey(number uint64) []byte {
+	return append(append([]byte{}, bidHistoryPrefix...), encodeBlockNumber(number)...)
+}
+
+func encodeBlockNumber(number uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, number)
+	return enc
+}
+
+// revenueAgg aggregates the history records of a group.
+type revenueAgg struct {
+	key       string
+	fromBlock uint64
+	toBlock   uint64
+	lastTime  uint64
+
+	blocks      uint64
+	mevBlocks   uint64
+	gasReward   *big.Int
+	bribe       *big.Int
+	mergeReward *big.Int
+	builders    map[common.Address]*types.MevBuilderRevenue
+}
+
+func newRevenueAgg(key string) *revenueAgg {
+	return &revenueAgg{
+		key:         key,
+		gasReward:   new(big.Int),
+		bribe:       new(big.Int),
+		mergeReward: new(big.Int),
+		builders:    make(map[common.Address]*types.MevBuilderRevenue),
+	}
+}
+
+func (a *revenueAgg) add(r *bidHistoryRecord) {
+	if a.blocks == 0 {
+		a.fromBlock = r.Number
+	}
+	a.toBlock = r.Number
+	a.lastTime = r.Time
+
+	a.blocks++
+	a.gasReward.Add(a.gasReward, r.GasReward)
+	a.bribe.Add(a.bribe, r.Bribe)
+	a.mergeReward.Add(a.mergeReward, r.MergeReward)
+
+	if !r.fromBid() {
+		return
+	}
+
+	a.mevBlocks++
+
+	builder, ok := a.builders[r.Builder]
+	if !ok {
+		builder = &types.MevBuilderRevenue{
+			GasReward:   new(big.Int),
+			Bribe:       new(big.Int),
+			MergeReward: new(big.Int),
+		}
+		a.builders[r.Builder] = builder
+	}
+	builder.Blocks++
+	builder.GasReward.Add(builder.GasReward, r.GasReward)
+	builder.Bribe.Add(builder.Bribe, r.Bribe)
+	builder.MergeReward.Add(builder.MergeReward, r.MergeReward)
+}
+
+// toGroup returns a deep copy of the aggregate, so it is safe to keep aggregating.
+func (a *revenueAgg) toGroup() *types.MevRevenueGroup {
+	group := &types.MevRevenueGroup{
+		Key:         a.key,
+		FromBlock:   a.fromBlock,
+		ToBlock:     a.toBlock,
+		Blocks:      a.blocks,
+		MevBlocks:   a.mevBlocks,
+		GasReward:   new(big.Int).Set(a.gasReward),
+		Bribe:       new(big.Int).Set(a.bribe),
+		MergeReward: new(big.Int).Set(a.mergeReward),
+		Builders:    make(map[common.Address]*types.MevBuilderRevenue, len(a.builders)),
+	}
+
+	total := new(big.Int).Add(a.gasReward, a.bribe)
+	group.TotalRewardBNB, _ = new(big.Float).Quo(new(big.Float).SetInt(total), big.NewFloat(params.Ether)).Float64()
+
+	for addr, builder := range a.builders {
+		group.Builders[addr] = &types.MevBuilderRevenue{
+			Blocks:      builder.Blocks,
+			GasReward:   new(big.Int).Set(builder.GasReward),
+			Bribe:       new(big.Int).Set(builder.Bribe),
+			MergeReward: new(big.Int).Set(builder.MergeReward),
+		}
+	}
+
+	return group
+}
+
+func dayKey(day uint64) string {
+	return time.Unix(int64(day*secondsPerDay), 0).UTC().Format("2006-01-02")
+}
+
+// bidHistory is the persistent store of the blocks proposed by the validator,
+// it keeps the aggregate of the most recent day in memory.
+type bidHistory struct {
+	db ethdb.KeyValueStore
+
+	mu       sync.Mutex
+	today    uint64 // day index of the cached aggregate
+	todayAgg *revenueAgg
+}
+
+func newBidHistory(db ethdb.KeyValueStore) *bidHistory {
+	h := &bidHistory{db: db}
+	h.reload()
+
+	return h
+}
+
+// reload rebuilds the aggregate of the most recent day from the store.
+func (h *bidHistory) reload() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.today, h.todayAgg = 0, nil
+
+	it := h.db.NewIterator(bidHistoryPrefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		record := new(bidHistoryRecord)
+		if err := rlp.DecodeBytes(it.Value(), record); err != nil {
+			log.Warn("BidHistory: failed to decode record", "key", common.Bytes2Hex(it.Key()), "err", err)
+			continue
+		}
+		h.cache(record)
+	}
+}
+
+// cache must be called with mu held.
+func (h *bidHistory) cache(r *bidHistoryRecord) {
+	day := r.Time / secondsPerDay
+	if h.todayAgg == nil || day > h.today {
+		h.today, h.todayAgg = day, newRevenueAgg(dayKey(day))
+	}
+
+	if day == h.today {
+		h.todayAgg.add(r)
+	}
+}
+
+func (h *bidHistory) record(r *bidHistoryRecord) error {
+	enc, err := rlp.EncodeToBytes(r)
+	if err != nil {
+		return err
+	}
+
+	if err = h.db.Put(bidHistoryKey(r.Number), enc); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	h.cache(r)
+	h.mu.Unlock()
+
+	return nil
+}
+
+// revenueReport aggregates the records within the range by day or epoch with a streaming
+// pass over the store.
+func (h *bidHistory) revenueReport(args *types.MevRevenueReportArgs, epochLength uint64) ([]*types.MevRevenueGroup, error) {
+	var (
+		byBlock = args.FromBlock != nil || args.ToBlock != nil
+		byTime  = args.FromTime != nil || args.ToTime != nil
+
+		from, to uint64 = 0, ^uint64(0)
+	)
+
+	if byBlock == byTime {
+		return nil, errors.New("either block range or time range should be given")
+	}
+
+	if byBlock {
+		if args.FromBlock != nil {
+			from = uint64(*args.FromBlock)
+		}
+		if args.ToBlock != nil {
+			to = uint64(*args.ToBlock)
+		}
+	} else {
+		if args.FromTime != nil {
+			from = uint64(*args.FromTime)
+		}
+		if args.ToTime != nil {
+			to = uint64(*args.ToTime)
+		}
+	}
+
+	if from > to {
+		return nil, errors.New("invalid range")
+	}
+
+	groupBy := args.GroupBy
+	if groupBy == "" {
+		groupBy = revenueGroupByDay
+	}
+
+	var groupKey func(r *bidHistoryRecord) string
+	switch groupBy {
+	case revenueGroupByDay:
+		groupKey = func(r *bidHistoryRecord) string { return dayKey(r.Time / secondsPerDay) }
+	case revenueGroupByEpoch:
+		if epochLength == 0 {
+			return nil, errors.New("epoch is unknown")
+		}
+		groupKey = func(r *bidHistoryRecord) string { return strconv.FormatUint(r.Number/epochLength, 10) }
+	default:
+		return nil, errors.New("unknown group, expect day or epoch")
+	}
+
+	// the cached aggregate can be used if the whole recent day is queried
+	var (
+		cached *types.MevRevenueGroup
+		today  uint64
+	)
+	h.mu.Lock()
+	today = h.today
+	if byTime && groupBy == revenueGroupByDay && h.todayAgg != nil &&
+		from <= h.today*secondsPerDay && to >= h.todayAgg.lastTime {
+		cached = h.todayAgg.toGroup()
+	}
+	h.mu.Unlock()
+
+	var start []byte
+	if byBlock {
+		start = encodeBlockNumber(from)
+	}
+
+	it := h.db.NewIterator(bidHistoryPrefix, start)
+	defer it.Release()
+
+	var (
+		groups []*types.MevRevenueGroup
+		agg    *revenueAgg
+	)
+
+	for it.Next() {
+		record := new(bidHistoryRecord)
+		if err := rlp.DecodeBytes(it.Value(), record); err != nil {
+			log.Warn("BidHistory: failed to decode record", "key", common.Bytes2Hex(it.Key()), "err", err)
+			continue
+		}
+
+		// records are ordered by number, so as their time
+		if byBlock && record.Number > to || byTime && record.Time > to {
+			break
+		}
+		if byTime && record.Time < from {
+			continue
+		}
+		if cached != nil && record.Time/secondsPerDay >= today {
+			break
+		}
+
+		key := groupKey(record)
+		if agg == nil || agg.key != key {
+			if agg != nil {
+				groups = append(groups, agg.toGroup())
+			}
+			agg = newRevenueAgg(key)
+		}
+		agg.add(record)
+	}
+
+	if agg != nil {
+		groups = append(groups, agg.toGroup())
+	}
+	if cached != nil {
+		groups = append(groups, cached)
+	}
+
+	return groups, it.Error()
+}
@@ -0,0 +1,62 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+)
+
+func TestBidHistoryRevenueReport(t *testing.T) {
+	var (
+		h       = newBidHistory(memorydb.New())
+		builder = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		day     = uint64(20000)
+	)
+
+	records := []*bidHistoryRecord{
+		{Number: 100, Time: day * secondsPerDay, GasReward: big.NewInt(10), Bribe: big.NewInt(0), MergeReward: big.NewInt(0)},
+		{Number: 130, Time: day*secondsPerDay + 90, Builder: builder, GasReward: big.NewInt(20), Bribe: big.NewInt(5), MergeReward: big.NewInt(2)},
+		{Number: 30000, Time: (day+1)*secondsPerDay + 10, Builder: builder, GasReward: big.NewInt(30), Bribe: big.NewInt(1), MergeReward: big.NewInt(0)},
+	}
+	for _, r := range records {
+		if err := h.record(r); err != nil {
+			t.Fatalf("failed to record: %v", err)
+		}
+	}
+
+	from, to := hexutil.Uint64(0), hexutil.Uint64((day+2)*secondsPerDay)
+	groups, err := h.revenueReport(&types.MevRevenueReportArgs{FromTime: &from, ToTime: &to}, 200)
+	if err != nil {
+		t.Fatalf("failed to report: %v", err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(groups))
+	}
+	if groups[0].Blocks != 2 || groups[0].MevBlocks != 1 || groups[0].GasReward.Uint64() != 30 || groups[0].Bribe.Uint64() != 5 {
+		t.Fatalf("unexpected first day: %+v", groups[0])
+	}
+	if groups[0].Builders[builder].MergeReward.Uint64() != 2 {
+		t.Fatalf("unexpected merge reward: %v", groups[0].Builders[builder].MergeReward)
+	}
+	// the most recent day comes from the cached aggregate
+	if groups[1].Blocks != 1 || groups[1].GasReward.Uint64() != 30 || groups[1].Key != dayKey(day+1) {
+		t.Fatalf("unexpected second day: %+v", groups[1])
+	}
+
+	fromBlock, toBlock := hexutil.Uint64(101), hexutil.Uint64(30000)
+	groups, err = h.revenueReport(&types.MevRevenueReportArgs{FromBlock: &fromBlock, ToBlock: &toBlock, GroupBy: revenueGroupByEpoch}, 200)
+	if err != nil {
+		t.Fatalf("failed to report: %v", err)
+	}
+	if len(groups) != 2 || groups[0].Key != "0" || groups[0].Blocks != 1 || groups[1].Key != "150" {
+		t.Fatalf("unexpected epoch groups: %+v", groups)
+	}
+
+	if _, err = h.revenueReport(&types.MevRevenueReportArgs{}, 200); err == nil {
+		t.Fatalf("expected error without range")
+	}
+}
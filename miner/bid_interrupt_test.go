@@ -0,0 +1,128 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+func TestInterruptMinIncrease(t *testing.T) {
+	var (
+		config = DefaultMevConfig
+		sim    = newBidRuntime(newTestBid(t, 1, 21000))
+		small  = newBidRuntime(newTestBid(t, 1, 21000))
+		large  = newBidRuntime(newTestBid(t, 1, 21000))
+	)
+	sim.bid.GasFee = big.NewInt(1_000_000)
+	small.bid.GasFee = big.NewInt(1_005_000)
+	large.bid.GasFee = big.NewInt(1_020_000)
+
+	if small.interruptsSimulatingBid(sim, &config, rewardValuator{}) {
+		t.Fatalf("0.5%% increase should not interrupt")
+	}
+	if !large.interruptsSimulatingBid(sim, &config, rewardValuator{}) {
+		t.Fatalf("2%% increase should interrupt")
+	}
+
+	config.BidInterruptMinIncreaseBps = 0
+	if !small.interruptsSimulatingBid(sim, &config, rewardValuator{}) {
+		t.Fatalf("any increase should interrupt without a minimum")
+	}
+}
+
+func TestResumeDeferredBid(t *testing.T) {
+	var (
+		b = &bidSimulator{
+			newBidCh:     make(chan newBidPackage, 1),
+			deferredBids: make(map[common.Hash]*deferredBid),
+		}
+		low  = newTestBid(t, 1, 21000)
+		high = newTestBid(t, 1, 42000)
+	)
+
+	if kept := b.deferBid(newBidPackage{bid: high}, big.NewInt(2), big.NewInt(2)); kept != nil {
+		t.Fatalf("the first bid should be deferred")
+	}
+	kept := b.deferBid(newBidPackage{bid: low}, big.NewInt(1), big.NewInt(1))
+	if kept == nil || kept.pkg.bid != high {
+		t.Fatalf("the less valuable bid should be superseded by the deferred one")
+	}
+
+	b.resumeDeferredBid(low.ParentHash)
+	select {
+	case pkg := <-b.newBidCh:
+		if pkg.bid != high {
+			t.Fatalf("expected the most valuable deferred bid")
+		}
+	default:
+		t.Fatalf("deferred bid not resumed")
+	}
+
+	// resumed once only
+	b.resumeDeferredBid(low.ParentHash)
+	if len(b.newBidCh) != 0 {
+		t.Fatalf("unexpected resumed bid")
+	}
+}
+
+func TestDropDeferredBid(t *testing.T) {
+	var (
+		b = &bidSimulator{
+			newBidCh:     make(chan newBidPackage, 1),
+			deferredBids: make(map[common.Hash]*deferredBid),
+			bidStatuses:  make(map[uint64]map[common.Hash]*types.BidStatus),
+		}
+		low  = newTestBid(t, 1, 21000)
+		high = newTestBid(t, 1, 42000)
+	)
+
+	// the replaced bid is dropped, it was accepted when deferred, so it is recorded as rejected
+	dropped := bidDeferredDropCounter.Snapshot().Count()
+	b.deferBid(newBidPackage{bid: low}, big.NewInt(1), big.NewInt(1))
+	b.deferBid(newBidPackage{bid: high}, big.NewInt(2), big.NewInt(2))
+	if metrics.Enabled {
+		if n := bidDeferredDropCounter.Snapshot().Count() - dropped; n != 1 {
+			t.Fatalf("expected 1 dropped bid, got %d", n)
+		}
+	}
+	if status := b.bidStatuses[low.BlockNumber][low.Hash()]; status == nil || status.Status != types.BidStatusRejected {
+		t.Fatalf("the superseded bid is not rejected: %+v", status)
+	}
+
+	// so is the one resumed into a full channel
+	b.newBidCh <- newBidPackage{bid: low}
+	b.resumeDeferredBid(high.ParentHash)
+	if metrics.Enabled {
+		if n := bidDeferredDropCounter.Snapshot().Count() - dropped; n != 2 {
+			t.Fatalf("expected 2 dropped bids, got %d", n)
+		}
+	}
+	if status := b.bidStatuses[high.BlockNumber][high.Hash()]; status == nil || status.Status != types.BidStatusRejected {
+		t.Fatalf("the dropped bid is not rejected: %+v", status)
+	}
+	if len(b.deferredBids) != 0 {
+		t.Fatalf("dropped bid still deferred")
+	}
+}
+
+func TestClearDeferredBids(t *testing.T) {
+	var (
+		b     = newTestBidSimulator(t)
+		head  = newTestBid(t, 5, 21000)
+		stale = newTestBid(t, 3, 21000)
+		next  = newTestBid(t, 6, 21000)
+	)
+	stale.ParentHash, next.ParentHash = common.Hash{0x03}, common.Hash{0x06}
+
+	b.deferBid(newBidPackage{bid: head}, big.NewInt(1), big.NewInt(1))
+	b.deferBid(newBidPackage{bid: stale}, big.NewInt(1), big.NewInt(1))
+	b.deferBid(newBidPackage{bid: next}, big.NewInt(1), big.NewInt(1))
+
+	b.clearBids(head.ParentHash, head.BlockNumber, 3)
+	if len(b.deferredBids) != 1 || b.deferredBids[next.ParentHash] == nil {
+		t.Fatalf("expected the deferred bid of the next block only, got %d", len(b.deferredBids))
+	}
+}
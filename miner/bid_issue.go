@@ -0,0 +1,209 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// issueQueueSize is the number of the pending issue reports per builder, the oldest is
+	// dropped once the queue is full
+	issueQueueSize = 16
+
+	// defaultIssueReportRate is the default maximum issue reports sent per second
+	defaultIssueReportRate = 20
+
+	// issueReportAttempts is the attempts to send an issue report, only the transient failures
+	// are retried
+	issueReportAttempts = 3
+
+	// issueRetryBackoff is the backoff before the first retry, it doubles for each retry
+	issueRetryBackoff = 200 * time.Millisecond
+
+	// issueReportMaxDuration caps the time spent on an issue report including the retries, so
+	// an endpoint down doesn't hold the reports of the other builders for long
+	issueReportMaxDuration = 3 * time.Second
+)
+
+var (
+	// bidIssueDropCounter counts the issue reports dropped, it grows if the reports are saturated
+	bidIssueDropCounter = metrics.NewRegisteredCounter("bid/issue/drop", nil)
+
+	// bidIssueRetryDropCounter counts the issue reports dropped after the retries of transient failures
+	bidIssueRetryDropCounter = metrics.NewRegisteredCounter("bid/issue/retry/drop", nil)
+)
+
+// issueQueue buffers the issue reports per builder. They are sent by a single worker at a
+// limited rate, so a flood of bad bids can't pile up goroutines or hammer the sentry.
+type issueQueue struct {
+	mu     sync.Mutex
+	queues map[common.Address][]*types.BidIssue
+	order  []common.Address // the builders with pending issues, served in turn
+
+	notify  chan struct{}
+	limiter *rate.Limiter
+}
+
+func newIssueQueue(reportRate float64) *issueQueue {
+	if reportRate <= 0 {
+		reportRate = defaultIssueReportRate
+	}
+
+	return &issueQueue{
+		queues:  make(map[common.Address][]*types.BidIssue),
+		notify:  make(chan struct{}, 1),
+		limiter: rate.NewLimiter(rate.Limit(reportRate), 1),
+	}
+}
+
+// push queues the issue of its builder, the oldest pending issue of the builder is dropped
+// if its queue is full.
+func (q *issueQueue) push(issue *types.BidIssue) {
+	q.mu.Lock()
+	pending, ok := q.queues[issue.Builder]
+	if !ok {
+		q.order = append(q.order, issue.Builder)
+	}
+	if len(pending) >= issueQueueSize {
+		pending = pending[1:]
+		bidIssueDropCounter.Inc(1)
+	}
+	q.queues[issue.Builder] = append(pending, issue)
+	q.mu.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// pop returns the next pending issue, nil if there is none. The builders are served in
+// turn, so the flood of one builder doesn't delay the issues of others.
+func (q *issueQueue) pop() *types.BidIssue {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.order) == 0 {
+		return nil
+	}
+
+	builder := q.order[0]
+	q.order = q.order[1:]
+
+	pending := q.queues[builder]
+	if len(pending) == 1 {
+		delete(q.queues, builder)
+	} else {
+		q.queues[builder] = pending[1:]
+		q.order = append(q.order, builder)
+	}
+
+	return pending[0]
+}
+
+func (q *issueQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var n int
+	for _, pending := range q.queues {
+		n += len(pending)
+	}
+
+	return n
+}
+
+// issueLoop sends the queued issue reports at the limited rate.
+func (b *bidSimulator) issueLoop() {
+	for {
+		select {
+		case <-b.issues.notify:
+		case <-b.exitCh:
+			return
+		}
+
+		for issue := b.issues.pop(); issue != nil; issue = b.issues.pop() {
+			select {
+			case <-time.After(b.issues.limiter.Reserve().Delay()):
+			case <-b.exitCh:
+				return
+			}
+
+			b.sendIssue(issue)
+		}
+	}
+}
+
+// sendIssue reports the issue to the builder, it is skipped if the builder is removed since.
+// The transient failures are retried with backoff, within issueReportMaxDuration.
+func (b *bidSimulator) sendIssue(issue *types.BidIssue) {
+	cli, _ := b.GetBuilder(issue.Builder)
+	if cli == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), issueReportMaxDuration)
+	defer cancel()
+
+	backoff := issueRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := cli.ReportIssue(ctx, issue)
+		if err == nil {
+			return
+		}
+
+		if !isTransientReportError(err) {
+			log.Warn("BidSimulator: failed to report issue", "builder", issue.Builder, "err", err)
+			return
+		}
+		if attempt >= issueReportAttempts {
+			bidIssueRetryDropCounter.Inc(1)
+			log.Warn("BidSimulator: failed to report issue", "builder", issue.Builder, "attempts", attempt, "err", err)
+			return
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			bidIssueRetryDropCounter.Inc(1)
+			log.Warn("BidSimulator: failed to report issue", "builder", issue.Builder, "attempts", attempt, "err", err)
+			return
+		case <-b.exitCh:
+			return
+		}
+	}
+}
+
+// isTransientReportError reports whether the report failed on the network, a timeout or a 5xx
+// status, which is worth a retry. The rejections by the builder are not retried.
+func isTransientReportError(err error) bool {
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,82 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestIssueQueue(t *testing.T) {
+	var (
+		q       = newIssueQueue(0)
+		flooder = common.HexToAddress("0x1")
+		other   = common.HexToAddress("0x2")
+		dropped = bidIssueDropCounter.Snapshot().Count()
+	)
+
+	for i := 0; i < issueQueueSize+4; i++ {
+		q.push(&types.BidIssue{Builder: flooder, Message: string(rune('a' + i))})
+	}
+	q.push(&types.BidIssue{Builder: other})
+
+	// the oldest issues of the flooding builder are dropped
+	if n := q.len(); n != issueQueueSize+1 {
+		t.Fatalf("unexpected pending issues %d, want %d", n, issueQueueSize+1)
+	}
+	if metrics.Enabled {
+		if n := bidIssueDropCounter.Snapshot().Count() - dropped; n != 4 {
+			t.Fatalf("unexpected dropped issues %d, want 4", n)
+		}
+	}
+
+	// the builders are served in turn
+	if issue := q.pop(); issue.Builder != flooder || issue.Message != "e" {
+		t.Fatalf("unexpected first issue of %v %q", issue.Builder, issue.Message)
+	}
+	if issue := q.pop(); issue.Builder != other {
+		t.Fatalf("issue of the other builder is not served in turn, got %v", issue.Builder)
+	}
+
+	for i := 1; i < issueQueueSize; i++ {
+		if issue := q.pop(); issue == nil || issue.Builder != flooder {
+			t.Fatalf("unexpected issue %d: %v", i, issue)
+		}
+	}
+	if issue := q.pop(); issue != nil {
+		t.Fatalf("unexpected issue after drained: %v", issue)
+	}
+}
+
+type testRPCError struct{}
+
+func (testRPCError) Error() string  { return "rejected" }
+func (testRPCError) ErrorCode() int { return -32000 }
+
+func TestIsTransientReportError(t *testing.T) {
+	tests := []struct {
+		err       error
+		transient bool
+	}{
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{fmt.Errorf("post: %w", context.DeadlineExceeded), true},
+		{io.EOF, true},
+		{rpc.HTTPError{StatusCode: http.StatusBadGateway}, true},
+		{rpc.HTTPError{StatusCode: http.StatusBadRequest}, false},
+		{testRPCError{}, false},
+		{errors.New("unknown"), false},
+	}
+	for i, tt := range tests {
+		if transient := isTransientReportError(tt.err); transient != tt.transient {
+			t.Errorf("test %d: transient mismatch, want %v, got %v", i, tt.transient, transient)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package miner
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// bidTiming is the timestamps of a bid at the RPC layer, threaded through the context
+// of the RPC call into the bid package.
+type bidTiming struct {
+	receivedAt time.Time // the request is received by the transport, before decoding
+	decodedAt  time.Time // the arguments of the request are decoded
+}
+
+// bidTimingFromContext reads the timestamps set by the RPC layer, the missing ones
+// fall back to the next known one, or now.
+func bidTimingFromContext(ctx context.Context) bidTiming {
+	timing := bidTiming{decodedAt: time.Now()}
+	if decodedAt, ok := rpc.DecodedAtFromContext(ctx); ok {
+		timing.decodedAt = decodedAt
+	}
+
+	timing.receivedAt = timing.decodedAt
+	if receivedAt, ok := rpc.ReceivedAtFromContext(ctx); ok {
+		timing.receivedAt = receivedAt
+	}
+
+	return timing
+}
+
+// latency returns the breakdown of the time spent by the bid until now, nil if the
+// timestamps are unknown.
+func (t bidTiming) latency(now time.Time) *types.BidLatency {
+	if t.decodedAt.IsZero() {
+		return nil
+	}
+
+	return &types.BidLatency{
+		Decode: t.decodedAt.Sub(t.receivedAt),
+		Queue:  now.Sub(t.decodedAt),
+	}
+}
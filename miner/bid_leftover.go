@@ -0,0 +1,101 @@
+package miner
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// leftOverWindowSize is the number of the recent seal latencies the leftover adapts to
+	leftOverWindowSize = 64
+
+	// leftOverMinSamples is the seal latencies needed to adapt the leftover, the configured
+	// BidSimulationLeftOver is used until then
+	leftOverMinSamples = 8
+
+	// leftOverPercentile is the percentile of the seal latencies the leftover adapts to, so an
+	// occasional slow seal doesn't push the deadline of the bids ahead
+	leftOverPercentile = 90
+)
+
+// bidLeftOverGauge is the effective BidSimulationLeftOver in milliseconds
+var bidLeftOverGauge = metrics.NewRegisteredGauge("bid/leftover/effective", nil)
+
+// sealLatencies keeps the recent time the worker takes from taking the best bid to handing the
+// block to the sealer, the oldest one is overwritten once it is full.
+type sealLatencies struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	full    bool
+}
+
+func newSealLatencies(size int) *sealLatencies {
+	return &sealLatencies{samples: make([]time.Duration, size)}
+}
+
+// add adds the latency, and returns the percentile of the window, false if there are not
+// enough samples yet.
+func (s *sealLatencies) add(latency time.Duration) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.samples[s.next] = latency
+	s.next = (s.next + 1) % len(s.samples)
+	if s.next == 0 {
+		s.full = true
+	}
+
+	size := s.next
+	if s.full {
+		size = len(s.samples)
+	}
+	if size < leftOverMinSamples {
+		return 0, false
+	}
+
+	sorted := slices.Clone(s.samples[:size])
+	slices.Sort(sorted)
+
+	return sorted[(size-1)*leftOverPercentile/100], true
+}
+
+// simulationLeftOver returns the effective BidSimulationLeftOver, the adapted one if
+// AdaptiveLeftOver is set and enough seals are observed.
+func (b *bidSimulator) simulationLeftOver() time.Duration {
+	if b.config.AdaptiveLeftOver {
+		if leftOver := b.leftOver.Load(); leftOver > 0 {
+			return time.Duration(leftOver)
+		}
+	}
+
+	return b.config.BidSimulationLeftOver
+}
+
+// RecordSealLatency records the time the worker takes from taking the best bid to handing the
+// block to the sealer, the leftover is adapted to the recent ones within the bounds.
+func (b *bidSimulator) RecordSealLatency(latency time.Duration) {
+	if !b.config.AdaptiveLeftOver {
+		return
+	}
+
+	leftOver, ok := b.sealLatencies.add(latency)
+	if !ok {
+		return
+	}
+
+	maxLeftOver := b.config.AdaptiveLeftOverMax
+	if maxLeftOver <= 0 {
+		maxLeftOver = 4 * b.config.BidSimulationLeftOver
+	}
+	leftOver = min(max(leftOver, b.config.AdaptiveLeftOverMin), maxLeftOver)
+
+	if prev := time.Duration(b.leftOver.Swap(int64(leftOver))); prev != leftOver {
+		log.Debug("BidSimulator: leftover adapted", "leftOver", leftOver, "prev", prev, "latency", latency)
+	}
+	bidLeftOverGauge.Update(leftOver.Milliseconds())
+}
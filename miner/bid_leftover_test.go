@@ -0,0 +1,44 @@
+package miner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdaptiveLeftOver(t *testing.T) {
+	config := DefaultMevConfig
+	config.AdaptiveLeftOver = true
+	config.AdaptiveLeftOverMin = 20 * time.Millisecond
+	config.AdaptiveLeftOverMax = 100 * time.Millisecond
+
+	b := &bidSimulator{config: &config, sealLatencies: newSealLatencies(leftOverWindowSize)}
+
+	// the configured leftover is used until enough seals are observed
+	for i := 0; i < leftOverMinSamples-1; i++ {
+		b.RecordSealLatency(30 * time.Millisecond)
+	}
+	if leftOver := b.simulationLeftOver(); leftOver != config.BidSimulationLeftOver {
+		t.Fatalf("expected the configured leftover, got %v", leftOver)
+	}
+
+	b.RecordSealLatency(30 * time.Millisecond)
+	if leftOver := b.simulationLeftOver(); leftOver != 30*time.Millisecond {
+		t.Fatalf("expected the adapted leftover, got %v", leftOver)
+	}
+
+	// bounded by the maximum
+	for i := 0; i < leftOverWindowSize; i++ {
+		b.RecordSealLatency(time.Second)
+	}
+	if leftOver := b.simulationLeftOver(); leftOver != config.AdaptiveLeftOverMax {
+		t.Fatalf("expected the maximum leftover, got %v", leftOver)
+	}
+
+	// bounded by the minimum
+	for i := 0; i < leftOverWindowSize; i++ {
+		b.RecordSealLatency(time.Millisecond)
+	}
+	if leftOver := b.simulationLeftOver(); leftOver != config.AdaptiveLeftOverMin {
+		t.Fatalf("expected the minimum leftover, got %v", leftOver)
+	}
+}
@@ -0,0 +1,227 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// gatedTestWorker holds the simulations in prepareWork until they are released, so they are
+// observed in flight.
+type gatedTestWorker struct {
+	chainTestWorker
+	entered chan common.Hash
+	proceed chan struct{}
+}
+
+func (w *gatedTestWorker) prepareWork(genParams *generateParams) (*environment, error) {
+	w.entered <- genParams.parentHash
+	<-w.proceed
+	return w.chainTestWorker.prepareWork(genParams)
+}
+
+// newTestMainLoopSimulator runs the mainLoop of a bid simulator on a chain of two blocks,
+// the genesis and its child are the parents of the simulated bids.
+func newTestMainLoopSimulator(t *testing.T) (*bidSimulator, *gatedTestWorker, []*types.Header) {
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  types.GenesisAlloc{testBankAddress: {Balance: testBankFunds}},
+	}
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	t.Cleanup(chain.Stop)
+
+	_, blocks, _ := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 1, nil)
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+
+	config := DefaultMevConfig
+	config.SimulateOutOfTurn = true
+
+	worker := &gatedTestWorker{
+		chainTestWorker: chainTestWorker{chain: chain},
+		entered:         make(chan common.Hash, 4),
+		proceed:         make(chan struct{}),
+	}
+
+	b := newTestBidSimulator(t)
+	b.config = &config
+	b.chain = chain
+	b.chainConfig = chain.Config()
+	b.engine = shadowTestEngine{ethash.NewFaker()}
+	b.bidWorker = worker
+	b.stats = make(map[common.Address]*builderStats)
+	b.chainHeadCh = make(chan core.ChainHeadEvent, chainHeadChanSize)
+	b.simBidCh = make(chan *simBidReq)
+	b.subscribeChainHead = func(chan<- core.ChainHeadEvent) event.Subscription {
+		return event.NewSubscription(func(quit <-chan struct{}) error {
+			<-quit
+			return nil
+		})
+	}
+	b.chainHeadSub = b.subscribeChainHead(b.chainHeadCh)
+	b.bidReceiving.Store(true)
+
+	b.simWg.Add(1)
+	go b.mainLoop()
+
+	return b, worker, []*types.Header{chain.Genesis().Header(), blocks[0].Header()}
+}
+
+// newTestChainBid creates a bid on top of the parent, the value tells apart the bids of the
+// same parent.
+func newTestChainBid(t *testing.T, b *bidSimulator, parent *types.Header, value int64) *BidRuntime {
+	var (
+		signer = types.LatestSigner(b.chainConfig)
+		price  = big.NewInt(10 * params.InitialBaseFee)
+	)
+	bid := newTestBid(t, parent.Number.Uint64()+1, 2*params.TxGas)
+	bid.ParentHash = parent.Hash()
+	bid.BuilderFee = big.NewInt(0)
+	bid.Txs = types.Transactions{
+		types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: 0, To: &testUserAddress, Value: big.NewInt(value), Gas: params.TxGas, GasPrice: price}),
+		types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: 1, To: &testBuilder, Gas: params.TxGas, GasPrice: price}),
+	}
+
+	return newBidRuntime(bid)
+}
+
+func waitEntered(t *testing.T, worker *gatedTestWorker) common.Hash {
+	t.Helper()
+
+	select {
+	case parentHash := <-worker.entered:
+		return parentHash
+	case <-time.After(5 * time.Second):
+		t.Fatal("simulation not started")
+	}
+	return common.Hash{}
+}
+
+func waitFinished(t *testing.T, bids ...*BidRuntime) {
+	t.Helper()
+
+	for _, bid := range bids {
+		select {
+		case <-bid.finished:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("simulation of bid %s not finished", bid.bid.Hash())
+		}
+	}
+}
+
+func TestMainLoopSimulatesParentsConcurrently(t *testing.T) {
+	b, worker, parents := newTestMainLoopSimulator(t)
+
+	bids := []*BidRuntime{newTestChainBid(t, b, parents[0], 1), newTestChainBid(t, b, parents[1], 1)}
+	for _, bid := range bids {
+		b.simBidCh <- &simBidReq{bid: bid, interruptCh: make(chan int32, 1)}
+	}
+
+	// both simulations are held in prepareWork at the same time
+	entered := map[common.Hash]bool{waitEntered(t, worker): true, waitEntered(t, worker): true}
+	if !entered[parents[0].Hash()] || !entered[parents[1].Hash()] {
+		t.Fatalf("the simulations of both parents should be in flight, got %v", entered)
+	}
+	close(worker.proceed)
+	waitFinished(t, bids...)
+
+	for i, bid := range bids {
+		if best := b.GetBestBid(parents[i].Hash()); best != bid {
+			t.Fatalf("unexpected best bid of parent %d: %v", i, best)
+		}
+	}
+}
+
+func TestMainLoopChainsSameParent(t *testing.T) {
+	b, worker, parents := newTestMainLoopSimulator(t)
+
+	var (
+		first  = newTestChainBid(t, b, parents[0], 1)
+		second = newTestChainBid(t, b, parents[0], 2)
+
+		firstInterrupt = make(chan int32, 1)
+	)
+	b.simBidCh <- &simBidReq{bid: first, interruptCh: firstInterrupt}
+	waitEntered(t, worker)
+
+	// interrupted like newBidLoop does for a better bid
+	firstInterrupt <- commitInterruptBetterBid
+	close(firstInterrupt)
+	b.simBidCh <- &simBidReq{bid: second, interruptCh: make(chan int32, 1)}
+
+	select {
+	case <-worker.entered:
+		t.Fatal("the bid of the same parent started before the running one exited")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(worker.proceed)
+	waitEntered(t, worker)
+	select {
+	case <-first.finished:
+	default:
+		t.Fatal("the interrupted simulation has not exited")
+	}
+	waitFinished(t, second)
+
+	if best := b.GetBestBid(parents[0].Hash()); best != second {
+		t.Fatalf("unexpected best bid %v", best)
+	}
+	if !first.envDiscarded {
+		t.Fatal("the env of the interrupted bid is not discarded")
+	}
+}
+
+func TestCloseWaitsForSimulations(t *testing.T) {
+	b, worker, parents := newTestMainLoopSimulator(t)
+
+	bids := []*BidRuntime{newTestChainBid(t, b, parents[0], 1), newTestChainBid(t, b, parents[1], 1)}
+	for _, bid := range bids {
+		b.simBidCh <- &simBidReq{bid: bid, interruptCh: make(chan int32, 1)}
+	}
+	waitEntered(t, worker)
+	waitEntered(t, worker)
+
+	closed := make(chan struct{})
+	go func() {
+		b.close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+		t.Fatal("close returned with the simulations in flight")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(worker.proceed)
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("close not returned")
+	}
+
+	for _, bid := range bids {
+		select {
+		case <-bid.finished:
+		default:
+			t.Fatalf("simulation of bid %s not exited", bid.bid.Hash())
+		}
+		if !bid.envDiscarded {
+			t.Fatalf("the env of bid %s is not discarded", bid.bid.Hash())
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package miner
+
+import (
+	"context"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	// bidMergeCounter counts the best bids replaced by the merged ones
+	bidMergeCounter = metrics.NewRegisteredCounter("bid/merge", nil)
+	// bidMergeTxCounter counts the txs of the second best bids merged into the best ones
+	bidMergeTxCounter = metrics.NewRegisteredCounter("bid/merge/tx", nil)
+	// bidMergeConflictCounter counts the txs not merged for touching the accounts of the best bid
+	bidMergeConflictCounter = metrics.NewRegisteredCounter("bid/merge/conflict", nil)
+)
+
+// touchedAccounts adds the accounts touched by the committed tx to the set, the accounts it
+// accessed if they are recorded in the env. Otherwise, e.g. for the txs of the greedy merge,
+// they are the sender, the recipient or the created contract, and the emitters of the logs, the
+// accounts touched only in the storage of other contracts are not tracked then.
+func touchedAccounts(set map[common.Address]struct{}, env *environment, from common.Address, tx *types.Transaction, receipt *types.Receipt) {
+	if accessed, ok := env.touched[tx.Hash()]; ok {
+		for _, addr := range accessed {
+			set[addr] = struct{}{}
+		}
+		return
+	}
+
+	set[from] = struct{}{}
+	if to := tx.To(); to != nil {
+		set[*to] = struct{}{}
+	}
+
+	if receipt == nil {
+		return
+	}
+	if receipt.ContractAddress != (common.Address{}) {
+		set[receipt.ContractAddress] = struct{}{}
+	}
+	for _, l := range receipt.Logs {
+		set[l.Address] = struct{}{}
+	}
+}
+
+// recordTouchedAccounts records the accounts accessed by the tx just committed to the env, if
+// the env records them, see touchedAccounts. The precompiles are left out, they hold no state.
+func recordTouchedAccounts(env *environment, chainConfig *params.ChainConfig, tx *types.Transaction) {
+	if env.touched == nil {
+		return
+	}
+
+	// the accesses are not tracked before Berlin
+	accessed := env.state.AccessListAddresses()
+	if accessed == nil {
+		return
+	}
+
+	precompiles := vm.ActivePrecompiles(chainConfig.Rules(env.header.Number, false, env.header.Time))
+	env.touched[tx.Hash()] = slices.DeleteFunc(accessed, func(addr common.Address) bool {
+		return slices.Contains(precompiles, addr)
+	})
+}
+
+// envTouchedAccounts returns the accounts touched by the txs committed in the env, except the
+// coinbase and the system address which every tx pays.
+func envTouchedAccounts(env *environment) map[common.Address]struct{} {
+	touched := make(map[common.Address]struct{}, 2*len(env.txs))
+	for i, tx := range env.txs {
+		from, _ := types.Sender(env.signer, tx)
+
+		var receipt *types.Receipt
+		if i < len(env.receipts) {
+			receipt = env.receipts[i]
+		}
+		touchedAccounts(touched, env, from, tx, receipt)
+	}
+
+	delete(touched, env.coinbase)
+	delete(touched, consensus.SystemAddress)
+
+	return touched
+}
+
+// mergeCandidates returns the txs of the second best bid touching none of the accounts touched
+// by the best one, in their order. The payBidTx of the second bid is left out as it pays the
+// builder of the second bid, and so are the later txs of a sender whose tx is left out, or
+// they would fail on the nonce.
+func mergeCandidates(best *environment, second *BidRuntime) []*types.Transaction {
+	var (
+		bestTouched = envTouchedAccounts(best)
+		bestTxs     = make(map[common.Hash]struct{}, len(best.txs))
+		receipts    = make(map[common.Hash]*types.Receipt, len(second.env.receipts))
+		blocked     = make(map[common.Address]struct{})
+		candidates  []*types.Transaction
+	)
+	for _, tx := range best.txs {
+		bestTxs[tx.Hash()] = struct{}{}
+	}
+	for _, receipt := range second.env.receipts {
+		receipts[receipt.TxHash] = receipt
+	}
+
+	for _, tx := range second.bid.Txs[:len(second.bid.Txs)-1] {
+		from, err := types.Sender(second.env.signer, tx)
+		if err != nil {
+			continue
+		}
+		if _, ok := blocked[from]; ok {
+			continue
+		}
+
+		// the optional txs skipped in the simulation of the second bid have no receipts
+		receipt, ok := receipts[tx.Hash()]
+		if _, dup := bestTxs[tx.Hash()]; dup || !ok {
+			blocked[from] = struct{}{}
+			continue
+		}
+
+		touched := make(map[common.Address]struct{})
+		touchedAccounts(touched, second.env, from, tx, receipt)
+		delete(touched, second.env.coinbase)
+		delete(touched, consensus.SystemAddress)
+
+		for addr := range touched {
+			if _, ok := bestTouched[addr]; ok {
+				bidMergeConflictCounter.Inc(1)
+				blocked[from] = struct{}{}
+				break
+			}
+		}
+		if _, ok := blocked[from]; ok {
+			continue
+		}
+
+		candidates = append(candidates, tx)
+	}
+
+	return candidates
+}
+
+// mergeBids appends the txs of the second best bid not conflicting with the best one to a copy
+// of the env of the best bid, and replaces the best bid with the merged one if it rewards the
+// validator more. The txs are committed like the optional ones, the failed or reverted ones
+// are skipped. A best bid is merged once only.
+func (b *bidSimulator) mergeBids(ctx context.Context, best, second *BidRuntime) {
+	if best.mergedFrom != nil || best.bid.Builder == second.bid.Builder || second.env == nil {
+		return
+	}
+
+	delay := b.engine.Delay(b.chain, best.env.header, &b.delayLeftOver)
+	if delay == nil || *delay <= 0 {
+		return
+	}
+
+	candidates := mergeCandidates(best.env, second)
+	if len(candidates) == 0 {
+		return
+	}
+
+	// the env of the best bid is copied, it is sealed from as is until the merged one wins
+	if !best.Acquire() {
+		return
+	}
+	mergedEnv := best.env.copy()
+	best.Release()
+
+	var (
+		start  = time.Now()
+		merged = &BidRuntime{
+			bid:                              best.bid,
+			env:                              mergedEnv,
+			sidecarSize:                      best.sidecarSize,
+			packedBlockRewardPreBEP95Builder: best.packedBlockRewardPreBEP95Builder,
+			directBribe:                      new(big.Int).Set(best.directBribe),
+			penaltyBps:                       best.penaltyBps,
+			finished:                         make(chan struct{}),
+			duration:                         best.duration,
+			fastPath:                         best.fastPath,
+			timing:                           best.timing,
+			mergedFrom:                       second.bid,
+		}
+		blocked = make(map[common.Address]struct{})
+		count   int
+	)
+	close(merged.finished)
+
+	for _, tx := range candidates {
+		select {
+		case <-b.exitCh:
+			merged.env.discard()
+			return
+		case <-ctx.Done():
+			merged.env.discard()
+			return
+		default:
+		}
+
+		from, _ := types.Sender(merged.env.signer, tx)
+		if _, ok := blocked[from]; ok {
+			continue
+		}
+		if checkTxGasPrice(tx, b.minGasPrice, merged.env.header.BaseFee) != nil {
+			blocked[from] = struct{}{}
+			continue
+		}
+
+		receipt, err := merged.commitOptionalTransaction(ctx, b.chain, b.chainConfig, tx)
+		if err != nil {
+			merged.env.discard()
+			return
+		}
+		if receipt == nil {
+			blocked[from] = struct{}{}
+			continue
+		}
+		count++
+	}
+
+	merged.updatePackReward(false)
+	if count == 0 || merged.totalReward().Cmp(best.totalReward()) <= 0 {
+		merged.env.discard()
+		return
+	}
+
+	if b.config.ParanoidMode {
+		b.startVerification(merged, best.duration+time.Since(start))
+	}
+	if !b.replaceBestBid(best.bid.ParentHash, best, merged) {
+		merged.discardEnv()
+		return
+	}
+
+	bidMergeCounter.Inc(1)
+	bidMergeTxCounter.Inc(int64(count))
+	log.Info("BidSimulator: merged bids", "block", best.bid.BlockNumber, "builder", best.bid.Builder,
+		"bidHash", best.bid.Hash().TerminalString(), "mergedBuilder", second.bid.Builder,
+		"mergedBidHash", second.bid.Hash().TerminalString(), "txs", count,
+		"reward", weiToEtherStringF6(best.totalReward()), "mergedReward", weiToEtherStringF6(merged.totalReward()),
+		"elapsed", common.PrettyDuration(time.Since(start)))
+}
+
+// replaceBestBid replaces the best bid of the parent with bid if it is still old, and returns
+// whether it is replaced.
+func (b *bidSimulator) replaceBestBid(prevBlockHash common.Hash, old, bid *BidRuntime) bool {
+	b.bestBidMu.Lock()
+	defer b.bestBidMu.Unlock()
+
+	if b.bestBid[prevBlockHash] != old {
+		return false
+	}
+
+	if !b.retainBackupBidLocked(prevBlockHash, old) {
+		old.discardEnv()
+	}
+	b.bestBid[prevBlockHash] = bid
+
+	return true
+}
@@ -0,0 +1,212 @@
+package miner
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestMergeCandidates(t *testing.T) {
+	var (
+		signer   = types.LatestSigner(params.TestChainConfig)
+		coinbase = common.HexToAddress("0xc0")
+		pool     = common.HexToAddress("0x10") // touched by both bids
+		token    = common.HexToAddress("0x20") // emits logs in the best bid
+		other    = common.HexToAddress("0x30")
+	)
+
+	newKey := func() *ecdsa.PrivateKey {
+		key, _ := crypto.GenerateKey()
+		return key
+	}
+	newTx := func(key *ecdsa.PrivateKey, nonce uint64, to common.Address) *types.Transaction {
+		tx, err := types.SignNewTx(key, signer, &types.LegacyTx{Nonce: nonce, Gas: 21000, To: &to})
+		if err != nil {
+			t.Fatalf("failed to sign tx: %v", err)
+		}
+		return tx
+	}
+	newReceipt := func(tx *types.Transaction, emitters ...common.Address) *types.Receipt {
+		receipt := &types.Receipt{TxHash: tx.Hash(), Status: types.ReceiptStatusSuccessful}
+		for _, addr := range emitters {
+			receipt.Logs = append(receipt.Logs, &types.Log{Address: addr})
+		}
+		return receipt
+	}
+
+	var (
+		bestKey, a, c, d, e = newKey(), newKey(), newKey(), newKey(), newKey()
+
+		bestTx = newTx(bestKey, 0, pool)
+		best   = &environment{
+			signer:   signer,
+			coinbase: coinbase,
+			txs:      []*types.Transaction{bestTx},
+			receipts: []*types.Receipt{newReceipt(bestTx, token)},
+		}
+
+		free      = newTx(a, 0, other)        // no conflict
+		conflict  = newTx(c, 0, pool)         // touches the pool of the best bid
+		followUp  = newTx(c, 1, other)        // blocked by the nonce of the conflicting tx
+		logged    = newTx(d, 0, other)        // emits the logs of the token
+		internal  = newTx(e, 0, other)        // calls the pool of the best bid internally
+		payBidTx  = newTx(newKey(), 0, other) // pays the builder of the second bid
+		secondTxs = types.Transactions{free, conflict, followUp, logged, internal, payBidTx}
+		receipts  = []*types.Receipt{newReceipt(free), newReceipt(conflict), newReceipt(followUp), newReceipt(logged, token),
+			newReceipt(internal), newReceipt(payBidTx)}
+		second = &BidRuntime{
+			bid: &types.Bid{Txs: secondTxs},
+			env: &environment{signer: signer, coinbase: coinbase, receipts: receipts, touched: map[common.Hash][]common.Address{
+				internal.Hash(): {crypto.PubkeyToAddress(e.PublicKey), other, pool},
+			}},
+		}
+	)
+
+	candidates := mergeCandidates(best, second)
+	if len(candidates) != 1 || candidates[0] != free {
+		t.Fatalf("unexpected candidates: %d", len(candidates))
+	}
+}
+
+// swapTestEngine runs onDelay when the time left is checked, e.g. to replace the best bid in
+// the middle of a merge.
+type swapTestEngine struct {
+	shadowTestEngine
+	onDelay func()
+}
+
+func (e swapTestEngine) Delay(chain consensus.ChainReader, header *types.Header, leftOver *time.Duration) *time.Duration {
+	e.onDelay()
+	return e.shadowTestEngine.Delay(chain, header, leftOver)
+}
+
+// newTestMergeBids simulates the best bid of the genesis, and a second bid of another builder
+// by another simulator so its env is kept. It returns the simulator of the best bid.
+func newTestMergeBids(t *testing.T) (b *bidSimulator, best, second *BidRuntime) {
+	var (
+		secondKey, _  = crypto.GenerateKey()
+		secondAddress = crypto.PubkeyToAddress(secondKey.PublicKey)
+		secondBuilder = common.HexToAddress("0x2")
+		other         = common.HexToAddress("0x3")
+	)
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: types.GenesisAlloc{
+			testBankAddress: {Balance: testBankFunds},
+			secondAddress:   {Balance: testBankFunds},
+		},
+	}
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	t.Cleanup(chain.Stop)
+
+	config := DefaultMevConfig
+	config.EnableBidMerging = true
+	config.SimulateOutOfTurn = true
+
+	newSimulator := func() *bidSimulator {
+		b := newTestBidSimulator(t)
+		b.config = &config
+		b.chain = chain
+		b.chainConfig = chain.Config()
+		b.engine = shadowTestEngine{ethash.NewFaker()}
+		b.bidWorker = &chainTestWorker{chain: chain}
+		b.stats = make(map[common.Address]*builderStats)
+		b.bidReceiving.Store(true)
+		return b
+	}
+
+	var (
+		genesis = chain.CurrentBlock()
+		signer  = types.LatestSigner(chain.Config())
+		price   = big.NewInt(10 * params.InitialBaseFee)
+	)
+	newBid := func(key *ecdsa.PrivateKey, builder, to common.Address) *BidRuntime {
+		bid := newTestBid(t, genesis.Number.Uint64()+1, 2*params.TxGas)
+		bid.ParentHash = genesis.Hash()
+		bid.Builder = builder
+		bid.BuilderFee = big.NewInt(0)
+		bid.Txs = types.Transactions{
+			types.MustSignNewTx(key, signer, &types.LegacyTx{Nonce: 0, To: &to, Value: big.NewInt(1), Gas: params.TxGas, GasPrice: price}),
+			types.MustSignNewTx(key, signer, &types.LegacyTx{Nonce: 1, To: &builder, Gas: params.TxGas, GasPrice: price}),
+		}
+		return newBidRuntime(bid)
+	}
+
+	b = newSimulator()
+	best = newBid(testBankKey, testBuilder, testUserAddress)
+	b.simBid(nil, best)
+	if b.GetBestBid(genesis.Hash()) != best {
+		t.Fatal("the first bid is not the best bid")
+	}
+
+	secondSim := newSimulator()
+	second = newBid(secondKey, secondBuilder, other)
+	secondSim.simBid(nil, second)
+	if secondSim.GetBestBid(genesis.Hash()) != second {
+		t.Fatal("the second bid is not simulated")
+	}
+
+	return b, best, second
+}
+
+func TestMergeBids(t *testing.T) {
+	b, best, second := newTestMergeBids(t)
+
+	// the accesses of the committed txs are recorded
+	touched := second.env.touched[second.bid.Txs[0].Hash()]
+	if !slices.Contains(touched, *second.bid.Txs[0].To()) || slices.Contains(touched, common.BytesToAddress([]byte{1})) {
+		t.Fatalf("unexpected touched accounts %v", touched)
+	}
+
+	b.mergeBids(context.Background(), best, second)
+
+	merged := b.GetBestBid(best.bid.ParentHash)
+	if merged == best || merged.mergedFrom != second.bid {
+		t.Fatal("the best bid is not replaced by the merged one")
+	}
+	if merged.totalReward().Cmp(best.totalReward()) <= 0 {
+		t.Fatalf("the merged reward %v is not above the best reward %v", merged.totalReward(), best.totalReward())
+	}
+	if len(merged.env.txs) != len(best.env.txs)+1 {
+		t.Fatalf("unexpected merged txs %d, the best bid has %d", len(merged.env.txs), len(best.env.txs))
+	}
+}
+
+func TestMergeBidsBestChanged(t *testing.T) {
+	b, best, second := newTestMergeBids(t)
+
+	// a newer best bid is set while the bids are merged
+	newer := newBidRuntime(best.bid)
+	b.engine = swapTestEngine{
+		shadowTestEngine: shadowTestEngine{ethash.NewFaker()},
+		onDelay: func() {
+			b.bestBidMu.Lock()
+			b.bestBid[best.bid.ParentHash] = newer
+			b.bestBidMu.Unlock()
+		},
+	}
+	b.mergeBids(context.Background(), best, second)
+
+	if current := b.GetBestBid(best.bid.ParentHash); current != newer {
+		t.Fatalf("the newer best bid is replaced by %v", current)
+	}
+	if best.envDiscarded {
+		t.Fatal("the env of the replaced best bid is discarded by the merge")
+	}
+}
@@ -0,0 +1,180 @@
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// defaultBidHistoryPageSize is the entries returned by mev_bidHistory if no limit is given
+const defaultBidHistoryPageSize = 100
+
+// bidCompetition is the competition of the bids for a block.
+type bidCompetition struct {
+	bids        int
+	simFailures int
+}
+
+// bidOutcomeRing keeps the outcomes of the recent sealed blocks in memory, the oldest one is
+// overwritten once it is full. Unlike bidHistory, it doesn't need a store and keeps the
+// competition of each block.
+type bidOutcomeRing struct {
+	mu      sync.RWMutex
+	entries []*types.BidHistoryEntry
+	next    int  // the index to write the next entry
+	full    bool // the entries wrapped around
+	byHash  map[common.Hash]*types.BidHistoryEntry
+
+	// the bids of the block are cleared on the new head before the block is sealed, so the
+	// competition is counted on the side
+	competitionMu sync.Mutex
+	competitions  map[uint64]*bidCompetition // blockNumber -> competition
+}
+
+func newBidOutcomeRing(size uint64) *bidOutcomeRing {
+	return &bidOutcomeRing{
+		entries:      make([]*types.BidHistoryEntry, size),
+		byHash:       make(map[common.Hash]*types.BidHistoryEntry, size),
+		competitions: make(map[uint64]*bidCompetition),
+	}
+}
+
+func (r *bidOutcomeRing) competition(blockNumber uint64, fn func(c *bidCompetition)) {
+	r.competitionMu.Lock()
+	defer r.competitionMu.Unlock()
+
+	c, ok := r.competitions[blockNumber]
+	if !ok {
+		c = &bidCompetition{}
+		r.competitions[blockNumber] = c
+	}
+	fn(c)
+}
+
+// takeCompetition removes the competitions of the blocks up to the number, and returns the
+// one of the block.
+func (r *bidOutcomeRing) takeCompetition(number uint64) bidCompetition {
+	r.competitionMu.Lock()
+	defer r.competitionMu.Unlock()
+
+	var taken bidCompetition
+	for n, c := range r.competitions {
+		if n > number {
+			continue
+		}
+		if n == number {
+			taken = *c
+		}
+		delete(r.competitions, n)
+	}
+
+	return taken
+}
+
+func (r *bidOutcomeRing) append(entry *types.BidHistoryEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if old := r.entries[r.next]; old != nil {
+		delete(r.byHash, old.BlockHash)
+	}
+	r.entries[r.next] = entry
+	r.byHash[entry.BlockHash] = entry
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// page returns the entries newest first, filtered by the builder if given.
+func (r *bidOutcomeRing) page(builder *common.Address, offset, limit uint64) *types.BidHistoryPage {
+	if limit == 0 {
+		limit = defaultBidHistoryPageSize
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	size := r.next
+	if r.full {
+		size = len(r.entries)
+	}
+
+	page := &types.BidHistoryPage{Entries: make([]*types.BidHistoryEntry, 0)}
+	for i := 0; i < size; i++ {
+		entry := r.entries[(r.next-1-i+len(r.entries))%len(r.entries)]
+		if builder != nil && (entry.Builder == nil || *entry.Builder != *builder) {
+			continue
+		}
+
+		if page.Total >= offset && uint64(len(page.Entries)) < limit {
+			page.Entries = append(page.Entries, entry)
+		}
+		page.Total++
+	}
+
+	return page
+}
+
+// find returns the entry of the block, nil if it is not kept.
+func (r *bidOutcomeRing) find(blockHash common.Hash) *types.BidHistoryEntry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.byHash[blockHash]
+}
+
+// newBidHistoryEntry summarizes the outcome and the competition of the sealed block.
+func (r *bidOutcomeRing) newBidHistoryEntry(block *types.Block, bid *BidRuntime, reward *big.Int) *types.BidHistoryEntry {
+	competition := r.takeCompetition(block.NumberU64())
+
+	entry := &types.BidHistoryEntry{
+		BlockNumber: block.NumberU64(),
+		BlockHash:   block.Hash(),
+		ParentHash:  block.ParentHash(),
+		Reward:      reward,
+		Bids:        competition.bids,
+		SimFailures: competition.simFailures,
+	}
+	if bid != nil {
+		builder, bidHash := bid.bid.Builder, bid.bid.Hash()
+		entry.Builder, entry.BidHash = &builder, &bidHash
+		entry.GreedyMerged = bid.blockReward().Cmp(calcRewardAfterBEP95(bid.packedBlockRewardPreBEP95Builder.ToBig())) > 0
+	}
+
+	return entry
+}
+
+// BidHistory returns the outcomes of the recent sealed blocks newest first.
+func (b *bidSimulator) BidHistory(args *types.BidHistoryArgs) (*types.BidHistoryPage, error) {
+	if b.outcomes == nil {
+		return nil, errors.New("bid history is disabled")
+	}
+
+	return b.outcomes.page(args.Builder, uint64(args.Offset), uint64(args.Limit)), nil
+}
+
+// BlockOrigin returns whether the block is built from a bid or locally, nil if the block is
+// not sealed by the validator within the retained window.
+func (b *bidSimulator) BlockOrigin(blockHash common.Hash) (*types.BlockOrigin, error) {
+	if b.outcomes == nil {
+		return nil, errors.New("bid history is disabled")
+	}
+
+	entry := b.outcomes.find(blockHash)
+	if entry == nil {
+		return nil, nil
+	}
+
+	return &types.BlockOrigin{
+		BlockNumber: entry.BlockNumber,
+		BlockHash:   entry.BlockHash,
+		FromBid:     entry.Builder != nil,
+		Builder:     entry.Builder,
+		BidHash:     entry.BidHash,
+		Reward:      entry.Reward,
+	}, nil
+}
@@ -0,0 +1,55 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestBidOutcomeRing(t *testing.T) {
+	var (
+		r     = newBidOutcomeRing(4)
+		other = common.Address{0x02}
+	)
+
+	r.competition(2, func(c *bidCompetition) { c.bids += 3 })
+	r.competition(2, func(c *bidCompetition) { c.simFailures++ })
+	r.competition(1, func(c *bidCompetition) { c.bids++ })
+
+	entry := r.newBidHistoryEntry(types.NewBlockWithHeader(&types.Header{Number: big.NewInt(2)}), nil, big.NewInt(1))
+	if entry.Bids != 3 || entry.SimFailures != 1 || entry.Builder != nil {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+	if len(r.competitions) != 0 {
+		t.Fatalf("competitions of the older blocks are not pruned")
+	}
+
+	// blocks 1-6 alternate between the builders, only the last 4 are kept
+	for number := uint64(1); number <= 6; number++ {
+		builder := testBuilder
+		if number%2 == 0 {
+			builder = other
+		}
+		r.append(&types.BidHistoryEntry{BlockNumber: number, BlockHash: common.Hash{byte(number)}, Builder: &builder})
+	}
+
+	page := r.page(nil, 0, 0)
+	if page.Total != 4 || len(page.Entries) != 4 || page.Entries[0].BlockNumber != 6 || page.Entries[3].BlockNumber != 3 {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+
+	page = r.page(&other, 1, 1)
+	if page.Total != 2 || len(page.Entries) != 1 || page.Entries[0].BlockNumber != 4 {
+		t.Fatalf("unexpected filtered page: %+v", page)
+	}
+
+	// the overwritten blocks are not found by hash
+	if r.find(common.Hash{2}) != nil || len(r.byHash) != 4 {
+		t.Fatalf("overwritten entry is still indexed")
+	}
+	if found := r.find(common.Hash{5}); found == nil || found.BlockNumber != 5 {
+		t.Fatalf("unexpected entry found: %+v", found)
+	}
+}
@@ -0,0 +1,40 @@
+package miner
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+)
+
+// applyStateOverride applies the overrides to the state like eth_call does, they are finalised
+// as if they were made by a tx before the bid. It is for the dry runs only, the bids competing
+// for the block are always simulated on the real parent state.
+func applyStateOverride(statedb *state.StateDB, overrides types.BidStateOverride) {
+	if len(overrides) == 0 {
+		return
+	}
+
+	for addr, account := range overrides {
+		if account.Nonce != nil {
+			statedb.SetNonce(addr, uint64(*account.Nonce))
+		}
+		if account.Code != nil {
+			statedb.SetCode(addr, *account.Code)
+		}
+		if account.Balance != nil {
+			balance, _ := uint256.FromBig((*big.Int)(account.Balance))
+			statedb.SetBalance(addr, balance)
+		}
+		if account.State != nil {
+			statedb.SetStorage(addr, *account.State)
+		}
+		if account.StateDiff != nil {
+			for key, value := range *account.StateDiff {
+				statedb.SetState(addr, key, value)
+			}
+		}
+	}
+	statedb.Finalise(false)
+}
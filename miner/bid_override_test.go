@@ -0,0 +1,54 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestApplyStateOverride(t *testing.T) {
+	var (
+		statedb, _ = state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		eoa        = common.HexToAddress("0x1")
+		token      = common.HexToAddress("0x2")
+		kept       = common.Hash{0x01}
+		replaced   = common.Hash{0x02}
+		nonce      = hexutil.Uint64(7)
+		code       = hexutil.Bytes{0x60, 0x00}
+		storage    = map[common.Hash]common.Hash{replaced: {0x03}}
+		diff       = map[common.Hash]common.Hash{replaced: {0x04}}
+	)
+	statedb.SetState(token, kept, common.Hash{0x05})
+	statedb.SetState(eoa, kept, common.Hash{0x05})
+
+	applyStateOverride(statedb, types.BidStateOverride{
+		eoa:   {Nonce: &nonce, Balance: (*hexutil.Big)(big.NewInt(100)), StateDiff: &diff},
+		token: {Code: &code, State: &storage},
+	})
+
+	if n := statedb.GetNonce(eoa); n != 7 {
+		t.Fatalf("unexpected nonce %d", n)
+	}
+	if balance := statedb.GetBalance(eoa); balance.Uint64() != 100 {
+		t.Fatalf("unexpected balance %v", balance)
+	}
+	if c := statedb.GetCode(token); string(c) != string(code) {
+		t.Fatalf("unexpected code %x", c)
+	}
+
+	// the state replaces the whole storage, the state diff only the given slots
+	if v := statedb.GetState(token, kept); v != (common.Hash{}) {
+		t.Fatalf("storage not replaced, got %x", v)
+	}
+	if v := statedb.GetState(eoa, kept); v != (common.Hash{0x05}) {
+		t.Fatalf("storage not kept by the diff, got %x", v)
+	}
+	if v := statedb.GetState(eoa, replaced); v != (common.Hash{0x04}) {
+		t.Fatalf("storage not overridden by the diff, got %x", v)
+	}
+}
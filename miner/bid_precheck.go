@@ -0,0 +1,251 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+var (
+	bidPreCheckRejectCounter = metrics.NewRegisteredCounter("bid/precheck/reject", nil)
+
+	// bidLegacyHashCounter counts the bids signed with the legacy hash
+	bidLegacyHashCounter = metrics.NewRegisteredCounter("bid/hash/legacy", nil)
+
+	// bidPreCheckNonceCounter counts the bids rejected as the txs of a sender are out of order
+	bidPreCheckNonceCounter = metrics.NewRegisteredCounter("bid/precheck/nonce", nil)
+
+	// bidPreCheckPayBidTxCounter counts the bids rejected as the last tx is not a payBidTx
+	bidPreCheckPayBidTxCounter = metrics.NewRegisteredCounter("bid/precheck/paybidtx", nil)
+
+	// bidPreCheckBlobsCounter counts the bids rejected as their blobs can't fit in a block
+	bidPreCheckBlobsCounter = metrics.NewRegisteredCounter("bid/precheck/blobs", nil)
+)
+
+// errInvalidPayBidTx is the last tx of the bid which is not a plain transfer, the simulation
+// would account the effect of it as the payment.
+var errInvalidPayBidTx = errors.New("invalid payBidTx")
+
+// nonceOrderError is the tx of the sender whose nonce is not next to the one of the earlier tx
+// of the same sender in the bid.
+type nonceOrderError struct {
+	sender   common.Address
+	nonce    uint64
+	expected uint64
+}
+
+func (e *nonceOrderError) Error() string {
+	return fmt.Sprintf("nonce %d of %s out of order, expected %d", e.nonce, e.sender, e.expected)
+}
+
+// checkHashVersion rejects the bids signed with the legacy hash once the compatibility window
+// ends, the legacy ones are counted meanwhile to tell when the builders have migrated.
+func (b *bidSimulator) checkHashVersion(rawBid *types.RawBid) error {
+	if rawBid.HashVersion != types.BidHashLegacy {
+		return nil
+	}
+
+	bidLegacyHashCounter.Inc(1)
+	if b.config.RejectLegacyBidHash {
+		return types.NewInvalidBidError("legacy bid hash is no longer accepted, sign the canonical hash")
+	}
+	return nil
+}
+
+// preCheckBid validates the txs of the bid statically, so an obviously broken bid is rejected
+// before prepareWork and the EVM execution. The senders are cached in the txs when they are
+// decoded, so the check doesn't repeat the ECDSA recovery. The misordered txs are reported to
+// the builder as an issue if report is set, it is not for the dry runs.
+func (b *bidSimulator) preCheckBid(bid *types.Bid, signer types.Signer, report bool) error {
+	parent := b.chain.GetHeaderByHash(bid.ParentHash)
+	if parent == nil {
+		return types.NewUnknownParentError(bid.ParentHash)
+	}
+
+	// a stale block number with a fresh parent would be filed as pending under the wrong block
+	if expected := parent.Number.Uint64() + 1; bid.BlockNumber != expected {
+		bidPreCheckRejectCounter.Inc(1)
+		return types.NewBlockNumberError(bid.BlockNumber, expected)
+	}
+
+	if err := checkPayBidTx(bid.Txs); err != nil {
+		bidPreCheckRejectCounter.Inc(1)
+		bidPreCheckPayBidTxCounter.Inc(1)
+		return types.NewInvalidPayBidTxError(err.Error())
+	}
+
+	// the blobs are only bounded tx by tx in the simulation, the bid may fail at the last one
+	if blobs, maxBlobs := countBlobs(bid.Txs), params.MaxBlobGasPerBlock/params.BlobTxBlobGasPerBlob; blobs > maxBlobs {
+		bidPreCheckRejectCounter.Inc(1)
+		bidPreCheckBlobsCounter.Inc(1)
+		return types.NewTooManyBlobsError(blobs, maxBlobs)
+	}
+
+	if err := preCheckBidTxs(b.chainConfig, parent, bid.Txs, signer); err != nil {
+		bidPreCheckRejectCounter.Inc(1)
+
+		// the misordered txs would fail the simulation midway, the builder is told which one
+		var nonceErr *nonceOrderError
+		if errors.As(err, &nonceErr) {
+			bidPreCheckNonceCounter.Inc(1)
+			if report {
+				b.reportIssue(newBidRuntime(bid), err)
+			}
+		}
+		return types.NewInvalidBidError(err.Error())
+	}
+
+	// the payBidTx is left to the simulation if the parent state is not available
+	if statedb, err := b.chain.StateAt(parent.Root); err == nil {
+		if err = preCheckPayBidTx(statedb, bid.Txs, signer); err != nil {
+			bidPreCheckRejectCounter.Inc(1)
+			if errors.Is(err, errInvalidPayBidTx) {
+				bidPreCheckPayBidTxCounter.Inc(1)
+				return types.NewInvalidPayBidTxError(err.Error())
+			}
+			return types.NewInvalidBidError(err.Error())
+		}
+	}
+
+	return nil
+}
+
+// countBlobs returns the number of the blobs declared by the blob txs.
+func countBlobs(txs []*types.Transaction) int {
+	var blobs int
+	for _, tx := range txs {
+		blobs += len(tx.BlobHashes())
+	}
+
+	return blobs
+}
+
+// checkPayBidTx checks the bid has the txs of the builder followed by the payBidTx, and the
+// payBidTx is a plain transfer, as the simulation commits the last tx as the payment.
+//
+// The recipient and the value of the payBidTx are not checked against the validator: the
+// payBidTx pays the builder fee out of the block reward, and the one appended by the sentry
+// carries no value at all. What it actually pays is checked on the realized balances instead,
+// see BidRuntime.checkPayment, so a bid declaring a reward it doesn't leave is rejected whoever
+// the payBidTx pays.
+func checkPayBidTx(txs []*types.Transaction) error {
+	if len(txs) < 2 {
+		return fmt.Errorf("%w: expect at least 2 txs with the payBidTx last, got %d", errInvalidPayBidTx, len(txs))
+	}
+
+	payBidTx := txs[len(txs)-1]
+	switch {
+	case payBidTx.To() == nil:
+		return fmt.Errorf("%w: tx %s is a contract creation", errInvalidPayBidTx, payBidTx.Hash().TerminalString())
+	case len(payBidTx.Data()) != 0:
+		return fmt.Errorf("%w: tx %s is not a plain transfer, %d bytes of data", errInvalidPayBidTx,
+			payBidTx.Hash().TerminalString(), len(payBidTx.Data()))
+	case payBidTx.Gas() > params.PayBidTxGasLimit:
+		return fmt.Errorf("%w: tx %s gas %d exceeds %d", errInvalidPayBidTx,
+			payBidTx.Hash().TerminalString(), payBidTx.Gas(), params.PayBidTxGasLimit)
+	}
+
+	return nil
+}
+
+// accountReader is the part of the parent state read by preCheckPayBidTx.
+type accountReader interface {
+	GetBalance(addr common.Address) *uint256.Int
+	GetNonce(addr common.Address) uint64
+	GetCodeSize(addr common.Address) int
+}
+
+// preCheckPayBidTx rejects the payBidTx, the last of the txs, that pays a contract or can't be
+// executed after the other txs on top of the parent state. The effect of the other txs on the
+// sender is estimated from their declared values only, so the check errs on the side of the simulation: a payBidTx
+// funded by a contract call in the bundle still goes through.
+func preCheckPayBidTx(statedb accountReader, txs []*types.Transaction, signer types.Signer) error {
+	var (
+		payBidTx = txs[len(txs)-1]
+		earlier  = txs[:len(txs)-1]
+	)
+
+	// the sender is cached by preCheckBidTxs
+	from, err := types.Sender(signer, payBidTx)
+	if err != nil {
+		return fmt.Errorf("payBidTx %s: invalid sender, %v", payBidTx.Hash().TerminalString(), err)
+	}
+
+	// a transfer to a contract runs its code, it is not a plain payment
+	if to := *payBidTx.To(); statedb.GetCodeSize(to) != 0 {
+		return fmt.Errorf("%w: tx %s pays the contract %v", errInvalidPayBidTx, payBidTx.Hash().TerminalString(), to)
+	}
+
+	var (
+		nonce   = statedb.GetNonce(from)
+		balance = statedb.GetBalance(from).ToBig()
+	)
+
+	for _, tx := range earlier {
+		if tx.To() != nil && *tx.To() == from {
+			balance.Add(balance, tx.Value())
+		}
+		if sender, _ := types.Sender(signer, tx); sender == from {
+			nonce++
+			balance.Sub(balance, tx.Value())
+		}
+	}
+
+	// a higher nonce may be filled by the merged mempool txs
+	if payBidTx.Nonce() < nonce {
+		return fmt.Errorf("payBidTx %s: %w: address %v, tx: %d state: %d", payBidTx.Hash().TerminalString(),
+			core.ErrNonceTooLow, from, payBidTx.Nonce(), nonce)
+	}
+
+	if cost := payBidTx.Cost(); balance.Cmp(cost) < 0 {
+		return fmt.Errorf("payBidTx %s: %w: address %v have %v want %v", payBidTx.Hash().TerminalString(),
+			core.ErrInsufficientFunds, from, balance, cost)
+	}
+
+	return nil
+}
+
+// preCheckBidTxs checks the chain ID, the senders, the nonce ordering of each sender
+// within the txs and the intrinsic gas of the txs to be included on top of the parent.
+func preCheckBidTxs(config *params.ChainConfig, parent *types.Header, txs []*types.Transaction, signer types.Signer) error {
+	var (
+		number     = new(big.Int).Add(parent.Number, common.Big1)
+		isIstanbul = config.IsIstanbul(number)
+		isShanghai = config.IsShanghai(number, uint64(time.Now().Unix()))
+		nonces     = make(map[common.Address]uint64)
+	)
+
+	for i, tx := range txs {
+		if tx.Protected() && tx.ChainId().Cmp(config.ChainID) != 0 {
+			return fmt.Errorf("tx %s: invalid chain id %v", tx.Hash().TerminalString(), tx.ChainId())
+		}
+
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return fmt.Errorf("tx %s: invalid sender, %v", tx.Hash().TerminalString(), err)
+		}
+
+		if last, ok := nonces[from]; ok && tx.Nonce() != last+1 {
+			return &bidTxError{index: i, txHash: tx.Hash(), err: &nonceOrderError{sender: from, nonce: tx.Nonce(), expected: last + 1}}
+		}
+		nonces[from] = tx.Nonce()
+
+		intrGas, err := core.IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, true, isIstanbul, isShanghai)
+		if err != nil {
+			return fmt.Errorf("tx %s: %v", tx.Hash().TerminalString(), err)
+		}
+		if tx.Gas() < intrGas {
+			return fmt.Errorf("tx %s: %w: gas %v, minimum needed %v", tx.Hash().TerminalString(), core.ErrIntrinsicGas, tx.Gas(), intrGas)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,249 @@
+package miner
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+func TestPreCheckBidTxs(t *testing.T) {
+	var (
+		config = params.TestChainConfig
+		signer = types.LatestSigner(config)
+		parent = &types.Header{Number: big.NewInt(1)}
+		key, _ = crypto.GenerateKey()
+		to     = common.HexToAddress("0x2")
+	)
+
+	newTx := func(chainID *big.Int, nonce, gas uint64) *types.Transaction {
+		tx, err := types.SignTx(types.NewTx(&types.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     nonce,
+			Gas:       gas,
+			GasFeeCap: big.NewInt(params.GWei),
+			To:        &to,
+		}), types.LatestSignerForChainID(chainID), key)
+		if err != nil {
+			t.Fatalf("failed to sign tx: %v", err)
+		}
+		return tx
+	}
+
+	if err := preCheckBidTxs(config, parent, []*types.Transaction{newTx(config.ChainID, 0, 21000), newTx(config.ChainID, 1, 21000)}, signer); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := preCheckBidTxs(config, parent, []*types.Transaction{newTx(big.NewInt(2), 0, 21000)}, signer); err == nil {
+		t.Fatalf("expected error with invalid chain id")
+	}
+
+	err := preCheckBidTxs(config, parent, []*types.Transaction{newTx(config.ChainID, 0, 21000), newTx(config.ChainID, 2, 21000)}, signer)
+	var nonceErr *nonceOrderError
+	if !errors.As(err, &nonceErr) {
+		t.Fatalf("expected nonce order error, got %v", err)
+	}
+	if nonceErr.sender != crypto.PubkeyToAddress(key.PublicKey) || nonceErr.nonce != 2 || nonceErr.expected != 1 {
+		t.Fatalf("unexpected nonce order error: %v", nonceErr)
+	}
+	issue := newBidIssue(common.Address{}, newTestBid(t, 1, 21000), err)
+	if issue.Code != types.ErrCodeNonceOrder || issue.TxIndex == nil || *issue.TxIndex != 1 {
+		t.Fatalf("unexpected issue of the misordered tx: %+v", issue)
+	}
+
+	if err := preCheckBidTxs(config, parent, []*types.Transaction{newTx(config.ChainID, 0, 20000)}, signer); !errors.Is(err, core.ErrIntrinsicGas) {
+		t.Fatalf("expected intrinsic gas error, got %v", err)
+	}
+}
+
+func TestPreCheckPayBidTx(t *testing.T) {
+	var (
+		config = params.TestChainConfig
+		signer = types.LatestSigner(config)
+		key, _ = crypto.GenerateKey()
+		from   = crypto.PubkeyToAddress(key.PublicKey)
+		to     = common.HexToAddress("0x2")
+	)
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetNonce(from, 5)
+	// enough for a transfer of 1 ether with the max gas cost of 21000 * 1 gwei
+	statedb.SetBalance(from, uint256.MustFromBig(new(big.Int).Add(big.NewInt(params.Ether), big.NewInt(21000*params.GWei))))
+
+	newTx := func(nonce uint64, value int64) *types.Transaction {
+		tx, err := types.SignTx(types.NewTx(&types.DynamicFeeTx{
+			ChainID:   config.ChainID,
+			Nonce:     nonce,
+			Gas:       21000,
+			GasFeeCap: big.NewInt(params.GWei),
+			To:        &to,
+			Value:     new(big.Int).Mul(big.NewInt(value), big.NewInt(params.Ether)),
+		}), signer, key)
+		if err != nil {
+			t.Fatalf("failed to sign tx: %v", err)
+		}
+		return tx
+	}
+
+	if err := preCheckPayBidTx(statedb, []*types.Transaction{newTx(5, 1)}, signer); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the nonce is taken by the earlier tx of the sender
+	if err := preCheckPayBidTx(statedb, []*types.Transaction{newTx(5, 0), newTx(5, 1)}, signer); !errors.Is(err, core.ErrNonceTooLow) {
+		t.Fatalf("expected nonce too low, got %v", err)
+	}
+
+	// a higher nonce is left to the simulation
+	if err := preCheckPayBidTx(statedb, []*types.Transaction{newTx(7, 1)}, signer); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := preCheckPayBidTx(statedb, []*types.Transaction{newTx(5, 2)}, signer); !errors.Is(err, core.ErrInsufficientFunds) {
+		t.Fatalf("expected insufficient funds, got %v", err)
+	}
+
+	// the earlier tx of the sender spends the funds
+	if err := preCheckPayBidTx(statedb, []*types.Transaction{newTx(5, 1), newTx(6, 1)}, signer); !errors.Is(err, core.ErrInsufficientFunds) {
+		t.Fatalf("expected insufficient funds, got %v", err)
+	}
+
+	statedb.SetCode(to, []byte{0x00})
+	if err := preCheckPayBidTx(statedb, []*types.Transaction{newTx(5, 1)}, signer); !errors.Is(err, errInvalidPayBidTx) {
+		t.Fatalf("expected invalid payBidTx paying a contract, got %v", err)
+	}
+}
+
+func TestCheckPayBidTx(t *testing.T) {
+	var (
+		to    = common.HexToAddress("0x2")
+		bidTx = types.NewTransaction(0, to, big.NewInt(0), 50000, big.NewInt(params.GWei), []byte{0x01})
+		newTx = func(to *common.Address, gas uint64, data []byte) *types.Transaction {
+			return types.NewTx(&types.LegacyTx{To: to, Gas: gas, GasPrice: big.NewInt(params.GWei), Value: big.NewInt(1), Data: data})
+		}
+	)
+
+	if err := checkPayBidTx([]*types.Transaction{bidTx, newTx(&to, 21000, nil)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, txs := range map[string][]*types.Transaction{
+		"empty":             nil,
+		"payBidTx only":     {newTx(&to, 21000, nil)},
+		"contract call":     {bidTx, newTx(&to, 21000, []byte{0x01})},
+		"contract create":   {bidTx, newTx(nil, 21000, nil)},
+		"gas exceeds limit": {bidTx, newTx(&to, params.PayBidTxGasLimit+1, nil)},
+	} {
+		if err := checkPayBidTx(txs); !errors.Is(err, errInvalidPayBidTx) {
+			t.Fatalf("%s: expected invalid payBidTx, got %v", name, err)
+		}
+	}
+}
+
+func TestPreCheckBidParent(t *testing.T) {
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, &core.Genesis{Config: params.TestChainConfig},
+		nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	var (
+		b      = &bidSimulator{chain: chain, chainConfig: params.TestChainConfig}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	errorCode := func(err error) int {
+		var codeErr interface{ ErrorCode() int }
+		if !errors.As(err, &codeErr) {
+			t.Fatalf("unexpected error without code: %v", err)
+		}
+		return codeErr.ErrorCode()
+	}
+
+	bid := newTestBid(t, 2, 21000)
+	bid.ParentHash = chain.Genesis().Hash()
+	if code := errorCode(b.preCheckBid(bid, signer, false)); code != types.BlockNumberError {
+		t.Fatalf("unexpected error code %d, want %d", code, types.BlockNumberError)
+	}
+
+	bid.ParentHash = common.Hash{0xff}
+	if code := errorCode(b.preCheckBid(bid, signer, false)); code != types.UnknownParentError {
+		t.Fatalf("unexpected error code %d, want %d", code, types.UnknownParentError)
+	}
+
+	if !b.bidBetterBefore(bid.ParentHash).IsZero() {
+		t.Fatalf("expected the zero time for the unknown parent")
+	}
+}
+
+func TestCheckBidCutoff(t *testing.T) {
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, &core.Genesis{Config: params.TestChainConfig},
+		nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	b := &bidSimulator{
+		chain:       chain,
+		chainConfig: params.ParliaTestChainConfig,
+		config:      &MevConfig{BidCutoffGrace: 20 * time.Millisecond},
+	}
+
+	// the genesis is long gone, so is the cutoff of its children
+	err = b.checkBidCutoff(chain.Genesis().Hash())
+	var codeErr interface{ ErrorCode() int }
+	if !errors.As(err, &codeErr) || codeErr.ErrorCode() != types.BidTooLateError {
+		t.Fatalf("unexpected error %v, want the code %d", err, types.BidTooLateError)
+	}
+
+	// the grace covers whatever the lateness is
+	b.config.BidCutoffGrace = time.Duration(math.MaxInt64)
+	if err := b.checkBidCutoff(chain.Genesis().Hash()); err != nil {
+		t.Fatalf("unexpected error within the grace: %v", err)
+	}
+}
+
+func TestPreCheckBidBlobs(t *testing.T) {
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, &core.Genesis{Config: params.TestChainConfig},
+		nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	var (
+		b        = &bidSimulator{chain: chain, chainConfig: params.TestChainConfig}
+		to       = common.HexToAddress("0x2")
+		maxBlobs = params.MaxBlobGasPerBlock / params.BlobTxBlobGasPerBlob
+		payBidTx = types.NewTx(&types.LegacyTx{To: &to, Gas: params.TxGas})
+	)
+	newBlobTx := func(blobs int) *types.Transaction {
+		return types.NewTx(&types.BlobTx{To: to, BlobHashes: make([]common.Hash, blobs)})
+	}
+
+	bid := newTestBid(t, 1, 21000)
+	bid.ParentHash = chain.Genesis().Hash()
+	bid.Txs = types.Transactions{newBlobTx(maxBlobs - 1), newBlobTx(2), payBidTx}
+	if blobs := countBlobs(bid.Txs); blobs != maxBlobs+1 {
+		t.Fatalf("unexpected blobs %d, want %d", blobs, maxBlobs+1)
+	}
+
+	err = b.preCheckBid(bid, types.LatestSigner(params.TestChainConfig), false)
+	var codeErr interface{ ErrorCode() int }
+	if !errors.As(err, &codeErr) || codeErr.ErrorCode() != types.TooManyBlobsError {
+		t.Fatalf("unexpected error %v, want the code %d", err, types.TooManyBlobsError)
+	}
+}
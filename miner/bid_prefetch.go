@@ -0,0 +1,72 @@
+package miner
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// bidPrefetchCounter counts the accounts loaded ahead of the simulations.
+var bidPrefetchCounter = metrics.NewRegisteredCounter("bid/prefetch/account", nil)
+
+// bidTouchedAccounts returns the accounts the txs of the bid touch at least, the senders and
+// the recipients, without duplicates.
+func bidTouchedAccounts(signer types.Signer, txs types.Transactions) []common.Address {
+	var (
+		seen     = make(map[common.Address]struct{}, 2*len(txs))
+		accounts = make([]common.Address, 0, 2*len(txs))
+	)
+	add := func(addr common.Address) {
+		if _, ok := seen[addr]; ok {
+			return
+		}
+		seen[addr] = struct{}{}
+		accounts = append(accounts, addr)
+	}
+
+	for _, tx := range txs {
+		// the invalid signatures fail in the commit loop, nothing to prefetch for them
+		if from, err := types.Sender(signer, tx); err == nil {
+			add(from)
+		}
+		if to := tx.To(); to != nil {
+			add(*to)
+		}
+	}
+
+	return accounts
+}
+
+// prefetchBidState loads the accounts and the code on a copy of the state in the background,
+// so the trie nodes missing in the caches of a cold parent are read ahead of the commit loop
+// instead of by the first txs. The returned func stops the prefetching, it must be called
+// once the simulation ends.
+func prefetchBidState(statedb *state.StateDB, accounts []common.Address) func() {
+	var (
+		stopCh = make(chan struct{})
+		doneCh = make(chan struct{})
+		warm   = statedb.CopyDoPrefetch()
+	)
+
+	go func() {
+		defer close(doneCh)
+
+		for _, addr := range accounts {
+			select {
+			case <-stopCh:
+				return
+			default:
+			}
+
+			warm.GetBalance(addr)
+			warm.GetCode(addr)
+			bidPrefetchCounter.Inc(1)
+		}
+	}()
+
+	return func() {
+		close(stopCh)
+		<-doneCh
+	}
+}
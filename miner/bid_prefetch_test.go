@@ -0,0 +1,32 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestBidTouchedAccounts(t *testing.T) {
+	var (
+		signer = types.LatestSigner(params.TestChainConfig)
+		key, _ = crypto.GenerateKey()
+		from   = crypto.PubkeyToAddress(key.PublicKey)
+		to     = common.HexToAddress("0x2")
+	)
+
+	newTx := func(nonce uint64, to *common.Address) *types.Transaction {
+		tx, err := types.SignNewTx(key, signer, &types.LegacyTx{Nonce: nonce, Gas: 21000, To: to})
+		if err != nil {
+			t.Fatalf("failed to sign tx: %v", err)
+		}
+		return tx
+	}
+
+	accounts := bidTouchedAccounts(signer, types.Transactions{newTx(0, &to), newTx(1, &to), newTx(2, nil)})
+	if len(accounts) != 2 || accounts[0] != from || accounts[1] != to {
+		t.Fatalf("unexpected accounts: %v", accounts)
+	}
+}
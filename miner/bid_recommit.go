@@ -0,0 +1,58 @@
+package miner
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var bidRecommitHitCounter = metrics.NewRegisteredCounter("bid/recommit/hit", nil)
+
+// simResultKey identifies the inputs of a simulation, the bid txs and the state it executes on.
+type simResultKey struct {
+	bidHash    common.Hash
+	parentHash common.Hash
+	stateRoot  common.Hash
+}
+
+func newSimResultKey(bid *types.Bid, parent *types.Header) simResultKey {
+	return simResultKey{bidHash: bid.Hash(), parentHash: bid.ParentHash, stateRoot: parent.Root}
+}
+
+// cacheSimResult caches the bid became the best, so that it isn't simulated again on recommit.
+// Nothing is cached if GreedyMergeTx is enabled, the result depends on the mempool then.
+func (b *bidSimulator) cacheSimResult(bidRuntime *BidRuntime, parent *types.Header) {
+	if b.config.GreedyMergeTx || parent == nil {
+		return
+	}
+
+	b.simResultsMu.Lock()
+	b.simResults[newSimResultKey(bidRuntime.bid, parent)] = bidRuntime
+	b.simResultsMu.Unlock()
+}
+
+// cachedSimResult returns the cached result of the bid if it is still the best bid of its
+// parent, nil if the bid needs a simulation.
+func (b *bidSimulator) cachedSimResult(bid *types.Bid, parent *types.Header) *BidRuntime {
+	if b.config.GreedyMergeTx || parent == nil {
+		return nil
+	}
+
+	b.simResultsMu.Lock()
+	cached := b.simResults[newSimResultKey(bid, parent)]
+	b.simResultsMu.Unlock()
+
+	// the environment of a dethroned bid is discarded
+	if cached == nil || cached != b.GetBestBid(bid.ParentHash) {
+		return nil
+	}
+
+	return cached
+}
+
+// resetSimResults drops the cached results on the chain head change.
+func (b *bidSimulator) resetSimResults() {
+	b.simResultsMu.Lock()
+	clear(b.simResults)
+	b.simResultsMu.Unlock()
+}
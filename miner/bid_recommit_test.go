@@ -0,0 +1,54 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestSimResultCache(t *testing.T) {
+	var (
+		b      = newTestBidSimulator(t)
+		parent = &types.Header{Number: big.NewInt(0), Root: common.Hash{0xaa}}
+
+		bid   = newBidRuntime(newTestBid(t, 1, 21000))
+		other = newBidRuntime(newTestBid(t, 1, 42000))
+	)
+	bid.env, other.env = &environment{}, &environment{}
+
+	b.SetBestBid(bid.bid.ParentHash, bid)
+	b.cacheSimResult(bid, parent)
+
+	if cached := b.cachedSimResult(bid.bid, parent); cached != bid {
+		t.Fatalf("expected the cached result of the best bid")
+	}
+
+	// the key covers the state root of the parent
+	if cached := b.cachedSimResult(bid.bid, &types.Header{Number: big.NewInt(0), Root: common.Hash{0xbb}}); cached != nil {
+		t.Fatalf("unexpected cache hit with another state root")
+	}
+
+	// the result depends on the mempool with greedy merge
+	config := DefaultMevConfig
+	config.GreedyMergeTx = true
+	b.config = &config
+	if cached := b.cachedSimResult(bid.bid, parent); cached != nil {
+		t.Fatalf("unexpected cache hit with greedy merge")
+	}
+	b.config = &DefaultMevConfig
+
+	// the dethroned bid needs a simulation
+	b.SetBestBid(bid.bid.ParentHash, other)
+	if cached := b.cachedSimResult(bid.bid, parent); cached != nil {
+		t.Fatalf("unexpected cache hit of the dethroned bid")
+	}
+
+	// the cache is dropped on the chain head change
+	b.cacheSimResult(other, parent)
+	b.resetSimResults()
+	if cached := b.cachedSimResult(other.bid, parent); cached != nil {
+		t.Fatalf("unexpected cache hit after the head change")
+	}
+}
@@ -0,0 +1,119 @@
+package miner
+
+import (
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	bidReorgCounter = metrics.NewRegisteredCounter("bid/reorg", nil)
+
+	// bidReorgInvalidatedCounter counts the bids pruned as their parents are no longer canonical
+	bidReorgInvalidatedCounter = metrics.NewRegisteredCounter("bid/reorg/invalidated", nil)
+
+	// bidReorgRebasedCounter counts the pruned bids resubmitted on another parent they listed
+	bidReorgRebasedCounter = metrics.NewRegisteredCounter("bid/reorg/rebased", nil)
+)
+
+// resolveParent returns the parent the bid proceeds with, the head if the bid lists it among
+// its acceptable parents, otherwise the parent hash of the bid.
+func (b *bidSimulator) resolveParent(rawBid *types.RawBid) common.Hash {
+	if len(rawBid.ParentHashes) == 0 {
+		return rawBid.ParentHash
+	}
+
+	if head := b.chain.CurrentHeader(); head != nil && rawBid.HasParent(head.Hash()) {
+		return head.Hash()
+	}
+	return rawBid.ParentHash
+}
+
+// isCanonicalParent reports whether the parent of the bid is on the canonical chain, the bids
+// beyond the next block of the head are not judged, their parents may be imported later.
+func (b *bidSimulator) isCanonicalParent(bid *types.Bid, headNumber uint64) bool {
+	if bid.BlockNumber == 0 || bid.BlockNumber > headNumber+1 {
+		return true
+	}
+
+	return b.chain.GetCanonicalHash(bid.BlockNumber-1) == bid.ParentHash
+}
+
+// pruneNonCanonicalBids discards the best, backup and simulating bids whose parents are no
+// longer canonical, so a shallow reorg back can't seal from their stale states. The pruned
+// bids listing the canonical parent are resubmitted on it, without counting against the quota
+// again. It returns the number of the bids pruned.
+func (b *bidSimulator) pruneNonCanonicalBids(headNumber uint64) int {
+	var (
+		pruned  int
+		rebased = make(map[common.Hash]*types.Bid)
+	)
+	prune := func(bids map[common.Hash]*BidRuntime) {
+		for parentHash, bid := range bids {
+			if b.isCanonicalParent(bid.bid, headNumber) {
+				continue
+			}
+			bid.discardEnv()
+			delete(bids, parentHash)
+			pruned++
+
+			if len(bid.bid.Parents()) < 2 {
+				continue
+			}
+			canonical := b.chain.GetCanonicalHash(bid.bid.BlockNumber - 1)
+			if slices.Contains(bid.bid.Parents(), canonical) {
+				rebased[bid.bid.Hash()] = bid.bid.WithParent(canonical)
+			}
+		}
+	}
+
+	b.bestBidMu.Lock()
+	prune(b.bestBid)
+	prune(b.backupBid)
+	b.bestBidMu.Unlock()
+
+	b.simBidMu.Lock()
+	prune(b.simulatingBid)
+	b.simBidMu.Unlock()
+
+	bidReorgInvalidatedCounter.Inc(int64(pruned))
+
+	for _, bid := range rebased {
+		select {
+		case b.newBidCh <- newBidPackage{bid: bid}:
+			bidReorgRebasedCounter.Inc(1)
+			log.Debug("BidSimulator: rebase bid on canonical parent", "builder", bid.Builder,
+				"bidHash", bid.Hash().Hex(), "parentHash", bid.ParentHash.TerminalString())
+		default:
+		}
+	}
+
+	return pruned
+}
+
+// handleReorg logs the reorg if the previous head is no longer canonical, and recommits the
+// best bid of the new head. The txs of the orphaned blocks return to the mempool, so the best
+// bid may merge more of them.
+func (b *bidSimulator) handleReorg(prev *types.Header, head *types.Block, pruned int) {
+	if b.chain.GetCanonicalHash(prev.Number.Uint64()) == prev.Hash() {
+		return
+	}
+	bidReorgCounter.Inc(1)
+
+	log.Warn("BidSimulator: chain reorg, bids of the orphaned parents pruned", "oldHead", prev.Hash().TerminalString(),
+		"newHead", head.Hash().TerminalString(), "number", head.NumberU64(), "pruned", pruned)
+
+	bestBid := b.GetBestBid(head.Hash())
+	if bestBid == nil {
+		return
+	}
+
+	select {
+	case b.newBidCh <- newBidPackage{bid: bestBid.bid}:
+		log.Debug("BidSimulator: recommit best bid on reorg", "builder", bestBid.bid.Builder, "bidHash", bestBid.bid.Hash().Hex())
+	default:
+	}
+}
@@ -0,0 +1,114 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestPruneNonCanonicalBids(t *testing.T) {
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, &core.Genesis{Config: params.TestChainConfig},
+		nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	var (
+		b        = newTestBidSimulator(t)
+		genesis  = chain.Genesis().Hash()
+		orphaned = common.Hash{0x01}
+		future   = common.Hash{0x02}
+		newBid   = func(blockNumber uint64, parentHash common.Hash) *BidRuntime {
+			bidRuntime := newBidRuntime(newTestBid(t, blockNumber, 21000))
+			bidRuntime.bid.ParentHash = parentHash
+			bidRuntime.env = &environment{}
+			return bidRuntime
+		}
+	)
+	b.chain = chain
+	b.bestBid[genesis] = newBid(1, genesis)
+	b.bestBid[orphaned] = newBid(1, orphaned)
+	b.backupBid[orphaned] = newBid(1, orphaned)
+	b.simulatingBid[orphaned] = newBid(1, orphaned)
+	b.bestBid[future] = newBid(2, future) // its parent may be imported later
+
+	if n := b.pruneNonCanonicalBids(0); n != 3 {
+		t.Fatalf("unexpected pruned bids %d, want 3", n)
+	}
+	if b.bestBid[orphaned] != nil || b.backupBid[orphaned] != nil || b.simulatingBid[orphaned] != nil {
+		t.Fatal("bids of the orphaned parent are kept")
+	}
+	if b.bestBid[genesis] == nil || b.bestBid[future] == nil {
+		t.Fatal("bids of the canonical or future parents are pruned")
+	}
+}
+
+func TestPruneRebasesListedParent(t *testing.T) {
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, &core.Genesis{Config: params.TestChainConfig},
+		nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	var (
+		genesis  = chain.Genesis().Hash()
+		orphaned = common.Hash{0x01}
+		b        = &bidSimulator{
+			chain:         chain,
+			newBidCh:      make(chan newBidPackage, 1),
+			bestBid:       make(map[common.Hash]*BidRuntime),
+			backupBid:     make(map[common.Hash]*BidRuntime),
+			simulatingBid: make(map[common.Hash]*BidRuntime),
+		}
+	)
+
+	// the bid listing both parents proceeds with the orphaned one first
+	args := &types.BidArgs{
+		RawBid: &types.RawBid{
+			BlockNumber:  1,
+			ParentHash:   orphaned,
+			ParentHashes: []common.Hash{orphaned, genesis},
+			GasUsed:      21000,
+			GasFee:       big.NewInt(1),
+		},
+	}
+	bid, err := args.ToBid(testBuilder, types.LatestSignerForChainID(big.NewInt(1)))
+	if err != nil {
+		t.Fatalf("failed to create bid: %v", err)
+	}
+	if parent := b.resolveParent(args.RawBid); parent != genesis {
+		t.Fatalf("unexpected resolved parent %v, want the head %v", parent, genesis)
+	}
+
+	bidRuntime := newBidRuntime(bid)
+	bidRuntime.env = &environment{}
+	b.bestBid[orphaned] = bidRuntime
+	b.simulatingBid[orphaned] = bidRuntime
+
+	if n := b.pruneNonCanonicalBids(0); n != 2 {
+		t.Fatalf("unexpected pruned bids %d, want 2", n)
+	}
+	select {
+	case rebased := <-b.newBidCh:
+		if rebased.bid.ParentHash != genesis || rebased.bid.Hash() != bid.Hash() {
+			t.Fatalf("unexpected rebased bid on %v", rebased.bid.ParentHash)
+		}
+		if rebased.feedback != nil {
+			t.Fatal("rebased bid expects feedback")
+		}
+	default:
+		t.Fatal("bid listing the canonical parent is not rebased")
+	}
+	if len(b.newBidCh) != 0 {
+		t.Fatal("bid is rebased more than once")
+	}
+}
@@ -0,0 +1,122 @@
+package miner
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// tinySelfImprovementBps is the margin in basis points of the best reward below which a builder
+// replacing its own best bid is not logged one by one, but counted per slot.
+const tinySelfImprovementBps = 10
+
+// selfImprovements counts the tiny self improvements of the builders per slot.
+type selfImprovements struct {
+	mu     sync.Mutex
+	counts map[common.Hash]*selfImprovementCount // parentHash -> count
+}
+
+type selfImprovementCount struct {
+	blockNumber uint64
+	count       int
+}
+
+func newSelfImprovements() *selfImprovements {
+	return &selfImprovements{counts: make(map[common.Hash]*selfImprovementCount)}
+}
+
+func (s *selfImprovements) inc(parentHash common.Hash, blockNumber uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.counts[parentHash]
+	if !ok {
+		c = &selfImprovementCount{blockNumber: blockNumber}
+		s.counts[parentHash] = c
+	}
+	c.count++
+}
+
+// flush removes the counts of the slots up to the block number, and returns them by block.
+func (s *selfImprovements) flush(blockNumber uint64) map[uint64]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	flushed := make(map[uint64]int)
+	for parentHash, c := range s.counts {
+		if c.blockNumber <= blockNumber {
+			flushed[c.blockNumber] += c.count
+			delete(s.counts, parentHash)
+		}
+	}
+
+	return flushed
+}
+
+// isTinySelfImprovement reports whether the bid wins the best bid of the same builder by a
+// margin too small to be logged one by one.
+func isTinySelfImprovement(bid, best *BidRuntime, delta, bestReward *big.Int) bool {
+	if bid.bid.Builder != best.bid.Builder || bestReward.Sign() <= 0 {
+		return false
+	}
+
+	margin := new(big.Int).Mul(bestReward, big.NewInt(tinySelfImprovementBps))
+	margin.Div(margin, big.NewInt(10000))
+
+	return delta.Cmp(margin) < 0
+}
+
+// logBidResult logs the result of the bid compared with the best bid in a single line, with
+// what changes if the bid wins, or the margin it loses by.
+func (b *bidSimulator) logBidResult(bidRuntime, bestBid *BidRuntime, win bool, bidReward, bestReward *big.Int, elapsed time.Duration) {
+	var (
+		delta  = new(big.Int).Sub(bidReward, bestReward)
+		logCtx = []interface{}{
+			"win", win,
+			"builder", bidRuntime.bid.Builder,
+			"bestBuilder", bestBid.bid.Builder,
+
+			"bidHash", bidRuntime.bid.Hash().TerminalString(),
+			"bestHash", bestBid.bid.Hash().TerminalString(),
+
+			"bidCtb", weiToEtherStringF6(bidReward),
+			"bestCtb", weiToEtherStringF6(bestReward),
+		}
+	)
+
+	if win {
+		logCtx = append(logCtx,
+			"ctbDelta", weiToEtherStringF6(delta),
+			"txDelta", bidRuntime.env.tcount-bestBid.env.tcount,
+			"builderChanged", bidRuntime.bid.Builder != bestBid.bid.Builder,
+		)
+	} else {
+		logCtx = append(logCtx, "margin", weiToEtherStringF6(new(big.Int).Neg(delta)))
+	}
+
+	logCtx = append(logCtx,
+		"bidBlockTx", bidRuntime.env.tcount,
+		"bestBlockTx", bestBid.env.tcount,
+
+		"simElapsed", elapsed,
+	)
+
+	if win && isTinySelfImprovement(bidRuntime, bestBid, delta, bestReward) {
+		b.selfImproves.inc(bidRuntime.bid.ParentHash, bidRuntime.bid.BlockNumber)
+		log.Debug("[BID RESULT]", logCtx...)
+		return
+	}
+
+	log.Info("[BID RESULT]", logCtx...)
+}
+
+// logSelfImprovements logs the counts of the tiny self improvements of the slots up to the
+// block number.
+func (b *bidSimulator) logSelfImprovements(blockNumber uint64) {
+	for number, count := range b.selfImproves.flush(blockNumber) {
+		log.Info("BidSimulator: tiny self improvements of builders", "block", number, "count", count)
+	}
+}
@@ -0,0 +1,53 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestIsTinySelfImprovement(t *testing.T) {
+	var (
+		best       = newBidRuntime(newTestBid(t, 1, 21000))
+		self       = newBidRuntime(newTestBid(t, 1, 21000))
+		other      = newBidRuntime(newTestBid(t, 1, 21000))
+		bestReward = big.NewInt(1_000_000)
+	)
+	other.bid.Builder = common.HexToAddress("0x2")
+
+	for _, c := range []struct {
+		bid   *BidRuntime
+		delta int64
+		want  bool
+	}{
+		{self, 999, true},     // below 10 bps of the best reward
+		{self, 1000, false},   // at the margin
+		{other, 1, false},     // another builder is always logged
+		{self, 100000, false}, // a large self improvement is logged
+	} {
+		if got := isTinySelfImprovement(c.bid, best, big.NewInt(c.delta), bestReward); got != c.want {
+			t.Errorf("builder %v delta %d: got %v, want %v", c.bid.bid.Builder, c.delta, got, c.want)
+		}
+	}
+}
+
+func TestSelfImprovementsFlush(t *testing.T) {
+	s := newSelfImprovements()
+	s.inc(common.Hash{0x01}, 1)
+	s.inc(common.Hash{0x01}, 1)
+	s.inc(common.Hash{0x02}, 1) // a sibling parent of the same block
+	s.inc(common.Hash{0x03}, 2)
+
+	flushed := s.flush(1)
+	if len(flushed) != 1 || flushed[1] != 3 {
+		t.Fatalf("unexpected flushed counts %v, want 3 of block 1", flushed)
+	}
+
+	if flushed = s.flush(2); len(flushed) != 1 || flushed[2] != 1 {
+		t.Fatalf("unexpected flushed counts %v, want 1 of block 2", flushed)
+	}
+	if flushed = s.flush(2); len(flushed) != 0 {
+		t.Fatalf("counts are flushed twice: %v", flushed)
+	}
+}
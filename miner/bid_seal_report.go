@@ -0,0 +1,173 @@
+package miner
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// sealReportChanSize is the size of the channel buffering the bid results to report
+	sealReportChanSize = 256
+
+	// defaultBidResultReportRate is the default maximum bid results reported per second
+	defaultBidResultReportRate = 20
+)
+
+// bidSealReportDropCounter counts the bid results dropped, it grows if the reports are saturated
+var bidSealReportDropCounter = metrics.NewRegisteredCounter("bid/seal/report/drop", nil)
+
+// sealBidders records the builders with accepted bids per block, so the result of the sealed
+// block can be reported to the builders which lost as well.
+type sealBidders struct {
+	mu     sync.Mutex
+	blocks map[uint64]map[common.Address]struct{} // blockNumber -> builders
+}
+
+func newSealBidders() *sealBidders {
+	return &sealBidders{blocks: make(map[uint64]map[common.Address]struct{})}
+}
+
+func (s *sealBidders) add(blockNumber uint64, builder common.Address) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	builders, ok := s.blocks[blockNumber]
+	if !ok {
+		builders = make(map[common.Address]struct{})
+		s.blocks[blockNumber] = builders
+	}
+	builders[builder] = struct{}{}
+}
+
+// take removes the builders of the blocks up to the number, and returns the ones of the block.
+func (s *sealBidders) take(number uint64) []common.Address {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var taken []common.Address
+	for n, builders := range s.blocks {
+		if n > number {
+			continue
+		}
+		if n == number {
+			for builder := range builders {
+				taken = append(taken, builder)
+			}
+		}
+		delete(s.blocks, n)
+	}
+
+	return taken
+}
+
+// recordBidder records the builder of the accepted bid if the losers are reported.
+func (b *bidSimulator) recordBidder(bid *types.Bid) {
+	if !b.config.ReportBidResults || !b.config.ReportBidResultsToLosers {
+		return
+	}
+
+	b.sealBidders.add(bid.BlockNumber, bid.Builder)
+}
+
+// reportSealed queues the result of the sealed block for the builder of the sealed bid, and
+// for the builders which lost if configured, bid is nil if the block is built locally.
+// The sealed bid is not matched from the chain head: the worker seals from the best bid (or
+// the promoted backup) of the parent of the block, bestBid[block.ParentHash()], and hands the
+// very bid to OnBlockSealed once the block is written, so the win with the block hash and
+// number is only reported for a block which is actually sealed from the bid.
+func (b *bidSimulator) reportSealed(block *types.Block, bid *BidRuntime, reward *big.Int) {
+	losers := b.sealBidders.take(block.NumberU64())
+
+	if !b.config.ReportBidResults {
+		return
+	}
+
+	validator := b.bidWorker.etherbase()
+	newResult := func(builder common.Address) *types.BidSealedResult {
+		return &types.BidSealedResult{
+			Validator:       validator,
+			Builder:         builder,
+			BlockNumber:     block.NumberU64(),
+			BlockHash:       block.Hash(),
+			ValidatorReward: reward,
+		}
+	}
+
+	if bid != nil {
+		result := newResult(bid.bid.Builder)
+		result.Win = true
+		result.BidHash = bid.bid.Hash()
+		b.queueSealReport(result)
+	}
+
+	if !b.config.ReportBidResultsToLosers {
+		return
+	}
+
+	for _, builder := range losers {
+		if bid != nil && builder == bid.bid.Builder {
+			continue
+		}
+		b.queueSealReport(newResult(builder))
+	}
+}
+
+// queueSealReport never blocks, the result is dropped if the reports fall behind.
+func (b *bidSimulator) queueSealReport(result *types.BidSealedResult) {
+	select {
+	case b.sealReportCh <- result:
+	default:
+		bidSealReportDropCounter.Inc(1)
+	}
+}
+
+// sealReportLoop sends the queued bid results at the limited rate.
+func (b *bidSimulator) sealReportLoop() {
+	if !b.config.ReportBidResults {
+		return
+	}
+
+	reportRate := b.config.BidResultReportRate
+	if reportRate <= 0 {
+		reportRate = defaultBidResultReportRate
+	}
+	limiter := rate.NewLimiter(rate.Limit(reportRate), 1)
+
+	for {
+		select {
+		case result := <-b.sealReportCh:
+			select {
+			case <-time.After(limiter.Reserve().Delay()):
+			case <-b.exitCh:
+				return
+			}
+
+			b.sendBidResult(result)
+		case <-b.exitCh:
+			return
+		}
+	}
+}
+
+// sendBidResult reports the bid result to the builder, it is skipped if the builder is removed
+// since. The builders not supporting the report reply an error, so it is only logged in debug.
+func (b *bidSimulator) sendBidResult(result *types.BidSealedResult) {
+	cli, _ := b.GetBuilder(result.Builder)
+	if cli == nil {
+		return
+	}
+
+	if err := cli.ReportBidResult(context.Background(), result); err != nil {
+		log.Debug("BidSimulator: failed to report bid result", "builder", result.Builder,
+			"block", result.BlockNumber, "err", err)
+	}
+}
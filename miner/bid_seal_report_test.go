@@ -0,0 +1,72 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+)
+
+func TestSealBiddersTake(t *testing.T) {
+	var (
+		s     = newSealBidders()
+		alice = common.HexToAddress("0x1")
+		bob   = common.HexToAddress("0x2")
+	)
+	s.add(1, alice)
+	s.add(2, alice)
+	s.add(2, alice) // a builder sending several bids is reported once
+	s.add(2, bob)
+	s.add(3, bob)
+
+	if taken := s.take(2); len(taken) != 2 {
+		t.Fatalf("unexpected builders %v of block 2, want 2", taken)
+	}
+
+	// the builders of the earlier blocks are pruned, the later ones are kept
+	if taken := s.take(1); len(taken) != 0 {
+		t.Fatalf("builders of block 1 are not pruned: %v", taken)
+	}
+	if taken := s.take(3); len(taken) != 1 || taken[0] != bob {
+		t.Fatalf("unexpected builders %v of block 3, want %v", taken, bob)
+	}
+}
+
+func TestOnBlockSealedReport(t *testing.T) {
+	var (
+		b      = newTestBidSimulator(t)
+		config = DefaultMevConfig
+		loser  = common.HexToAddress("0x2")
+		block  = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), ParentHash: common.Hash{0x01}})
+		winner = newBidRuntime(newTestBid(t, 1, 21000))
+	)
+	config.ReportBidResults, config.ReportBidResultsToLosers = true, true
+	b.config = &config
+	b.bidWorker = &testMergeWorker{}
+	winner.packedBlockRewardPreBEP95Final = uint256.NewInt(100)
+
+	b.sealBidders.add(1, testBuilder)
+	b.sealBidders.add(1, loser)
+	b.OnBlockSealed(block, winner, big.NewInt(0))
+
+	results := make(map[common.Address]*types.BidSealedResult)
+	for len(b.sealReportCh) > 0 {
+		result := <-b.sealReportCh
+		results[result.Builder] = result
+	}
+	if len(results) != 2 {
+		t.Fatalf("unexpected reports %v, want the winner and the loser", results)
+	}
+
+	won := results[testBuilder]
+	if won == nil || !won.Win || won.BidHash != winner.bid.Hash() || won.BlockHash != block.Hash() || won.BlockNumber != 1 ||
+		won.ValidatorReward.Cmp(winner.totalReward()) != 0 {
+		t.Fatalf("unexpected report of the winner %+v", won)
+	}
+	lost := results[loser]
+	if lost == nil || lost.Win || lost.BidHash != (common.Hash{}) || lost.BlockHash != block.Hash() || lost.BlockNumber != 1 {
+		t.Fatalf("unexpected report of the loser %+v", lost)
+	}
+}
@@ -0,0 +1,47 @@
+package miner
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// bidShadowBestCounter counts the bids which would have become the best bid out of shadow mode
+var bidShadowBestCounter = metrics.NewRegisteredCounter("bid/shadow/best", nil)
+
+// shadowBid is the bid which would be the best bid of its parent out of shadow mode, only its
+// value is kept, the env is discarded.
+type shadowBid struct {
+	bid   *types.Bid
+	value *big.Int // the realized value of the bid
+}
+
+// recordShadowBest records the simulated bid as the would-be best bid of its parent if it beats
+// the previous one by the same margin as a best bid must, and reports whether it does.
+func (b *bidSimulator) recordShadowBest(bidRuntime *BidRuntime) bool {
+	var (
+		parentHash = bidRuntime.bid.ParentHash
+		value      = b.valuator.RealizedValue(bidRuntime, false)
+	)
+
+	b.bestBidMu.Lock()
+	last := b.shadowBest[parentHash]
+	won := last == nil || beatsBestReward(b.config, value, last.value)
+	if won {
+		b.shadowBest[parentHash] = &shadowBid{bid: bidRuntime.bid, value: value}
+	}
+	b.bestBidMu.Unlock()
+
+	if !won {
+		return false
+	}
+
+	bidShadowBestCounter.Inc(1)
+	log.Info("BidSimulator: shadow best bid, not sealed", "block", bidRuntime.bid.BlockNumber,
+		"builder", bidRuntime.bid.Builder, "bidHash", bidRuntime.bid.Hash().TerminalString(),
+		"totalReward", weiToEtherStringF6(bidRuntime.totalReward()), "value", weiToEtherStringF6(value))
+
+	return true
+}
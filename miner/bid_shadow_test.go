@@ -0,0 +1,77 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// shadowTestEngine is the ethash faker leaving the time to simulate the bids.
+type shadowTestEngine struct {
+	consensus.Engine
+}
+
+func (shadowTestEngine) Delay(consensus.ChainReader, *types.Header, *time.Duration) *time.Duration {
+	delay := time.Second
+	return &delay
+}
+
+func TestShadowModeSkipsBestBid(t *testing.T) {
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  types.GenesisAlloc{testBankAddress: {Balance: testBankFunds}},
+	}
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	config := DefaultMevConfig
+	config.ShadowMode = true
+	config.SimulateOutOfTurn = true
+
+	b := newTestBidSimulator(t)
+	b.config = &config
+	b.chain = chain
+	b.chainConfig = chain.Config()
+	b.engine = shadowTestEngine{ethash.NewFaker()}
+	b.bidWorker = &chainTestWorker{chain: chain}
+	b.stats = make(map[common.Address]*builderStats)
+	b.bidReceiving.Store(true)
+
+	var (
+		genesis = chain.CurrentBlock()
+		signer  = types.LatestSigner(chain.Config())
+		price   = big.NewInt(10 * params.InitialBaseFee)
+	)
+	bid := newTestBid(t, genesis.Number.Uint64()+1, 2*params.TxGas)
+	bid.ParentHash = genesis.Hash()
+	bid.BuilderFee = big.NewInt(0)
+	bid.Txs = types.Transactions{
+		types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: 0, To: &testUserAddress, Value: big.NewInt(1), Gas: params.TxGas, GasPrice: price}),
+		types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: 1, To: &testBuilder, Gas: params.TxGas, GasPrice: price}),
+	}
+
+	b.simBid(nil, newBidRuntime(bid))
+
+	// the bid is simulated to the end, but it never becomes the best bid to seal or publish
+	if shadow := b.shadowBest[bid.ParentHash]; shadow == nil || shadow.bid.Hash() != bid.Hash() || shadow.value.Sign() <= 0 {
+		t.Fatalf("the would-be best bid is not recorded: %+v", shadow)
+	}
+	if best := b.GetBestBid(bid.ParentHash); best != nil {
+		t.Fatalf("unexpected best bid in shadow mode %s", best.bid.Hash())
+	}
+	if info := b.BestBidInfo(bid.ParentHash); info != nil {
+		t.Fatalf("unexpected best bid info in shadow mode %+v", info)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"math/big"
 	"net"
 	"net/http"
+	"slices"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -15,54 +16,216 @@ import (
 
 	mapset "github.com/deckarep/golang-set/v2"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/bidutil"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/leveldb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/miner/builderclient"
 	"github.com/ethereum/go-ethereum/params"
-	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
 )
 
 const (
 	// maxBidPerBuilderPerBlock is the max bid number per builder
 	maxBidPerBuilderPerBlock = 3
+
+	// bidResultChanSize is the size of the channel buffering the bid results for the subscribers
+	bidResultChanSize = 128
+
+	// greedyMergeAbandonGrace is the time for the greedy merge to stop after its budget,
+	// it is considered stuck in a transaction if it doesn't
+	greedyMergeAbandonGrace = 20 * time.Millisecond
+)
+
+var (
+	errBidSimulationTimeout = errors.New("simulation abort due to timeout")
+	errBetterBidArrived     = errors.New("simulation abort due to better bid arrived")
+	errNewHeadArrived       = errors.New("simulation abort due to new head arrived")
+	errSimMinerExit         = errors.New("miner exit")
+	errSimCanceled          = errors.New("simulation abort due to request canceled")
+
+	// errBidNotVerified tells the paranoid verification of the bid did not finish in time, the bid
+	// is sealed unverified then
+	errBidNotVerified = errors.New("bid not verified in time")
 )
 
+// bidSimError is a simulation error with the code of the issue reported to the builder.
+type bidSimError struct {
+	code types.BidIssueCode
+	err  error
+}
+
+func newBidSimError(code types.BidIssueCode, err error) error {
+	return &bidSimError{code: code, err: err}
+}
+
+func (e *bidSimError) Error() string { return e.err.Error() }
+func (e *bidSimError) Unwrap() error { return e.err }
+
+// bidTxError is the failure of a tx of the bid, index is the position of the tx in the bid.
+type bidTxError struct {
+	index  int
+	txHash common.Hash
+	err    error
+}
+
+func (e *bidTxError) Error() string {
+	return fmt.Sprintf("invalid tx in bid, tx %d %s, %v", e.index, e.txHash.TerminalString(), e.err)
+}
+
+func (e *bidTxError) Unwrap() error { return e.err }
+
+// txRevertedError is the failure of an unRevertible tx which reverted.
+type txRevertedError struct {
+	reason string // the revert reason, or the EVM error if the tx failed otherwise
+}
+
+func newTxRevertedError(result *core.ExecutionResult) *txRevertedError {
+	revert := result.Revert()
+	if reason, err := abi.UnpackRevert(revert); err == nil {
+		return &txRevertedError{reason: reason}
+	}
+	if len(revert) > 0 {
+		return &txRevertedError{reason: hexutil.Encode(revert)}
+	}
+	return &txRevertedError{reason: result.Err.Error()}
+}
+
+func (e *txRevertedError) Error() string {
+	return "no revertible transaction failed, " + e.reason
+}
+
+// bidIssueCode classifies the simulation error, empty if it is not classified.
+func bidIssueCode(err error) types.BidIssueCode {
+	var (
+		simErr   *bidSimError
+		nonceErr *nonceOrderError
+	)
+	switch {
+	case errors.As(err, &simErr):
+		return simErr.code
+	case errors.As(err, &nonceErr):
+		return types.ErrCodeNonceOrder
+	case errors.Is(err, errBidSimulationTimeout):
+		return types.ErrCodeTimeout
+	case errors.Is(err, errBetterBidArrived), errors.Is(err, errNewHeadArrived), errors.Is(err, errSimMinerExit), errors.Is(err, errSimCanceled):
+		return types.ErrCodeAborted
+	default:
+		return ""
+	}
+}
+
 var (
 	bidSimTimer = metrics.NewRegisteredTimer("bid/sim/duration", nil)
+
+	// the simulations cut short, by the reason
+	bidSimInterruptBetterBidCounter = metrics.NewRegisteredCounter("bid/sim/interrupt/betterbid", nil)
+	bidSimInterruptNewHeadCounter   = metrics.NewRegisteredCounter("bid/sim/interrupt/newhead", nil)
+	bidSimInterruptShutdownCounter  = metrics.NewRegisteredCounter("bid/sim/interrupt/shutdown", nil)
+	bidSimInterruptTimeoutCounter   = metrics.NewRegisteredCounter("bid/sim/interrupt/timeout", nil)
+	bidSimInterruptCanceledCounter  = metrics.NewRegisteredCounter("bid/sim/interrupt/canceled", nil)
+
+	bidVerifySkipCounter = metrics.NewRegisteredCounter("bid/verify/skip", nil)
+	bidVerifyFailCounter = metrics.NewRegisteredCounter("bid/verify/fail", nil)
+
+	bidResultDropCounter = metrics.NewRegisteredCounter("bid/result/drop", nil)
+
+	bidDedupCounter = metrics.NewRegisteredCounter("bid/dedup", nil)
+
+	bidGreedyMergeAbandonCounter = metrics.NewRegisteredCounter("bid/greedymerge/abandon", nil)
+
+	bidOptionalSkipCounter = metrics.NewRegisteredCounter("bid/optional/skip", nil)
+
+	// bidSenderRecoverCounter counts the bid txs whose senders are recovered at the simulation,
+	// it stays flat if the senders recovered at the intake are reused
+	bidSenderRecoverCounter = metrics.NewRegisteredCounter("bid/sim/sender/recover", nil)
+
+	// bidInterruptSuppressCounter counts the better bids deferred instead of interrupting the simulation
+	bidInterruptSuppressCounter = metrics.NewRegisteredCounter("bid/interrupt/suppress", nil)
+	// bidDeferredDropCounter counts the deferred bids accepted but dropped without being simulated
+	bidDeferredDropCounter = metrics.NewRegisteredCounter("bid/interrupt/deferred/drop", nil)
+
+	bidQueueDepthGauge    = metrics.NewRegisteredGauge("bid/queue/depth", nil)
+	bidQueueRejectCounter = metrics.NewRegisteredCounter("bid/queue/reject", nil)
+	// the queued bids rejected for passing the cutoff of the simulations
+	bidLateRejectCounter = metrics.NewRegisteredCounter("bid/late/reject", nil)
+
+	// bidBribeSenderCounter counts the bids sending txs from a bribe EOA, it should stay 0
+	bidBribeSenderCounter = metrics.NewRegisteredCounter("bid/bribe/sender", nil)
+
+	// the sealed blocks built from a bid or locally
+	mevBlockFromBidCounter = metrics.NewRegisteredCounter("mev/block/fromBid", nil)
+	mevBlockLocalCounter   = metrics.NewRegisteredCounter("mev/block/local", nil)
 )
 
 var (
 	diffInTurn = big.NewInt(2) // the difficulty of a block that proposed by an in-turn validator
+)
+
+const (
+	// the defaults of the http client to the sentry and builders
+	defaultDialTimeout     = time.Second
+	defaultRequestTimeout  = 5 * time.Second
+	defaultMaxConnsPerHost = 50
+)
+
+// newHTTPClient creates the http client to the sentry and builders, the unset options
+// fall back to the defaults. It fails if the TLS files are misconfigured.
+func newHTTPClient(config *MevConfig) (*http.Client, error) {
+	var (
+		dialTimeout     = defaultDialTimeout
+		requestTimeout  = defaultRequestTimeout
+		maxConnsPerHost = defaultMaxConnsPerHost
+	)
+	if config.DialTimeout > 0 {
+		dialTimeout = config.DialTimeout
+	}
+	if config.RequestTimeout > 0 {
+		requestTimeout = config.RequestTimeout
+	}
+	if config.MaxConnsPerHost > 0 {
+		maxConnsPerHost = config.MaxConnsPerHost
+	}
+
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
 
-	dialer = &net.Dialer{
-		Timeout:   time.Second,
+	dialer := &net.Dialer{
+		Timeout:   dialTimeout,
 		KeepAlive: 60 * time.Second,
 	}
 
-	transport = &http.Transport{
+	transport := &http.Transport{
 		DialContext:         dialer.DialContext,
-		MaxIdleConnsPerHost: 50,
-		MaxConnsPerHost:     50,
+		MaxIdleConnsPerHost: maxConnsPerHost,
+		MaxConnsPerHost:     maxConnsPerHost,
 		IdleConnTimeout:     90 * time.Second,
+		TLSClientConfig:     tlsConfig,
 	}
 
-	client = &http.Client{
-		Timeout:   5 * time.Second,
+	return &http.Client{
+		Timeout:   requestTimeout,
 		Transport: transport,
-	}
-)
+	}, nil
+}
 
 type bidWorker interface {
 	prepareWork(params *generateParams) (*environment, error)
 	etherbase() common.Address
-	fillTransactions(interruptCh chan int32, env *environment, stopTimer *time.Timer, bidTxs mapset.Set[common.Hash]) (err error)
+	etherbaseForBlock(number uint64) common.Address
+	fillTransactions(interruptCh chan int32, env *environment, stopTimer *time.Timer, bidTxs mapset.Set[common.Hash], maxTxs int) (err error)
 }
 
 // simBidReq is the request for simulating a bid
@@ -75,6 +238,8 @@ type simBidReq struct {
 type newBidPackage struct {
 	bid      *types.Bid
 	feedback chan error
+	fastPath bool      // the bid arrived late and is accepted by the fast path
+	timing   bidTiming // zero for the recommitted bids
 }
 
 // bidSimulator is in charge of receiving bid from builders, reporting issue to builders.
@@ -83,6 +248,7 @@ type bidSimulator struct {
 	config        *MevConfig
 	delayLeftOver time.Duration
 	minGasPrice   *big.Int
+	valuator      BidValuator // values the bids to select the best one, see MevConfig.BidValuation
 	chain         *core.BlockChain
 	txpool        *txpool.TxPool
 	chainConfig   *params.ChainConfig
@@ -91,30 +257,89 @@ type bidSimulator struct {
 
 	running atomic.Bool // controlled by miner
 	exitCh  chan struct{}
+	simWg   sync.WaitGroup // mainLoop and the in-flight simulations, waited on close
+
+	dryRunSem chan struct{} // bounds the concurrent dry runs, see DryRunBid
 
 	bidReceiving atomic.Bool // controlled by config and eth.AdminAPI
 
-	chainHeadCh  chan core.ChainHeadEvent
-	chainHeadSub event.Subscription
+	chainHeadCh        chan core.ChainHeadEvent
+	chainHeadSub       event.Subscription                                  // owned by mainLoop once started, see resubscribeChainHead
+	subscribeChainHead func(chan<- core.ChainHeadEvent) event.Subscription // the subscription of the chain, replaced in the tests
 
-	sentryCli *builderclient.Client
+	httpClient *http.Client          // shared by the clients of the sentry and builders
+	sentryCli  *builderclient.Client // guarded by buildersMu
+	sentryURL  string                // the url of sentryCli, guarded by buildersMu
 
-	// builder info (warning: only keep status in memory!)
+	healthMu      sync.Mutex
+	builderHealth map[common.Address]*endpointHealth
+	sentryHealth  *endpointHealth // nil if the sentry is not checked yet
+
+	// builder info, the builders added at runtime are persisted along with the builder stats
 	buildersMu sync.RWMutex
 	builders   map[common.Address]*builderclient.Client
+	urls       map[common.Address]string    // builder -> configured url, empty if the builder has no endpoint
+	mutedUntil map[common.Address]time.Time // builder -> the end of the mute by the operator, see MuteBuilder
 
 	// channels
 	simBidCh chan *simBidReq
 	newBidCh chan newBidPackage
 
-	pendingMu sync.RWMutex
-	pending   map[uint64]map[common.Address]map[common.Hash]struct{} // blockNumber -> builder -> bidHash -> struct{}
+	pendingMu    sync.RWMutex
+	pending      map[uint64]map[common.Address]map[common.Hash]struct{} // blockNumber -> builder -> bidHash -> struct{}
+	simReply     map[uint64]map[common.Hash]error                       // blockNumber -> bidHash -> reply, for the simulated pending bids
+	bidStatuses  map[uint64]map[common.Hash]*types.BidStatus            // blockNumber -> bidHash -> terminal status, for the pending bids
+	pendingStats map[uint64]map[common.Address]*types.PendingBidStats   // blockNumber -> builder -> timing of the pending bids
 
-	bestBidMu sync.RWMutex
-	bestBid   map[common.Hash]*BidRuntime // prevBlockHash -> bidRuntime
+	bestBidMu  sync.RWMutex
+	bestBid    map[common.Hash]*BidRuntime // prevBlockHash -> bidRuntime
+	backupBid  map[common.Hash]*BidRuntime // prevBlockHash -> the runner-up of the best bid, see BackupBidMaxSize
+	shadowBest map[common.Hash]*shadowBid  // prevBlockHash -> the would-be best bid, see ShadowMode
 
 	simBidMu      sync.RWMutex
 	simulatingBid map[common.Hash]*BidRuntime // prevBlockHash -> bidRuntime, in the process of simulation
+
+	deferredMu   sync.Mutex
+	deferredBids map[common.Hash]*deferredBid // prevBlockHash -> the bid waiting for the simulation, see BidInterruptMinIncreaseBps
+
+	futureBids futureBidQueue // the bids built on top of the block being sealed, see FutureBidsPerBuilder
+
+	statsMu    sync.RWMutex
+	stats      map[common.Address]*builderStats
+	statsDirty map[common.Address]struct{} // builders whose stats are changed since last flush
+	statsDB    ethdb.KeyValueStore         // nil if the builder stats are only kept in memory
+	statsLoad  sync.Once
+
+	decisions    *bidDecisions
+	obligations  *bidObligations   // the accepted bids waiting for their outcomes, see BidOutcomeSLA
+	selfImproves *selfImprovements // the tiny self improvements counted instead of logged, see logBidResult
+	issues       *issueQueue       // the issue reports waiting to be sent by issueLoop
+	sealBidders  *sealBidders      // the builders to report the result of the sealed block to, see ReportBidResultsToLosers
+	sealReportCh chan *types.BidSealedResult
+
+	simResultsMu sync.Mutex
+	simResults   map[simResultKey]*BidRuntime // the simulated best bids of the current head
+
+	fastPathMu sync.Mutex
+
+	warnedBribeSenders sync.Map // the bribe EOAs warned once as senders of bid txs, see bribeEOAs
+
+	beaconMu     sync.RWMutex
+	beaconSigner *beaconSigner // nil until the validator key is authorized, see beaconLoop
+
+	// bid results are sent to the feed by bidResultLoop, so slow subscribers can't stall the simulation
+	bidResultCh   chan types.BidResult
+	bidResultFeed event.Feed
+
+	historyDB ethdb.KeyValueStore
+	history   *bidHistory // nil if the bid history is disabled
+
+	outcomes *bidOutcomeRing // nil if BidHistoryBlocks is 0
+
+	audit *bidAuditLog // nil if BidAuditLogPath is empty
+
+	sealLatencies *sealLatencies
+	leftOver      atomic.Int64 // the adapted BidSimulationLeftOver in nanoseconds, 0 until adapted
 }
 
 func newBidSimulator(
@@ -126,10 +351,13 @@ func newBidSimulator(
 	engine consensus.Engine,
 	bidWorker bidWorker,
 ) *bidSimulator {
+	config.ValidatorBribeEOAs = uniqueBribeEOAs(config.ValidatorBribeEOAs)
+
 	b := &bidSimulator{
 		config:        config,
 		delayLeftOver: delayLeftOver,
 		minGasPrice:   minGasPrice,
+		valuator:      newBidValuator(config, minGasPrice),
 		chain:         eth.BlockChain(),
 		txpool:        eth.TxPool(),
 		chainConfig:   chainConfig,
@@ -138,18 +366,78 @@ func newBidSimulator(
 		exitCh:        make(chan struct{}),
 		chainHeadCh:   make(chan core.ChainHeadEvent, chainHeadChanSize),
 		builders:      make(map[common.Address]*builderclient.Client),
+		urls:          make(map[common.Address]string),
+		mutedUntil:    make(map[common.Address]time.Time),
+		builderHealth: make(map[common.Address]*endpointHealth),
 		simBidCh:      make(chan *simBidReq),
 		newBidCh:      make(chan newBidPackage, 100),
 		pending:       make(map[uint64]map[common.Address]map[common.Hash]struct{}),
+		simReply:      make(map[uint64]map[common.Hash]error),
+		bidStatuses:   make(map[uint64]map[common.Hash]*types.BidStatus),
+		pendingStats:  make(map[uint64]map[common.Address]*types.PendingBidStats),
 		bestBid:       make(map[common.Hash]*BidRuntime),
+		shadowBest:    make(map[common.Hash]*shadowBid),
+		backupBid:     make(map[common.Hash]*BidRuntime),
+		simResults:    make(map[simResultKey]*BidRuntime),
 		simulatingBid: make(map[common.Hash]*BidRuntime),
+		deferredBids:  make(map[common.Hash]*deferredBid),
+		stats:         make(map[common.Address]*builderStats),
+		statsDirty:    make(map[common.Address]struct{}),
+		decisions:     newBidDecisions(config.BidDecisionRetainBlocks),
+		obligations:   newBidObligations(),
+		selfImproves:  newSelfImprovements(),
+		issues:        newIssueQueue(config.IssueReportRate),
+		sealBidders:   newSealBidders(),
+		sealReportCh:  make(chan *types.BidSealedResult, sealReportChanSize),
+		dryRunSem:     make(chan struct{}, maxConcurrentDryRuns),
+		bidResultCh:   make(chan types.BidResult, bidResultChanSize),
+		sealLatencies: newSealLatencies(leftOverWindowSize),
+	}
+
+	// the misconfigured TLS mustn't fall back to the connections without it
+	httpClient, err := newHTTPClient(config)
+	if err != nil {
+		log.Crit("BidSimulator: invalid TLS config of the sentry and builders", "err", err)
+	}
+	b.httpClient = httpClient
+
+	b.subscribeChainHead = b.chain.SubscribeChainHeadEvent
+	b.chainHeadSub = b.subscribeChainHead(b.chainHeadCh)
+
+	if config.BidHistoryBlocks > 0 {
+		b.outcomes = newBidOutcomeRing(config.BidHistoryBlocks)
+	}
+
+	if config.BidAuditLogPath != "" {
+		b.audit = newBidAuditLog(config.BidAuditLogPath, config.BidAuditLogMaxSize)
+		go b.audit.loop(b.exitCh)
+	}
+
+	if config.BidHistoryPath != "" {
+		db, err := openMevLevelDB(config.BidHistoryPath, "mev/history", bidHistorySchema)
+		if err != nil {
+			log.Error("BidSimulator: failed to open bid history", "path", config.BidHistoryPath, "err", err)
+		} else {
+			b.historyDB = db
+			b.history = newBidHistory(db)
+		}
 	}
 
-	b.chainHeadSub = b.chain.SubscribeChainHeadEvent(b.chainHeadCh)
+	if config.BuilderStatsPath != "" {
+		db, err := openMevLevelDB(config.BuilderStatsPath, "mev/builders", builderStoreSchema)
+		if err != nil {
+			log.Error("BidSimulator: failed to open builder stats", "path", config.BuilderStatsPath, "err", err)
+		} else {
+			b.statsDB = db
+			go b.persistLoop()
+		}
+	}
 
 	if config.Enabled {
 		b.bidReceiving.Store(true)
-		b.dialSentryAndBuilders()
+		if err := b.dialSentryAndBuilders(); err != nil {
+			log.Crit("BidSimulator: failed to dial sentry and builders", "err", err)
+		}
 
 		if len(b.builders) == 0 {
 			log.Warn("BidSimulator: no valid builders")
@@ -157,28 +445,73 @@ func newBidSimulator(
 	}
 
 	go b.clearLoop()
+	b.simWg.Add(1)
 	go b.mainLoop()
 	go b.newBidLoop()
+	go b.bidResultLoop()
+	go b.healthCheckLoop()
+	go b.slaLoop()
+	go b.issueLoop()
+	go b.sealReportLoop()
+	go b.beaconLoop()
 
 	return b
 }
 
-func (b *bidSimulator) dialSentryAndBuilders() {
-	var sentryCli *builderclient.Client
-	var err error
+// openMevLevelDB opens the leveldb store and migrates it to the current schema version.
+func openMevLevelDB(path string, namespace string, schema *mevSchema) (ethdb.KeyValueStore, error) {
+	db, err := leveldb.New(path, 16, 16, namespace, false)
+	if err != nil {
+		return nil, err
+	}
 
-	if b.config.SentryURL != "" {
-		sentryCli, err = builderclient.DialOptions(context.Background(), b.config.SentryURL, rpc.WithHTTPClient(client))
-		if err != nil {
-			log.Error("BidSimulator: failed to dial sentry", "url", b.config.SentryURL, "err", err)
+	if err = openMevStore(db, schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// dialSentryAndBuilders dials the sentry and builders, the TLS files are checked again first as
+// they may be rotated since the start, it fails instead of dialing with a broken TLS config.
+func (b *bidSimulator) dialSentryAndBuilders() error {
+	var (
+		sentryCli *builderclient.Client
+		sentryURL string
+		err       error
+	)
+
+	if _, err = newTLSConfig(b.config); err != nil {
+		return fmt.Errorf("invalid TLS config: %w", err)
+	}
+
+	if err = validateAuth(b.config); err != nil {
+		return fmt.Errorf("invalid auth config: %w", err)
+	}
+
+	// the sentries are not pinged here, the health check fails over from the unreachable one
+	for _, url := range b.sentryURLs() {
+		if sentryCli, err = builderclient.DialOptions(context.Background(), url, b.dialOptions(&b.config.SentryAuth)...); err == nil {
+			sentryURL = url
+			break
 		}
+		log.Error("BidSimulator: failed to dial sentry", "url", url, "err", err)
 	}
 
-	b.sentryCli = sentryCli
+	b.buildersMu.Lock()
+	b.sentryCli, b.sentryURL = sentryCli, sentryURL
+	b.buildersMu.Unlock()
+
+	b.statsLoad.Do(b.loadBuilderStats)
 
 	for _, v := range b.config.Builders {
-		_ = b.AddBuilder(v.Address, v.URL)
+		_ = b.addBuilder(v.Address, v.URL)
 	}
+
+	b.loadBuilderRegistry()
+
+	return nil
 }
 
 func (b *bidSimulator) start() {
@@ -192,6 +525,51 @@ func (b *bidSimulator) stop() {
 func (b *bidSimulator) close() {
 	b.running.Store(false)
 	close(b.exitCh)
+
+	// the in-flight simulations discard their environments on exit
+	b.simWg.Wait()
+	b.discardBids()
+
+	if b.historyDB != nil {
+		b.historyDB.Close()
+	}
+}
+
+// discardBids discards the environments of the remaining bids, so none of them is still
+// reading the state while the blockchain is torn down.
+func (b *bidSimulator) discardBids() {
+	discard := func(bids map[common.Hash]*BidRuntime) {
+		for parentHash, bid := range bids {
+			bid.discardEnv()
+			delete(bids, parentHash)
+		}
+	}
+
+	b.bestBidMu.Lock()
+	discard(b.bestBid)
+	discard(b.backupBid)
+	b.bestBidMu.Unlock()
+
+	b.simBidMu.Lock()
+	discard(b.simulatingBid)
+	b.simBidMu.Unlock()
+}
+
+// drainNewBids replies to the bids still queued on exit, so their builders don't wait for the
+// RPC timeout.
+func (b *bidSimulator) drainNewBids() {
+	for {
+		select {
+		case newBid := <-b.newBidCh:
+			if newBid.feedback != nil {
+				b.RemovePending(newBid.bid.BlockNumber, newBid.bid.Builder, newBid.bid.Hash())
+				newBid.feedback <- types.ErrMevShuttingDown
+			}
+		default:
+			bidQueueDepthGauge.Update(0)
+			return
+		}
+	}
 }
 
 func (b *bidSimulator) isRunning() bool {
@@ -203,7 +581,10 @@ func (b *bidSimulator) receivingBid() bool {
 }
 
 func (b *bidSimulator) startReceivingBid() {
-	b.dialSentryAndBuilders()
+	if err := b.dialSentryAndBuilders(); err != nil {
+		log.Error("BidSimulator: failed to start receiving bids", "err", err)
+		return
+	}
 	b.bidReceiving.Store(true)
 }
 
@@ -211,19 +592,68 @@ func (b *bidSimulator) stopReceivingBid() {
 	b.bidReceiving.Store(false)
 }
 
+// drainReceivingBid stops receiving new bids like stopReceivingBid, but blocks until
+// the in-flight simulations finish, so that the best bid can still be sealed.
+// It returns an error if some simulations are still running when the timeout elapses.
+func (b *bidSimulator) drainReceivingBid(timeout time.Duration) error {
+	b.stopReceivingBid()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		b.simBidMu.RLock()
+		var simulating *BidRuntime
+		for _, bid := range b.simulatingBid {
+			simulating = bid
+			break
+		}
+		left := len(b.simulatingBid)
+		b.simBidMu.RUnlock()
+
+		if simulating == nil {
+			return nil
+		}
+
+		select {
+		case <-simulating.finished:
+		case <-timer.C:
+			return fmt.Errorf("drain timeout, %d bids still in simulation", left)
+		case <-b.exitCh:
+			return errors.New("bid simulator exited")
+		}
+	}
+}
+
+// AddBuilder adds the builder at runtime and persists it.
 func (b *bidSimulator) AddBuilder(builder common.Address, url string) error {
+	if err := b.addBuilder(builder, url); err != nil {
+		return err
+	}
+
+	b.persistBuilder(builder, url)
+
+	return nil
+}
+
+func (b *bidSimulator) addBuilder(builder common.Address, url string) error {
 	b.buildersMu.Lock()
 	defer b.buildersMu.Unlock()
 
+	viaSentry := len(b.sentryURLs()) > 0
+
 	if b.sentryCli != nil {
 		b.builders[builder] = b.sentryCli
+	} else if viaSentry {
+		// no sentry is dialed yet, the client is attached by the health check once one is
+		b.builders[builder] = nil
 	} else {
 		var builderCli *builderclient.Client
 
 		if url != "" {
 			var err error
 
-			builderCli, err = builderclient.DialOptions(context.Background(), url, rpc.WithHTTPClient(client))
+			builderCli, err = builderclient.DialOptions(context.Background(), url, b.dialOptions(b.builderAuth(builder))...)
 			if err != nil {
 				log.Error("BidSimulator: failed to dial builder", "url", url, "err", err)
 				return err
@@ -232,6 +662,10 @@ func (b *bidSimulator) AddBuilder(builder common.Address, url string) error {
 
 		b.builders[builder] = builderCli
 	}
+	b.urls[builder] = url
+
+	b.trackBuilderHealth(builder, url, viaSentry)
+	registerBuilderMetrics(builder)
 
 	return nil
 }
@@ -241,17 +675,54 @@ func (b *bidSimulator) RemoveBuilder(builder common.Address) error {
 	defer b.buildersMu.Unlock()
 
 	delete(b.builders, builder)
+	delete(b.urls, builder)
+	delete(b.mutedUntil, builder)
+	b.untrackBuilderHealth(builder)
+	unregisterBuilderMetrics(builder)
+	b.unpersistBuilder(builder)
 
 	return nil
 }
 
 func (b *bidSimulator) ExistBuilder(builder common.Address) bool {
+	_, ok := b.GetBuilder(builder)
+
+	return ok
+}
+
+// GetBuilder returns the client of the builder, the client is nil if the builder has no endpoint.
+func (b *bidSimulator) GetBuilder(builder common.Address) (*builderclient.Client, bool) {
 	b.buildersMu.RLock()
 	defer b.buildersMu.RUnlock()
 
-	_, ok := b.builders[builder]
+	cli, ok := b.builders[builder]
 
-	return ok
+	return cli, ok
+}
+
+// Builders returns the builders in the builder list with their endpoints, sorted by address.
+func (b *bidSimulator) Builders() []*types.BuilderInfo {
+	b.buildersMu.RLock()
+	defer b.buildersMu.RUnlock()
+
+	// the undialed builders are waiting for a sentry to be reachable if it is configured
+	sentryConfigured := len(b.sentryURLs()) > 0
+
+	builders := make([]*types.BuilderInfo, 0, len(b.builders))
+	for builder, cli := range b.builders {
+		builders = append(builders, &types.BuilderInfo{
+			Address:   builder,
+			ViaSentry: (cli != nil && cli == b.sentryCli) || (cli == nil && sentryConfigured),
+			Dialed:    cli != nil,
+			URL:       b.urls[builder],
+		})
+	}
+
+	slices.SortFunc(builders, func(a, b *types.BuilderInfo) int {
+		return a.Address.Cmp(b.Address)
+	})
+
+	return builders
 }
 
 func (b *bidSimulator) SetBestBid(prevBlockHash common.Hash, bid *BidRuntime) {
@@ -260,8 +731,8 @@ func (b *bidSimulator) SetBestBid(prevBlockHash common.Hash, bid *BidRuntime) {
 
 	// must discard the environment of the last best bid, otherwise it will cause memory leak
 	last := b.bestBid[prevBlockHash]
-	if last != nil && last.env != nil {
-		last.env.discard()
+	if last != nil && last != bid && !b.retainBackupBidLocked(prevBlockHash, last) {
+		last.discardEnv()
 	}
 
 	b.bestBid[prevBlockHash] = bid
@@ -274,6 +745,30 @@ func (b *bidSimulator) GetBestBid(prevBlockHash common.Hash) *BidRuntime {
 	return b.bestBid[prevBlockHash]
 }
 
+// BestBidInfo returns the summary of the best bid for the parent hash, nil if there is no best bid yet.
+func (b *bidSimulator) BestBidInfo(prevBlockHash common.Hash) *types.BestBidInfo {
+	b.bestBidMu.RLock()
+	defer b.bestBidMu.RUnlock()
+
+	bestBid := b.bestBid[prevBlockHash]
+	if bestBid == nil {
+		return nil
+	}
+
+	info := &types.BestBidInfo{
+		BlockNumber:    bestBid.bid.BlockNumber,
+		ExpectedReward: bestBid.expectedRewardFromBuilder(),
+		TxCount:        len(bestBid.bid.Txs),
+		FastPath:       bestBid.fastPath,
+	}
+	if !b.config.RedactBestBidBuilder {
+		builder := bestBid.bid.Builder
+		info.Builder = &builder
+	}
+
+	return info
+}
+
 func (b *bidSimulator) SetSimulatingBid(prevBlockHash common.Hash, bid *BidRuntime) {
 	b.simBidMu.Lock()
 	defer b.simBidMu.Unlock()
@@ -295,8 +790,20 @@ func (b *bidSimulator) RemoveSimulatingBid(prevBlockHash common.Hash) {
 	delete(b.simulatingBid, prevBlockHash)
 }
 
+// mainLoop simulates the bids of distinct parents concurrently, while the bids of the
+// same parent are simulated one after another, the next one starts once the interrupted
+// one exited.
 func (b *bidSimulator) mainLoop() {
-	defer b.chainHeadSub.Unsubscribe()
+	defer b.simWg.Done()
+	defer func() { b.chainHeadSub.Unsubscribe() }()
+
+	var (
+		// parentHash -> closed once the last simulation of the parent exited
+		inFlight = make(map[common.Hash]chan struct{})
+
+		headSub = newHeadSubState()
+		subErr  = b.chainHeadSub.Err()
+	)
 
 	for {
 		select {
@@ -305,143 +812,619 @@ func (b *bidSimulator) mainLoop() {
 				continue
 			}
 
-			b.simBid(req.interruptCh, req.bid)
+			for parentHash, done := range inFlight {
+				select {
+				case <-done:
+					delete(inFlight, parentHash)
+				default:
+				}
+			}
+
+			var (
+				parentHash = req.bid.bid.ParentHash
+				prev       = inFlight[parentHash]
+				done       = make(chan struct{})
+			)
+			inFlight[parentHash] = done
+
+			b.simWg.Add(1)
+			go func() {
+				defer b.simWg.Done()
+				defer close(done)
+
+				if prev != nil {
+					<-prev
+				}
+				b.simBid(req.interruptCh, req.bid)
+			}()
 
 		// System stopped
 		case <-b.exitCh:
 			return
 
-		case <-b.chainHeadSub.Err():
-			return
+		// the subscription is retried instead of stopping the simulations for good
+		case err, ok := <-subErr:
+			if !ok {
+				return
+			}
+			subErr = nil
+			b.chainHeadSubFailed(headSub, err)
+
+		case <-headSub.retry:
+			b.resubscribeChainHead(headSub)
+			subErr = b.chainHeadSub.Err()
 		}
 	}
 }
 
 func (b *bidSimulator) newBidLoop() {
-	var (
-		interruptCh chan int32
-	)
+	// parentHash -> the last simulation request of the parent
+	lastReqs := make(map[common.Hash]*simBidReq)
 
-	// commit aborts in-flight bid execution with given signal and resubmits a new one.
+	// commit aborts in-flight bid execution of the same parent with given signal and resubmits a new one.
 	commit := func(reason int32, bidRuntime *BidRuntime) {
-		if interruptCh != nil {
-			// each commit work will have its own interruptCh to stop work with a reason
-			interruptCh <- reason
-			close(interruptCh)
+		parentHash := bidRuntime.bid.ParentHash
+
+		for hash, req := range lastReqs {
+			// the bids of the same parent are replaced, and the bids of older blocks are obsolete,
+			// while the bids of other parents of the same block, e.g. on a reorg, go on
+			if hash == parentHash || req.bid.bid.BlockNumber < bidRuntime.bid.BlockNumber {
+				signal := reason
+				if hash != parentHash {
+					signal = commitInterruptNewHead
+				}
+				// each commit work will have its own interruptCh to stop work with a reason
+				req.interruptCh <- signal
+				close(req.interruptCh)
+				delete(lastReqs, hash)
+			}
 		}
-		interruptCh = make(chan int32, 1)
+
+		req := &simBidReq{interruptCh: make(chan int32, 1), bid: bidRuntime}
+		lastReqs[parentHash] = req
 		select {
-		case b.simBidCh <- &simBidReq{interruptCh: interruptCh, bid: bidRuntime}:
+		case b.simBidCh <- req:
 			log.Debug("BidSimulator: commit", "builder", bidRuntime.bid.Builder, "bidHash", bidRuntime.bid.Hash().Hex())
 		case <-b.exitCh:
 			return
 		}
 	}
 
-	for {
-		select {
-		case newBid := <-b.newBidCh:
-			if !b.isRunning() {
-				continue
+	// handle compares the bid with the simulating bid or the best bid of its parent, and commits
+	// it to the simulation if it is better.
+	handle := func(newBid newBidPackage) {
+		if !b.isRunning() {
+			// the miner is stopped since the bid was sent, its pending slot is released,
+			// so the builder can resend it once the miner is running again
+			if newBid.feedback != nil {
+				b.RemovePending(newBid.bid.BlockNumber, newBid.bid.Builder, newBid.bid.Hash())
+				newBid.feedback <- types.ErrMevNotRunning
 			}
+			return
+		}
 
-			var (
-				bidRuntime = newBidRuntime(newBid.bid)
-				replyErr   error
-			)
-			// simulatingBid will be nil if there is no bid in simulation, compare with the bestBid instead
-			if simulatingBid := b.GetSimulatingBid(newBid.bid.ParentHash); simulatingBid != nil {
-				// simulatingBid always better than bestBid, so only compare with simulatingBid if a simulatingBid exists
-				if bidRuntime.isExpectedBetterThanSimulatingBid(simulatingBid) {
-					commit(commitInterruptBetterBid, bidRuntime)
-				} else {
-					replyErr = fmt.Errorf("bid is discarded, current best is %s [after BEP95]", simulatingBid.expectedRewardFromBuilder())
+		// the head may have moved on since the bid was sent
+		if err := b.checkInTurn(newBid.bid.ParentHash); err != nil {
+			b.recordBidStatus(newBid.bid, types.BidStatusRejected, err)
+			if newBid.feedback != nil {
+				newBid.feedback <- err
+			}
+			return
+		}
+
+		// the bid queued past the cutoff can't finish the simulation in time, it mustn't
+		// interrupt a useful one, the fast path bids are late by design
+		if !newBid.fastPath {
+			if err := b.checkBidCutoff(newBid.bid.ParentHash); err != nil {
+				b.recordBidStatus(newBid.bid, types.BidStatusRejected, err)
+				if newBid.feedback != nil {
+					newBid.feedback <- err
 				}
-			} else {
-				// bestBid is nil means the bid is the first bid, otherwise the bid should compare with the bestBid
-				if bestBid := b.GetBestBid(newBid.bid.ParentHash); bestBid == nil ||
-					bidRuntime.isExpectedBetterThanBestBid(bestBid) {
+				return
+			}
+		}
+
+		var (
+			bidRuntime = newBidRuntime(newBid.bid)
+			replyErr   error
+		)
+		bidRuntime.penaltyBps = b.builderPenaltyBps(newBid.bid.Builder)
+		bidRuntime.fastPath = newBid.fastPath
+		bidRuntime.timing = newBid.timing
+
+		// simulatingBid will be nil if there is no bid in simulation, compare with the bestBid instead
+		comparison := &types.BidComparison{
+			Against:    againstNone,
+			Value:      bidRuntime.penalizedExpectedValue(b.valuator),
+			PenaltyBps: bidRuntime.penaltyBps,
+		}
+		if simulatingBid := b.GetSimulatingBid(newBid.bid.ParentHash); simulatingBid != nil {
+			comparison.Against, comparison.AgainstBid = againstSimulatingBid, bidHashRef(simulatingBid.bid)
+			comparison.AgainstValue = simulatingBid.penalizedExpectedValue(b.valuator)
+
+			// simulatingBid always better than bestBid, so only compare with simulatingBid if a simulatingBid exists
+			if bidRuntime.isExpectedBetterThanSimulatingBid(simulatingBid, b.valuator) {
+				if bidRuntime.interruptsSimulatingBid(simulatingBid, b.config, b.valuator) {
 					commit(commitInterruptBetterBid, bidRuntime)
 				} else {
-					replyErr = fmt.Errorf("bid is discarded, current best is %s [after BEP95]", bestBid.totalRewardFromBuilder())
+					// the tiny increase waits for the simulation, so two builders outbidding each
+					// other by a few wei can't keep interrupting it until the slot deadline
+					bidInterruptSuppressCounter.Inc(1)
+					if kept := b.deferBid(newBidPackage{bid: newBid.bid, timing: newBid.timing}, comparison.Value,
+						bidRuntime.expectedRewardFromBuilder()); kept != nil {
+						// superseded by the bid already waiting, it would never be simulated
+						replyErr = newBidDiscardedWorseError(kept.reward, newBid.timing.latency(time.Now()),
+							b.BidQuota(newBid.bid.BlockNumber, newBid.bid.Builder))
+					} else if b.GetSimulatingBid(newBid.bid.ParentHash) == nil {
+						b.resumeDeferredBid(newBid.bid.ParentHash)
+					}
 				}
+			} else {
+				replyErr = newBidDiscardedWorseError(simulatingBid.expectedRewardFromBuilder(), newBid.timing.latency(time.Now()),
+					b.BidQuota(newBid.bid.BlockNumber, newBid.bid.Builder))
 			}
-
-			if newBid.feedback != nil {
-				newBid.feedback <- replyErr
-
-				log.Info("[BID ARRIVED]",
-					"block", newBid.bid.BlockNumber,
-					"builder", newBid.bid.Builder,
-					"accepted", replyErr == nil,
-					"gasFee", weiToEtherStringF6(newBid.bid.GasFee),
-					"nontaxable", weiToEtherStringF6(newBid.bid.NontaxableFee),
-					"tx", len(newBid.bid.Txs),
-					"hash", newBid.bid.Hash().TerminalString(),
-				)
+		} else {
+			bestBid := b.GetBestBid(newBid.bid.ParentHash)
+			if bestBid != nil {
+				comparison.Against, comparison.AgainstBid = againstBestBid, bidHashRef(bestBid.bid)
+				comparison.AgainstValue = b.valuator.RealizedValue(bestBid, true)
 			}
 
-		case <-b.exitCh:
-			return
+			// bestBid is nil means the bid is the first bid, otherwise the bid should compare with the bestBid
+			if bestBid == nil || bidRuntime.isExpectedBetterThanBestBid(bestBid, b.config, b.valuator) {
+				commit(commitInterruptBetterBid, bidRuntime)
+			} else {
+				replyErr = newBidDiscardedWorseError(bestBid.totalRewardFromBuilder(), newBid.timing.latency(time.Now()),
+					b.BidQuota(newBid.bid.BlockNumber, newBid.bid.Builder))
+			}
+		}
+		comparison.Won = replyErr == nil
+		if replyErr != nil {
+			b.recordBidStatus(newBid.bid, types.BidStatusRejected, replyErr)
 		}
-	}
-}
 
-func (b *bidSimulator) bidBetterBefore(parentHash common.Hash) time.Time {
-	parentHeader := b.chain.GetHeaderByHash(parentHash)
-	return bidutil.BidBetterBefore(parentHeader, b.chainConfig.Parlia.Period, b.delayLeftOver, b.config.BidSimulationLeftOver)
-}
+		if newBid.feedback != nil {
+			b.decisions.preFilter(newBid.bid, comparison, newBid.timing.latency(time.Now()))
+			newBid.feedback <- replyErr
 
-func (b *bidSimulator) clearLoop() {
-	clearFn := func(parentHash common.Hash, blockNumber uint64) {
-		b.pendingMu.Lock()
-		delete(b.pending, blockNumber)
-		b.pendingMu.Unlock()
-
-		b.bestBidMu.Lock()
-		if bid, ok := b.bestBid[parentHash]; ok {
-			bid.env.discard()
-		}
-		delete(b.bestBid, parentHash)
-		for k, v := range b.bestBid {
-			if v.bid.BlockNumber <= blockNumber-b.chain.TriesInMemory() {
-				v.env.discard()
-				delete(b.bestBid, k)
+			if replyErr == nil {
+				b.recordAccepted(newBid.bid.Builder, newBid.bid.BlockNumber)
+				b.trackObligation(newBid.bid)
+				b.recordBidder(newBid.bid)
 			}
-		}
-		b.bestBidMu.Unlock()
 
-		b.simBidMu.Lock()
-		for k, v := range b.simulatingBid {
-			if v.bid.BlockNumber <= blockNumber-b.chain.TriesInMemory() {
-				v.env.discard()
-				delete(b.simulatingBid, k)
-			}
+			log.Info("[BID ARRIVED]",
+				"block", newBid.bid.BlockNumber,
+				"builder", newBid.bid.Builder,
+				"accepted", replyErr == nil,
+				"gasFee", weiToEtherStringF6(newBid.bid.GasFee),
+				"nontaxable", weiToEtherStringF6(newBid.bid.NontaxableFee),
+				"tx", len(newBid.bid.Txs),
+				"hash", newBid.bid.Hash().TerminalString(),
+			)
 		}
-		b.simBidMu.Unlock()
 	}
 
-	for head := range b.chainHeadCh {
-		if !b.isRunning() {
-			continue
-		}
+	for {
+		select {
+		case newBid := <-b.newBidCh:
+			bidQueueDepthGauge.Update(int64(len(b.newBidCh)))
 
-		clearFn(head.Block.ParentHash(), head.Block.NumberU64())
+			handle(b.debounceBids(newBid, handle))
+
+		case <-b.exitCh:
+			b.drainNewBids()
+			return
+		}
+	}
+}
+
+// deferredBid is a bid better than the simulating bid by too little to interrupt it.
+type deferredBid struct {
+	pkg    newBidPackage
+	value  *big.Int // the penalized expected value
+	reward *big.Int // the expected reward from the builder, replied to the bids it supersedes
+}
+
+// deferBid keeps the bid until the simulation of its parent finishes, only the most valuable
+// one of each parent is kept. It returns the deferred bid superseding the given one, or nil
+// if the given one is kept, the less valuable bid it replaces is dropped.
+func (b *bidSimulator) deferBid(pkg newBidPackage, value, reward *big.Int) *deferredBid {
+	var (
+		parentHash = pkg.bid.ParentHash
+		deferred   = &deferredBid{pkg: pkg, value: value, reward: reward}
+	)
+
+	b.deferredMu.Lock()
+	prev := b.deferredBids[parentHash]
+	if prev != nil && prev.value.Cmp(value) >= 0 {
+		b.deferredMu.Unlock()
+		return prev
+	}
+	b.deferredBids[parentHash] = deferred
+	b.deferredMu.Unlock()
+
+	if prev != nil {
+		b.dropDeferredBid(prev, newBidDiscardedWorseError(reward, prev.pkg.timing.latency(time.Now()), nil))
+		log.Debug("BidSimulator: deferred bid superseded", "builder", prev.pkg.bid.Builder,
+			"bidHash", prev.pkg.bid.Hash().Hex())
+	}
+	return nil
+}
+
+// dropDeferredBid records the deferred bid as rejected for the reason, it was replied as accepted
+// when it was deferred, but it is never simulated.
+func (b *bidSimulator) dropDeferredBid(deferred *deferredBid, reason error) {
+	bidDeferredDropCounter.Inc(1)
+	b.recordBidStatus(deferred.pkg.bid, types.BidStatusRejected, reason)
+}
+
+// resumeDeferredBid sends the deferred bid of the parent to newBidLoop again, it is evaluated
+// against the bid just simulated.
+func (b *bidSimulator) resumeDeferredBid(parentHash common.Hash) {
+	b.deferredMu.Lock()
+	deferred := b.deferredBids[parentHash]
+	delete(b.deferredBids, parentHash)
+	b.deferredMu.Unlock()
+
+	if deferred == nil {
+		return
+	}
+
+	select {
+	case b.newBidCh <- deferred.pkg:
+		log.Debug("BidSimulator: resume deferred bid", "builder", deferred.pkg.bid.Builder,
+			"bidHash", deferred.pkg.bid.Hash().Hex())
+	default:
+		b.dropDeferredBid(deferred, types.ErrMevBusy)
+		log.Warn("BidSimulator: drop deferred bid, bid channel is full", "builder", deferred.pkg.bid.Builder,
+			"bidHash", deferred.pkg.bid.Hash().Hex())
+	}
+}
+
+// greedyMerge fills the bid env with at most GreedyMergeMaxTxs transactions from mempool. If
+// GreedyMergeMaxDuration is set, a copy of the pre-merge env is kept, and the merge stuck over
+// the budget, e.g. on a slow disk path of the StateDB, is abandoned for the copy instead of being waited.
+func (b *bidSimulator) greedyMerge(interruptCh chan int32, bidRuntime *BidRuntime) {
+	var (
+		bidTxLen  = len(bidRuntime.bid.Txs)
+		bidTxsSet = mapset.NewThreadUnsafeSetWithSize[common.Hash](bidTxLen)
+		budget    = b.config.GreedyMergeMaxDuration
+		maxTxs    = b.config.GreedyMergeMaxTxs
+	)
+	for _, tx := range bidRuntime.bid.Txs {
+		bidTxsSet.Add(tx.Hash())
+	}
+
+	if budget <= 0 {
+		fillErr := b.bidWorker.fillTransactions(interruptCh, bidRuntime.env, nil, bidTxsSet, maxTxs)
+		log.Trace("BidSimulator: greedy merge stopped", "block", bidRuntime.env.header.Number,
+			"builder", bidRuntime.bid.Builder, "tx count", bidRuntime.env.tcount-bidTxLen+1, "err", fillErr)
+		return
+	}
+
+	var (
+		mergeEnv    = bidRuntime.env
+		preMergeEnv = mergeEnv.copy()
+		stopTimer   = time.NewTimer(budget) // stops the merge between transactions
+		done        = make(chan error, 1)
+	)
+
+	go func() {
+		done <- b.bidWorker.fillTransactions(interruptCh, mergeEnv, stopTimer, bidTxsSet, maxTxs)
+	}()
+
+	abandonTimer := time.NewTimer(budget + greedyMergeAbandonGrace)
+	defer abandonTimer.Stop()
+
+	select {
+	case fillErr := <-done:
+		stopTimer.Stop()
+		preMergeEnv.discard()
+		log.Trace("BidSimulator: greedy merge stopped", "block", mergeEnv.header.Number,
+			"builder", bidRuntime.bid.Builder, "tx count", mergeEnv.tcount-bidTxLen+1, "err", fillErr)
+
+	case <-abandonTimer.C:
+		bidGreedyMergeAbandonCounter.Inc(1)
+		log.Warn("BidSimulator: greedy merge abandoned", "block", mergeEnv.header.Number,
+			"builder", bidRuntime.bid.Builder, "budget", budget)
+
+		bidRuntime.env = preMergeEnv
+		go func() {
+			<-done
+			mergeEnv.discard()
+		}()
+	}
+}
+
+func bidHashRef(bid *types.Bid) *common.Hash {
+	hash := bid.Hash()
+	return &hash
+}
+
+func newBidDiscardedWorseError(currentBestReward *big.Int, latency *types.BidLatency, quota *types.BidQuota) error {
+	err := types.NewBidDiscardedWorseError(currentBestReward,
+		fmt.Sprintf("bid is discarded, current best is %s [after BEP95]", weiToEtherStringF6(currentBestReward)))
+	err.Latency = latency
+	err.Quota = quota
+
+	return err
+}
+
+// checkInTurn returns ErrMevNotInTurn if the validator is not the in-turn proposer of the
+// block on top of the parent, unless SimulateOutOfTurn is set.
+func (b *bidSimulator) checkInTurn(parentHash common.Hash) error {
+	if b.config.SimulateOutOfTurn {
+		return nil
+	}
+
+	parent := b.chain.GetHeaderByHash(parentHash)
+	if parent == nil {
+		return types.NewUnknownParentError(parentHash)
+	}
+
+	validator, err := b.engine.NextInTurnValidator(b.chain, parent)
+	if err != nil || validator == (common.Address{}) || validator != b.bidWorker.etherbase() {
+		return types.ErrMevNotInTurn
+	}
+
+	return nil
+}
+
+// checkBidCutoff rejects the bid of the parent if the cutoff of the simulations is passed by
+// more than BidCutoffGrace.
+func (b *bidSimulator) checkBidCutoff(parentHash common.Hash) error {
+	cutoff := b.bidBetterBefore(parentHash)
+	if late := time.Since(cutoff); late > b.config.BidCutoffGrace {
+		bidLateRejectCounter.Inc(1)
+		return types.NewBidTooLateError(cutoff, late)
+	}
+
+	return nil
+}
+
+// bidBetterBefore returns the time the bids of the parent should arrive before, it is the zero
+// time if the parent is unknown, so the bids are too late.
+func (b *bidSimulator) bidBetterBefore(parentHash common.Hash) time.Time {
+	parentHeader := b.chain.GetHeaderByHash(parentHash)
+	if parentHeader == nil {
+		return time.Time{}
+	}
+	return bidutil.BidBetterBefore(parentHeader, b.chainConfig.Parlia.Period, b.delayLeftOver, b.simulationLeftOver())
+}
+
+// gasFeeCeil returns the plausible maximum gas fee of a block built on top of the parent,
+// nil means there is no ceiling.
+func (b *bidSimulator) gasFeeCeil(parent *types.Header) *big.Int {
+	if b.config.BidGasPriceCeil == nil || parent == nil {
+		return nil
+	}
+
+	return new(big.Int).Mul(new(big.Int).SetUint64(parent.GasLimit), b.config.BidGasPriceCeil)
+}
+
+// checkFeeCeil rejects the bid whose declared fees exceed what a block could plausibly yield,
+// so that a bid can't win the simulation slot by declaring absurd fees. The rejections count
+// against the accuracy of the builder.
+func (b *bidSimulator) checkFeeCeil(builder common.Address, parentHash common.Hash, gasFee, nontaxableFee *big.Int) error {
+	var err error
+
+	if ceil := b.gasFeeCeil(b.chain.GetHeaderByHash(parentHash)); ceil != nil && gasFee != nil && gasFee.Cmp(ceil) > 0 {
+		err = types.NewFeeTooHighError("gas", gasFee, ceil)
+	} else if ceil = b.config.NontaxableFeeCeil; ceil != nil && nontaxableFee != nil && nontaxableFee.Cmp(ceil) > 0 {
+		err = types.NewFeeTooHighError("nontaxable", nontaxableFee, ceil)
+	}
+
+	if err != nil {
+		b.incBuilderCounter(builderCeilCounterPrefix, builder)
+		b.recordFeeCeilRejection(builder)
+		return err
+	}
+
+	return nil
+}
+
+// pruneHorizon returns the block number up to which the bids are pruned at the block, it is 0
+// in the first triesInMemory blocks, when no bid is old enough to be pruned.
+func pruneHorizon(blockNumber, triesInMemory uint64) uint64 {
+	if blockNumber <= triesInMemory {
+		return 0
+	}
+
+	return blockNumber - triesInMemory
+}
+
+// clearBids clears the bids of the parent of the new head at the block, and prunes the bids of
+// the blocks up to the horizon.
+func (b *bidSimulator) clearBids(parentHash common.Hash, blockNumber, horizon uint64) {
+	b.pendingMu.Lock()
+	delete(b.pending, blockNumber)
+	delete(b.simReply, blockNumber)
+	delete(b.bidStatuses, blockNumber)
+	delete(b.pendingStats, blockNumber)
+	b.pendingMu.Unlock()
+
+	b.bestBidMu.Lock()
+	if bid, ok := b.bestBid[parentHash]; ok {
+		bid.discardEnv()
+	}
+	delete(b.bestBid, parentHash)
+	for k, v := range b.bestBid {
+		if v.bid.BlockNumber <= horizon {
+			v.discardEnv()
+			delete(b.bestBid, k)
+		}
+	}
+	b.clearBackupBidsLocked(parentHash, horizon)
+	delete(b.shadowBest, parentHash)
+	for k, v := range b.shadowBest {
+		if v.bid.BlockNumber <= horizon {
+			delete(b.shadowBest, k)
+		}
+	}
+	b.bestBidMu.Unlock()
+
+	b.resetSimResults()
+	b.logSelfImprovements(blockNumber)
+
+	b.simBidMu.Lock()
+	for k, v := range b.simulatingBid {
+		if v.bid.BlockNumber <= horizon {
+			v.discardEnv()
+			delete(b.simulatingBid, k)
+		}
+	}
+	b.simBidMu.Unlock()
+
+	b.deferredMu.Lock()
+	delete(b.deferredBids, parentHash)
+	for k, v := range b.deferredBids {
+		if v.pkg.bid.BlockNumber <= horizon {
+			delete(b.deferredBids, k)
+		}
+	}
+	b.deferredMu.Unlock()
+}
+
+func (b *bidSimulator) clearLoop() {
+	var prevHead *types.Header
+	for head := range b.chainHeadCh {
+		b.resolveImported(head.Block)
+		// the builders of the head itself are left to OnBlockSealed, which is called after
+		b.sealBidders.take(head.Block.NumberU64() - 1)
+		if b.outcomes != nil {
+			b.outcomes.takeCompetition(head.Block.NumberU64() - 1)
+		}
+
+		prev := prevHead
+		prevHead = head.Block.Header()
+
+		if !b.isRunning() {
+			continue
+		}
+
+		b.releaseFutureBids(head.Block)
+
+		number := head.Block.NumberU64()
+		b.clearBids(head.Block.ParentHash(), number, pruneHorizon(number, b.chain.TriesInMemory()))
+
+		pruned := b.pruneNonCanonicalBids(number)
+		if prev != nil && head.Block.ParentHash() != prev.Hash() {
+			b.handleReorg(prev, head.Block, pruned)
+		}
+	}
+}
+
+// OnBlockSealed is called by the worker when a block is sealed and written into the chain,
+// bid is nil if the block is built locally.
+func (b *bidSimulator) OnBlockSealed(block *types.Block, bid *BidRuntime, fees *big.Int) {
+	var reward *big.Int
+	if bid != nil {
+		reward = bid.totalReward()
+		mevBlockFromBidCounter.Inc(1)
+		b.incBuilderCounter(builderSealedCounterPrefix, bid.bid.Builder)
+		b.addBuilderReward(bid.bid.Builder, reward)
+		b.auditBid(bidAuditSealed, bid.bid, reward, nil)
+		b.decisions.sealed(block.NumberU64(), bid.bid, reward, b.config.RedactBestBidBuilder)
+		b.resolveSealed(block.NumberU64(), bid.bid)
+		b.reportSealed(block, bid, reward)
+	} else {
+		reward = calcRewardAfterBEP95(fees)
+		mevBlockLocalCounter.Inc(1)
+		b.decisions.sealed(block.NumberU64(), nil, reward, b.config.RedactBestBidBuilder)
+		b.resolveSealed(block.NumberU64(), nil)
+		b.reportSealed(block, nil, reward)
+	}
+
+	if b.outcomes != nil {
+		b.outcomes.append(b.outcomes.newBidHistoryEntry(block, bid, reward))
+	}
+
+	if b.history == nil {
+		return
+	}
+
+	if err := b.history.record(newBidHistoryRecord(block, bid, fees)); err != nil {
+		log.Warn("BidSimulator: failed to record bid history", "block", block.NumberU64(), "err", err)
+	}
+}
+
+// ExplainOutcome returns the decision records of the bid and the winner of its block.
+func (b *bidSimulator) ExplainOutcome(blockNumber uint64, bidHash common.Hash) (*types.BidOutcome, error) {
+	outcome, err := b.decisions.explain(blockNumber, bidHash)
+	if err != nil {
+		return nil, err
+	}
+
+	outcome.Quota = b.BidQuota(blockNumber, outcome.Decision.Builder)
+
+	return outcome, nil
+}
+
+// RevenueReport returns the MEV revenue of the proposed blocks grouped by day or epoch.
+func (b *bidSimulator) RevenueReport(args *types.MevRevenueReportArgs) ([]*types.MevRevenueGroup, error) {
+	if b.history == nil {
+		return nil, errors.New("bid history is disabled")
+	}
+
+	var epochLength uint64
+	if b.chainConfig.Parlia != nil {
+		epochLength = b.chainConfig.Parlia.Epoch
 	}
+
+	return b.history.revenueReport(args, epochLength)
 }
 
 // sendBid checks if the bid is already exists or if the builder sends too many bids,
 // if yes, return error, if not, add bid into newBid chan waiting for judge profit.
-func (b *bidSimulator) sendBid(_ context.Context, bid *types.Bid) error {
+func (b *bidSimulator) sendBid(ctx context.Context, bid *types.Bid) error {
+	return b.enqueueBid(ctx, bid, false)
+}
+
+func (b *bidSimulator) enqueueBid(ctx context.Context, bid *types.Bid, fastPath bool) error {
+	timing := bidTimingFromContext(ctx)
+	b.incBuilderCounter(builderReceivedCounterPrefix, bid.Builder)
+
+	// the miner is restarted around the epoch boundaries, the bids sent meanwhile are rejected
+	// instead of being skipped by newBidLoop without feedback
+	if !b.isRunning() {
+		b.decisions.intake(bid, timing, types.ErrMevNotRunning)
+		return types.ErrMevNotRunning
+	}
+
+	if reply, ok := b.CachedReply(bid.BlockNumber, bid.Builder, bid.Hash()); ok {
+		return reply
+	}
+
+	// the queue is full, the bid is rejected at once instead of waiting for the timeout, so
+	// builders can tell the overload from a momentary busy
+	if len(b.newBidCh) >= cap(b.newBidCh) {
+		bidQueueRejectCounter.Inc(1)
+		b.decisions.intake(bid, timing, types.ErrMevBusy)
+		return types.ErrMevBusy
+	}
+
+	if err := b.CheckAndAddPending(bid.BlockNumber, bid.Builder, bid.Hash()); err != nil {
+		// the duplicate bid must not overwrite the record of the original one
+		if !errors.Is(err, types.ErrBidAlreadyExists) {
+			b.decisions.intake(bid, timing, err)
+		}
+		return err
+	}
+
 	timer := time.NewTimer(1 * time.Second)
 	defer timer.Stop()
 
 	replyCh := make(chan error, 1)
 
 	select {
-	case b.newBidCh <- newBidPackage{bid: bid, feedback: replyCh}:
-		b.AddPending(bid.BlockNumber, bid.Builder, bid.Hash())
+	case b.newBidCh <- newBidPackage{bid: bid, feedback: replyCh, fastPath: fastPath, timing: timing}:
+		bidQueueDepthGauge.Update(int64(len(b.newBidCh)))
+		b.decisions.intake(bid, timing, nil)
 	case <-timer.C:
+		// the bid never reached newBidLoop, release its pending slot
+		b.RemovePending(bid.BlockNumber, bid.Builder, bid.Hash())
+		b.decisions.intake(bid, timing, types.ErrMevBusy)
 		return types.ErrMevBusy
 	}
 
@@ -450,13 +1433,103 @@ func (b *bidSimulator) sendBid(_ context.Context, bid *types.Bid) error {
 		return reply
 	case <-timer.C:
 		return types.ErrMevBusy
+	case <-b.exitCh:
+		return types.ErrMevShuttingDown
 	}
 }
 
+// BidQueueStatus returns the depth of the queue of the bids sent to newBidLoop.
+func (b *bidSimulator) BidQueueStatus() *types.BidQueueStatus {
+	return &types.BidQueueStatus{Depth: len(b.newBidCh), Capacity: cap(b.newBidCh)}
+}
+
+// CheckPending checks if the bid already exists or if the builder sends too many bids,
+// without recording the bid.
 func (b *bidSimulator) CheckPending(blockNumber uint64, builder common.Address, bidHash common.Hash) error {
 	b.pendingMu.Lock()
 	defer b.pendingMu.Unlock()
 
+	return b.checkPendingLocked(blockNumber, builder, bidHash)
+}
+
+// CheckAndAddPending checks the bid like CheckPending and records it as pending,
+// both are done under the same lock so concurrent submissions can't both pass.
+func (b *bidSimulator) CheckAndAddPending(blockNumber uint64, builder common.Address, bidHash common.Hash) error {
+	b.pendingMu.Lock()
+	defer b.pendingMu.Unlock()
+
+	if err := b.checkPendingLocked(blockNumber, builder, bidHash); err != nil {
+		return err
+	}
+
+	b.pending[blockNumber][builder][bidHash] = struct{}{}
+	b.countPendingLocked(blockNumber, builder, time.Now())
+	if b.outcomes != nil {
+		b.outcomes.competition(blockNumber, func(c *bidCompetition) { c.bids++ })
+	}
+
+	return nil
+}
+
+// CachedReply returns the reply of the bid if the builder resends a pending bid which is
+// already simulated, so the resent bid is answered without being simulated again. ok is false
+// if there is no cached reply, reply is nil if the bid is accepted.
+//
+// The env result is not compared: the bid hash covers the parents, the txs and the fees, so a
+// bid of the same hash is simulated on the same state and ends the same way.
+func (b *bidSimulator) CachedReply(blockNumber uint64, builder common.Address, bidHash common.Hash) (reply error, ok bool) {
+	b.pendingMu.RLock()
+	defer b.pendingMu.RUnlock()
+
+	if _, pending := b.pending[blockNumber][builder][bidHash]; !pending {
+		return nil, false
+	}
+
+	reply, ok = b.simReply[blockNumber][bidHash]
+	if ok {
+		bidDedupCounter.Inc(1)
+	}
+
+	return reply, ok
+}
+
+// cacheSimReply records the reply of the simulated bid for the resends of it.
+func (b *bidSimulator) cacheSimReply(bid *types.Bid, simErr error) {
+	var reply error
+	if simErr != nil {
+		reply = types.NewInvalidBidError(fmt.Sprintf("bid is already simulated and failed: %v", simErr))
+	}
+
+	b.pendingMu.Lock()
+	defer b.pendingMu.Unlock()
+
+	if _, ok := b.simReply[bid.BlockNumber]; !ok {
+		b.simReply[bid.BlockNumber] = make(map[common.Hash]error)
+	}
+	b.simReply[bid.BlockNumber][bid.Hash()] = reply
+
+	if simErr != nil {
+		b.recordBidStatusLocked(bid, types.BidStatusFailed, simErr)
+		if b.outcomes != nil {
+			b.outcomes.competition(bid.BlockNumber, func(c *bidCompetition) { c.simFailures++ })
+		}
+	} else {
+		b.recordBidStatusLocked(bid, types.BidStatusSimulated, nil)
+	}
+}
+
+// RemovePending releases the pending slot of a bid.
+func (b *bidSimulator) RemovePending(blockNumber uint64, builder common.Address, bidHash common.Hash) {
+	b.pendingMu.Lock()
+	defer b.pendingMu.Unlock()
+
+	if bids, ok := b.pending[blockNumber][builder]; ok {
+		delete(bids, bidHash)
+	}
+}
+
+// checkPendingLocked must be called with pendingMu held.
+func (b *bidSimulator) checkPendingLocked(blockNumber uint64, builder common.Address, bidHash common.Hash) error {
 	// check if bid exists or if builder sends too many bids
 	if _, ok := b.pending[blockNumber]; !ok {
 		b.pending[blockNumber] = make(map[common.Address]map[common.Hash]struct{})
@@ -467,267 +1540,707 @@ func (b *bidSimulator) CheckPending(blockNumber uint64, builder common.Address,
 	}
 
 	if _, ok := b.pending[blockNumber][builder][bidHash]; ok {
-		return errors.New("bid already exists")
+		return types.ErrBidAlreadyExists
 	}
 
 	if len(b.pending[blockNumber][builder]) >= maxBidPerBuilderPerBlock {
-		return errors.New("too many bids")
+		return types.NewTooManyBidsError(b.bidQuotaLocked(blockNumber, builder))
 	}
 
 	return nil
 }
 
-func (b *bidSimulator) AddPending(blockNumber uint64, builder common.Address, bidHash common.Hash) {
-	b.pendingMu.Lock()
-	defer b.pendingMu.Unlock()
+// BidQuota returns the number of the bids the builder could still send for the block, nil if
+// the bids of the block are cleared.
+func (b *bidSimulator) BidQuota(blockNumber uint64, builder common.Address) *types.BidQuota {
+	b.pendingMu.RLock()
+	defer b.pendingMu.RUnlock()
 
-	b.pending[blockNumber][builder][bidHash] = struct{}{}
+	if _, ok := b.pending[blockNumber]; !ok {
+		return nil
+	}
+
+	return b.bidQuotaLocked(blockNumber, builder)
+}
+
+// bidQuotaLocked must be called with pendingMu held.
+func (b *bidSimulator) bidQuotaLocked(blockNumber uint64, builder common.Address) *types.BidQuota {
+	remaining := maxBidPerBuilderPerBlock - len(b.pending[blockNumber][builder])
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return &types.BidQuota{Remaining: remaining, Max: maxBidPerBuilderPerBlock}
+}
+
+// The reasons a simulation of simBid ends with, each simBid logs a single line with one of
+// them, or with the classification of the error by simEndReason.
+const (
+	simEndNotRunning = "notRunning"
+	simEndNotInTurn  = "notInTurn"
+	simEndCached     = "cached" // a recommit with unchanged inputs, still the best bid
+	simEndNoTimeLeft = "noTimeLeft"
+	simEndBest       = "best"
+	simEndShadowBest = "shadowBest" // the would-be best bid in shadow mode
+	simEndWorse      = "worse"
+)
+
+// simEndReason classifies the error the simulation ends with.
+func simEndReason(err error) string {
+	switch {
+	case errors.Is(err, errBetterBidArrived):
+		return "betterBidArrived"
+	case errors.Is(err, errNewHeadArrived):
+		return "newHeadArrived"
+	case errors.Is(err, errSimMinerExit):
+		return "minerExit"
+	case errors.Is(err, errSimCanceled):
+		return "canceled"
+	}
+
+	if code := bidIssueCode(err); code != "" {
+		return string(code)
+	}
+	return "failed"
+}
+
+// simInterruptErr converts the signal the simulation is cut short with to the error it aborts
+// with, and counts the interrupt by its reason.
+func simInterruptErr(signal int32) error {
+	switch signal {
+	case commitInterruptNewHead:
+		bidSimInterruptNewHeadCounter.Inc(1)
+		return errNewHeadArrived
+	case commitInterruptShutdown:
+		bidSimInterruptShutdownCounter.Inc(1)
+		return errSimMinerExit
+	case commitInterruptTimeout:
+		bidSimInterruptTimeoutCounter.Inc(1)
+		return errBidSimulationTimeout
+	default:
+		// commitInterruptBetterBid, or commitInterruptNone read off the closed interruptCh
+		bidSimInterruptBetterBidCounter.Inc(1)
+		return errBetterBidArrived
+	}
+}
+
+// simCtxErr converts the end of the simulation context to the error the simulation aborts with,
+// the context is canceled with the RPC request of the dry run, otherwise it is out of time.
+func simCtxErr(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		bidSimInterruptCanceledCounter.Inc(1)
+		return errSimCanceled
+	}
+	return simInterruptErr(commitInterruptTimeout)
+}
+
+// isSimInterrupted reports whether the simulation is cut short by a newer bid or block, which
+// is neither the fault of the builder nor a failure of the bid.
+func isSimInterrupted(err error) bool {
+	return errors.Is(err, errBetterBidArrived) || errors.Is(err, errNewHeadArrived)
+}
+
+// logSimEnd logs the end of the simulation of the bid, the ones not simulated to the end are
+// logged at info level.
+func logSimEnd(bidRuntime *BidRuntime, reason string, start time.Time, err error) {
+	logCtx := []any{
+		"builder", bidRuntime.bid.Builder,
+		"bidHash", bidRuntime.bid.Hash().TerminalString(),
+		"blockNumber", bidRuntime.bid.BlockNumber,
+		"parentHash", bidRuntime.bid.ParentHash,
+		"reason", reason,
+		"elapsed", common.PrettyDuration(time.Since(start)),
+		"gasUsed", bidRuntime.bid.GasUsed,
+	}
+	if bidRuntime.env != nil {
+		logCtx = append(logCtx, "gasLimit", bidRuntime.env.header.GasLimit)
+	}
+	if err != nil {
+		logCtx = append(logCtx, "err", err)
+	}
+
+	switch reason {
+	case simEndBest, simEndShadowBest, simEndWorse, simEndCached:
+		log.Debug("BidSimulator: simulation ended", logCtx...)
+	default:
+		log.Info("BidSimulator: simulation ended", logCtx...)
+	}
 }
 
 // simBid simulates a newBid with txs.
-// simBid does not enable state prefetching when commit transaction.
+// simBid does not enable state prefetching when commit transaction, the accounts touched by
+// the bid are loaded ahead if BidStatePrefetch is set.
 func (b *bidSimulator) simBid(interruptCh chan int32, bidRuntime *BidRuntime) {
+	startTS := time.Now()
+
 	// prevent from stopping happen in time interval from sendBid to simBid
 	if !b.isRunning() || !b.receivingBid() {
+		logSimEnd(bidRuntime, simEndNotRunning, startTS, nil)
+		b.resolveSimulated(bidRuntime.bid, errSimMinerExit)
 		return
 	}
 
-	var (
-		startTS = time.Now()
-
-		blockNumber = bidRuntime.bid.BlockNumber
-		parentHash  = bidRuntime.bid.ParentHash
-		builder     = bidRuntime.bid.Builder
+	// don't burn the trie cache on a block the validator won't seal
+	if err := b.checkInTurn(bidRuntime.bid.ParentHash); err != nil {
+		logSimEnd(bidRuntime, simEndNotInTurn, startTS, nil)
+		b.resolveSimulated(bidRuntime.bid, err)
+		return
+	}
 
-		bidTxs   = bidRuntime.bid.Txs
-		bidTxLen = len(bidTxs)
-		payBidTx = bidTxs[bidTxLen-1]
+	var (
+		parentHash = bidRuntime.bid.ParentHash
+		builder    = bidRuntime.bid.Builder
 
-		receipt *types.Receipt
 		err     error
+		reason  string // set at each return, or classified from err
 		success bool
+
+		parent = b.chain.GetHeaderByHash(parentHash)
 	)
 
+	// a recommitted bid with unchanged inputs is still the best, nothing to simulate
+	if cached := b.cachedSimResult(bidRuntime.bid, parent); cached != nil {
+		bidRecommitHitCounter.Inc(1)
+		logSimEnd(bidRuntime, simEndCached, startTS, nil)
+		return
+	}
+
 	// ensure simulation exited then start next simulation
 	b.SetSimulatingBid(parentHash, bidRuntime)
+	b.incBuilderCounter(builderSimulateCounterPrefix, builder)
+	b.auditBid(bidAuditSimStarted, bidRuntime.bid, nil, nil)
 
 	defer func(simStart time.Time) {
-		logCtx := []any{
-			"blockNumber", blockNumber,
-			"parentHash", parentHash,
-			"builder", builder,
-			"gasUsed", bidRuntime.bid.GasUsed,
+		if err != nil {
+			reason = simEndReason(err)
+		}
+		logSimEnd(bidRuntime, reason, simStart, err)
+		switch {
+		case reason == simEndBest:
+			b.incBuilderCounter(builderBestCounterPrefix, builder)
+			b.auditBid(bidAuditBest, bidRuntime.bid, bidRuntime.totalReward(), nil)
+		case isSimInterrupted(err):
+			b.auditBid(bidAuditInterrupted, bidRuntime.bid, nil, err)
+		case err != nil:
+			b.auditBid(bidAuditFailed, bidRuntime.bid, nil, err)
 		}
 
-		if bidRuntime.env != nil {
-			logCtx = append(logCtx, "gasLimit", bidRuntime.env.header.GasLimit)
-
-			if err != nil || !success {
-				bidRuntime.env.discard()
-			}
+		if err != nil || !success {
+			bidRuntime.discardEnv()
 		}
 
 		if err != nil {
-			logCtx = append(logCtx, "err", err)
-			log.Info("BidSimulator: simulation failed", logCtx...)
+			b.reportIssue(bidRuntime, err)
+		}
+
+		// aborted simulations are not the fault of the builder
+		if success || err != nil && !isSimInterrupted(err) && !errors.Is(err, errSimMinerExit) {
+			if errors.Is(err, errBidSimulationTimeout) {
+				b.recordSimTimeout(builder, time.Since(simStart))
+			} else {
+				b.recordSimResult(builder, time.Since(simStart), err != nil)
+			}
+			b.cacheSimReply(bidRuntime.bid, err)
 
-			go b.reportIssue(bidRuntime, err)
+			if err != nil && bidRuntime.fastPath {
+				b.recordFastPathFailure(builder)
+			}
+		}
+		if err != nil {
+			b.decisions.simulated(bidRuntime, time.Since(simStart), err)
+			b.resolveSimulated(bidRuntime.bid, err)
 		}
 
 		b.RemoveSimulatingBid(parentHash)
 		close(bidRuntime.finished)
+		b.resumeDeferredBid(parentHash)
 
 		if success {
 			bidRuntime.duration = time.Since(simStart)
 			bidSimTimer.UpdateSince(simStart)
+			b.updateBuilderTimer(builderSimTimerPrefix, builder, simStart)
+			b.cacheSimResult(bidRuntime, parent)
 
 			// only recommit self bid when newBidCh is empty
 			if len(b.newBidCh) > 0 {
 				return
 			}
 
-			select {
-			case b.newBidCh <- newBidPackage{bid: bidRuntime.bid}:
-				log.Debug("BidSimulator: recommit", "builder", bidRuntime.bid.Builder, "bidHash", bidRuntime.bid.Hash().Hex())
-			default:
-			}
+			select {
+			case b.newBidCh <- newBidPackage{bid: bidRuntime.bid, timing: bidRuntime.timing}:
+				log.Debug("BidSimulator: recommit", "builder", bidRuntime.bid.Builder, "bidHash", bidRuntime.bid.Hash().Hex())
+			default:
+			}
+		}
+	}(startTS)
+
+	// prepareWork will configure header with a suitable time according to consensus
+	// prepareWork will start trie prefetching
+	if bidRuntime.env, err = b.bidWorker.prepareWork(&generateParams{
+		parentHash: bidRuntime.bid.ParentHash,
+		coinbase:   b.bidWorker.etherbase(),
+	}); err != nil {
+		err = newBidSimError(types.ErrCodeInternal, err)
+		return
+	}
+
+	// the accounts accessed by the bid txs tell the conflicts of the txs merged from the second best bid
+	if b.config.EnableBidMerging {
+		bidRuntime.env.touched = make(map[common.Hash][]common.Address, len(bidRuntime.bid.Txs))
+	}
+
+	// the senders are cached with the signer of the block, the txs of the bid are shared by its
+	// recommits, so the re-simulations skip the ECDSA recovery in ApplyTransaction
+	bidSenderRecoverCounter.Inc(int64(bidRuntime.bid.CacheSenders(bidRuntime.env.signer)))
+
+	if b.config.BidStatePrefetch {
+		stop := prefetchBidState(bidRuntime.env.state, bidTouchedAccounts(bidRuntime.env.signer, bidRuntime.bid.Txs))
+		defer stop()
+	}
+
+	// if the left time is not enough to do simulation, return
+	delay := b.engine.Delay(b.chain, bidRuntime.env.header, &b.delayLeftOver)
+	if delay == nil || *delay <= 0 {
+		reason = simEndNoTimeLeft
+		return
+	}
+
+	// a hard deadline for the whole simulation, so a heavy bid can't starve the later ones,
+	// it is checked between the txs and cancels the EVM in the middle of a tx
+	simCtx := context.Background()
+	if b.config.BidSimulationMaxDuration > 0 {
+		var cancel context.CancelFunc
+		simCtx, cancel = context.WithDeadline(simCtx, startTS.Add(b.config.BidSimulationMaxDuration))
+		defer cancel()
+	}
+
+	// commit the txs of the bid, the payBidTx is committed after the greedy merge
+	err = b.commitBidTxs(simCtx, interruptCh, bidRuntime)
+	// the declarations are judged once the builder txs are executed, whether they hold or not
+	if err == nil || bidIssueCode(err) == types.ErrCodeRewardTooLow {
+		b.recordDeclaration(builder, bidRuntime)
+	}
+	if err != nil {
+		return
+	}
+
+	// the fast path verifies the payment of late bids on the pre-merge snapshot of the best bid
+	if b.config.FastPathEnabled {
+		bidRuntime.preMergeEnv = bidRuntime.env.copy()
+	}
+
+	// if enable greedy merge, fill bid env with transactions from mempool
+	if b.config.GreedyMergeTx {
+		delay := b.engine.Delay(b.chain, bidRuntime.env.header, &b.delayLeftOver)
+		if delay != nil && *delay > 0 {
+			b.greedyMerge(interruptCh, bidRuntime)
+
+			// recalculate the packed reward
+			bidRuntime.updatePackReward(false)
+		}
+	}
+
+	if err = b.commitPayBidTx(simCtx, bidRuntime); err != nil {
+		return
+	}
+
+	b.decisions.simulated(bidRuntime, time.Since(startTS), nil)
+	b.incBuilderCounter(builderSimOkCounterPrefix, builder)
+
+	// no bid becomes the best bid in shadow mode, so none is sealed, published to the builders
+	// or recommitted, the env is discarded as not successful
+	if b.config.ShadowMode {
+		bidSimTimer.UpdateSince(startTS)
+		b.recordSimResult(builder, time.Since(startTS), false)
+		b.cacheSimReply(bidRuntime.bid, nil)
+
+		reason = simEndWorse
+		if b.recordShadowBest(bidRuntime) {
+			reason = simEndShadowBest
+		}
+		return
+	}
+
+	bestBid := b.GetBestBid(parentHash)
+	if bestBid == nil {
+		b.decisions.finalComparison(bidRuntime.bid, &types.BidComparison{
+			Against: againstNone,
+			Value:   b.valuator.RealizedValue(bidRuntime, false),
+			Won:     true,
+		})
+		log.Info("[BID RESULT]", "win", "true[first]", "builder", bidRuntime.bid.Builder, "hash", bidRuntime.bid.Hash().TerminalString())
+		b.publishBidResult(bidRuntime, true, bidRuntime.totalReward(), time.Since(startTS))
+		if b.config.ParanoidMode {
+			b.startVerification(bidRuntime, time.Since(startTS))
+		}
+		b.SetBestBid(bidRuntime.bid.ParentHash, bidRuntime)
+		reason, success = simEndBest, true
+		return
+	}
+
+	var (
+		bidContribute       = b.valuator.RealizedValue(bidRuntime, false)
+		existBidContribute  = b.valuator.RealizedValue(bestBid, false)
+		shouldUpdateBestBid = beatsBestReward(b.config, bidContribute, existBidContribute)
+	)
+
+	b.decisions.finalComparison(bidRuntime.bid, &types.BidComparison{
+		Against:      againstBestBid,
+		AgainstBid:   bidHashRef(bestBid.bid),
+		Value:        bidContribute,
+		AgainstValue: existBidContribute,
+		Won:          shouldUpdateBestBid,
+	})
+
+	if bidRuntime.bid.Hash() != bestBid.bid.Hash() {
+		// bestBid is the previous best, it is replaced by SetBestBid below if the bid wins
+		b.logBidResult(bidRuntime, bestBid, shouldUpdateBestBid, bidContribute, existBidContribute, time.Since(startTS))
+		b.publishBidResult(bidRuntime, shouldUpdateBestBid, bidContribute, time.Since(startTS))
+	}
+
+	// this is the simplest strategy: best for all the delegators.
+	if shouldUpdateBestBid {
+		if b.config.ParanoidMode {
+			b.startVerification(bidRuntime, time.Since(startTS))
 		}
-	}(startTS)
+		if bidRuntime.bid.Hash() != bestBid.bid.Hash() {
+			b.auditBid(bidAuditDisplaced, bestBid.bid, bestBid.totalReward(), nil)
+		}
+		b.SetBestBid(bidRuntime.bid.ParentHash, bidRuntime)
+		reason, success = simEndBest, true
 
-	// prepareWork will configure header with a suitable time according to consensus
-	// prepareWork will start trie prefetching
-	if bidRuntime.env, err = b.bidWorker.prepareWork(&generateParams{
-		parentHash: bidRuntime.bid.ParentHash,
-		coinbase:   b.bidWorker.etherbase(),
-	}); err != nil {
+		// the displaced best bid is the second best now
+		if b.config.EnableBidMerging {
+			b.mergeBids(simCtx, bidRuntime, bestBid)
+		}
 		return
 	}
+	reason = simEndWorse
 
-	// if the left time is not enough to do simulation, return
-	delay := b.engine.Delay(b.chain, bidRuntime.env.header, &b.delayLeftOver)
-	if delay == nil || *delay <= 0 {
-		log.Info("BidSimulator: abort commit, not enough time to simulate",
-			"builder", bidRuntime.bid.Builder, "bidHash", bidRuntime.bid.Hash().Hex())
+	if b.config.EnableBidMerging {
+		b.mergeBids(simCtx, bestBid, bidRuntime)
+	}
+
+	// only recommit last best bid when newBidCh is empty
+	if len(b.newBidCh) > 0 {
 		return
 	}
 
-	gasLimit := bidRuntime.env.header.GasLimit
+	select {
+	case b.newBidCh <- newBidPackage{bid: bestBid.bid}:
+		log.Debug("BidSimulator: recommit last bid", "builder", bidRuntime.bid.Builder, "bidHash", bidRuntime.bid.Hash().Hex())
+	default:
+	}
+}
+
+// commitBidTxs commits the txs of the bid but the payBidTx into the env of the bid, and checks
+// the reward of them. interruptCh is nil if the simulation can't be interrupted.
+func (b *bidSimulator) commitBidTxs(ctx context.Context, interruptCh chan int32, bidRuntime *BidRuntime) error {
+	var (
+		bidTxs    = bidRuntime.bid.Txs
+		gasLimit  = bidRuntime.env.header.GasLimit
+		bribeEOAs = b.bribeEOAs(bidRuntime)
+	)
+
+	// the payBidTx is indexed below, the simulation does not rely on the precheck of the intake
+	if err := checkPayBidTx(bidTxs); err != nil {
+		return newBidSimError(types.ErrCodeInvalidPayBidTx, err)
+	}
+
 	if bidRuntime.env.gasPool == nil {
 		bidRuntime.env.gasPool = new(core.GasPool).AddGas(gasLimit)
 		bidRuntime.env.gasPool.SubGas(params.SystemTxsGas)
 		bidRuntime.env.gasPool.SubGas(params.PayBidTxGasLimit)
 	}
 
+	// the error is reported to the builder, with the numbers to self-correct
 	if bidRuntime.bid.GasUsed > bidRuntime.env.gasPool.Gas() {
-		err = errors.New("gas used exceeds gas limit")
-		return
+		return newBidSimError(types.ErrCodeGasExceeded, fmt.Errorf("gas used exceeds gas limit, declared gasUsed %d, available gas %d, gasLimit %d",
+			bidRuntime.bid.GasUsed, bidRuntime.env.gasPool.Gas(), gasLimit))
 	}
 
-	// commit transactions in bid
-	for _, tx := range bidRuntime.bid.Txs {
+	// the optional txs may be skipped, so the payBidTx is told by its position instead of tcount
+	for i, tx := range bidTxs[:len(bidTxs)-1] {
 		select {
-		case <-interruptCh:
-			err = errors.New("simulation abort due to better bid arrived")
-			return
+		case signal := <-interruptCh:
+			return simInterruptErr(signal)
 
 		case <-b.exitCh:
-			err = errors.New("miner exit")
-			return
+			return simInterruptErr(commitInterruptShutdown)
+
+		case <-ctx.Done():
+			return simCtxErr(ctx)
 
 		default:
 		}
 
-		if bidRuntime.env.tcount == bidTxLen-1 {
-			break
+		// the underpriced optional tx is skipped like a failed one
+		optional := bidRuntime.bid.Optional.Contains(tx.Hash())
+		if err := checkTxGasPrice(tx, b.minGasPrice, bidRuntime.env.header.BaseFee); err != nil {
+			if optional {
+				continue
+			}
+			return newBidSimError(types.ErrCodeUnderpriced, &bidTxError{index: i, txHash: tx.Hash(), err: err})
 		}
 
-		receipt, err = bidRuntime.commitTransaction(b.chain, b.chainConfig, tx, bidRuntime.bid.UnRevertible.Contains(tx.Hash()))
+		var (
+			bribeBalances = bidRuntime.bribeBalances(bribeEOAs)
+			receipt       *types.Receipt
+			err           error
+		)
+		if optional {
+			receipt, err = bidRuntime.commitOptionalTransaction(ctx, b.chain, b.chainConfig, tx)
+		} else {
+			receipt, err = bidRuntime.commitTransaction(ctx, b.chain, b.chainConfig, tx, bidRuntime.bid.UnRevertible.Contains(tx.Hash()))
+		}
+		if errors.Is(err, core.ErrExecutionAborted) {
+			return simCtxErr(ctx)
+		}
 		if err != nil {
-			log.Error("BidSimulator: failed to commit tx", "bidHash", bidRuntime.bid.Hash(), "tx", tx.Hash(), "err", err)
-			err = fmt.Errorf("invalid tx in bid, %v", err)
-			return
+			return newBidSimError(types.ErrCodeInvalidTx, &bidTxError{index: i, txHash: tx.Hash(), err: err})
 		}
-		bidRuntime.checkValidatorBribe(b.config.ValidatorBribeEOAs, tx, receipt)
+		if receipt == nil {
+			// the optional tx is skipped
+			continue
+		}
+		bidRuntime.checkValidatorBribe(bribeEOAs, bribeBalances, receipt)
 	}
 
 	// check if bid reward is valid
-	{
-		bidRuntime.updatePackReward(true)
-		if !bidRuntime.validReward() {
-			err = errors.New("reward does not achieve the expectation")
-			return
-		}
+	bidRuntime.updatePackReward(true)
+	if !bidRuntime.validReward() {
+		return newBidSimError(types.ErrCodeRewardTooLow, errors.New("reward does not achieve the expectation"))
 	}
 
-	// if enable greedy merge, fill bid env with transactions from mempool
-	if b.config.GreedyMergeTx {
-		delay := b.engine.Delay(b.chain, bidRuntime.env.header, &b.delayLeftOver)
-		if delay != nil && *delay > 0 {
-			bidTxsSet := mapset.NewThreadUnsafeSetWithSize[common.Hash](len(bidRuntime.bid.Txs))
-			for _, tx := range bidRuntime.bid.Txs {
-				bidTxsSet.Add(tx.Hash())
-			}
-
-			fillErr := b.bidWorker.fillTransactions(interruptCh, bidRuntime.env, nil, bidTxsSet)
-			log.Trace("BidSimulator: greedy merge stopped", "block", bidRuntime.env.header.Number,
-				"builder", bidRuntime.bid.Builder, "tx count", bidRuntime.env.tcount-bidTxLen+1, "err", fillErr)
+	return nil
+}
 
-			// recalculate the packed reward
-			bidRuntime.updatePackReward(false)
+// checkTxGasPrice checks the effective gas price of the tx in the block meets both the minimum
+// gas price of the miner and the base fee, as the txpool does for the txs it admits.
+func checkTxGasPrice(tx *types.Transaction, minGasPrice, baseFee *big.Int) error {
+	price := tx.GasPrice()
+	if baseFee != nil {
+		tip, err := tx.EffectiveGasTip(baseFee)
+		if err != nil {
+			return fmt.Errorf("%w: maxFeePerGas %v, baseFee %v", core.ErrFeeCapTooLow, tx.GasFeeCap(), baseFee)
 		}
+		price = tip.Add(tip, baseFee)
+	}
+
+	if minGasPrice != nil && price.Cmp(minGasPrice) < 0 {
+		return fmt.Errorf("%w: effective gas price %v, minimum %v", txpool.ErrUnderpriced, price, minGasPrice)
 	}
 
-	// commit payBidTx at the end of the block
+	return nil
+}
+
+// commitPayBidTx commits the payBidTx at the end of the block, and checks the payment and
+// the size of the block.
+func (b *bidSimulator) commitPayBidTx(ctx context.Context, bidRuntime *BidRuntime) error {
+	var (
+		bidTxs   = bidRuntime.bid.Txs
+		payBidTx = bidTxs[len(bidTxs)-1]
+
+		prePayReward = bidRuntime.env.state.GetBalance(consensus.SystemAddress).Clone()
+		bribeEOAs    = b.validatorBribeEOAs(bidRuntime.bid.BlockNumber)
+		prePayBribes = bidRuntime.bribeBalances(bribeEOAs)
+	)
+
 	bidRuntime.env.gasPool.AddGas(params.PayBidTxGasLimit)
-	_, err = bidRuntime.commitTransaction(b.chain, b.chainConfig, payBidTx, true)
+	_, err := bidRuntime.commitTransaction(ctx, b.chain, b.chainConfig, payBidTx, true)
+	if errors.Is(err, core.ErrExecutionAborted) {
+		return simCtxErr(ctx)
+	}
 	if err != nil {
-		log.Error("BidSimulator: failed to commit tx", "builder", bidRuntime.bid.Builder,
-			"bidHash", bidRuntime.bid.Hash(), "tx", payBidTx.Hash(), "err", err)
-		err = fmt.Errorf("invalid tx in bid, %v", err)
-		return
+		return newBidSimError(types.ErrCodeInvalidTx, &bidTxError{index: len(bidTxs) - 1, txHash: payBidTx.Hash(), err: err})
 	}
 
-	// check bid size
-	if bidRuntime.env.size+blockReserveSize > params.MaxMessageSize {
-		log.Error("BidSimulator: failed to check bid size", "builder", bidRuntime.bid.Builder,
-			"bidHash", bidRuntime.bid.Hash(), "env.size", bidRuntime.env.size)
-		err = errors.New("invalid bid size")
-		return
+	// the payBidTx must not take more than the declared builder fee from the validator
+	if err = bidRuntime.checkPayment(bribeEOAs, prePayReward, prePayBribes); err != nil {
+		return newBidSimError(types.ErrCodeInvalidPayment, err)
 	}
 
-	bestBid := b.GetBestBid(parentHash)
-	if bestBid == nil {
-		log.Info("[BID RESULT]", "win", "true[first]", "builder", bidRuntime.bid.Builder, "hash", bidRuntime.bid.Hash().TerminalString())
-		b.SetBestBid(bidRuntime.bid.ParentHash, bidRuntime)
-		success = true
+	// check bid size, the blob sidecars are sent along with the sealed block
+	if bidRuntime.env.size+bidRuntime.sidecarSize+blockReserveSize > params.MaxMessageSize {
+		return newBidSimError(types.ErrCodeInvalidSize, fmt.Errorf("invalid bid size, env.size %d, sidecarSize %d",
+			bidRuntime.env.size, bidRuntime.sidecarSize))
+	}
+
+	return nil
+}
+
+// SubscribeBidResults subscribes the results of the simulated bids compared with the best bid.
+func (b *bidSimulator) SubscribeBidResults(ch chan<- types.BidResult) event.Subscription {
+	return b.bidResultFeed.Subscribe(ch)
+}
+
+// publishBidResult never blocks, the result is dropped if the subscribers fall behind.
+func (b *bidSimulator) publishBidResult(bidRuntime *BidRuntime, win bool, contribute *big.Int, elapsed time.Duration) {
+	result := types.BidResult{
+		Builder:    bidRuntime.bid.Builder,
+		BidHash:    bidRuntime.bid.Hash(),
+		Win:        win,
+		Contribute: contribute,
+		Elapsed:    elapsed,
+	}
+
+	select {
+	case b.bidResultCh <- result:
+	default:
+		bidResultDropCounter.Inc(1)
+	}
+}
+
+func (b *bidSimulator) bidResultLoop() {
+	for {
+		select {
+		case result := <-b.bidResultCh:
+			b.bidResultFeed.Send(result)
+		case <-b.exitCh:
+			return
+		}
+	}
+}
+
+// startVerification snapshots the environment of the bid and re-executes its txs on a second state
+// in background, the worker only seals the bid if both executions agree on the receipts root and
+// state root. It must be called before the bid is published as the best bid.
+func (b *bidSimulator) startVerification(bidRuntime *BidRuntime, simElapsed time.Duration) {
+	bidRuntime.verified = make(chan struct{})
+
+	// re-execution costs about the same as the simulation, skip it if there is no enough time left
+	delay := b.engine.Delay(b.chain, bidRuntime.env.header, &b.delayLeftOver)
+	if delay == nil || *delay < simElapsed {
+		bidVerifySkipCounter.Inc(1)
+		close(bidRuntime.verified)
 		return
 	}
 
 	var (
-		bidContribute       = bidRuntime.totalReward()
-		existBidContribute  = bestBid.totalReward()
-		shouldUpdateBestBid = bidContribute.Cmp(existBidContribute) > 0
+		header   = types.CopyHeader(bidRuntime.env.header)
+		coinbase = bidRuntime.env.coinbase
+		statedb  = bidRuntime.env.state.Copy()
+		txs      = append([]*types.Transaction(nil), bidRuntime.env.txs...)
+		receipts = append(types.Receipts(nil), bidRuntime.env.receipts...)
 	)
 
-	if bidRuntime.bid.Hash() != bestBid.bid.Hash() {
-		log.Info("[BID RESULT]",
-			"win", shouldUpdateBestBid,
+	go func() {
+		defer close(bidRuntime.verified)
+		bidRuntime.verifyErr = b.verifyBid(header, coinbase, statedb, txs, receipts)
+		if bidRuntime.verifyErr != nil {
+			bidVerifyFailCounter.Inc(1)
+			log.Error("BidSimulator: bid verification failed", "builder", bidRuntime.bid.Builder,
+				"bidHash", bidRuntime.bid.Hash().TerminalString(), "err", bidRuntime.verifyErr)
+		}
+	}()
+}
 
-			"bidHash", bidRuntime.bid.Hash().TerminalString(),
-			"bestHash", bestBid.bid.Hash().TerminalString(),
+// verifyBid re-executes the txs on a fresh environment of the parent, and compares the result
+// with the simulated one.
+func (b *bidSimulator) verifyBid(header *types.Header, coinbase common.Address, statedb *state.StateDB,
+	txs []*types.Transaction, receipts types.Receipts) error {
+	env, err := b.bidWorker.prepareWork(&generateParams{
+		parentHash: header.ParentHash,
+		coinbase:   coinbase,
+		timestamp:  header.Time,
+	})
+	if err != nil {
+		return err
+	}
+	defer env.discard()
 
-			"bidCtb", weiToEtherStringF6(bidContribute),
-			"bestCtb", weiToEtherStringF6(existBidContribute),
+	var (
+		gasPool        = new(core.GasPool).AddGas(env.header.GasLimit)
+		replayReceipts = make(types.Receipts, 0, len(txs))
+	)
 
-			"bidBlockTx", bidRuntime.env.tcount,
-			"bestBlockTx", bestBid.env.tcount,
+	for i, tx := range txs {
+		env.state.SetTxContext(tx.Hash(), i)
 
-			"simElapsed", time.Since(startTS),
-		)
+		receipt, err := core.ApplyTransaction(b.chainConfig, b.chain, &env.coinbase, gasPool, env.state, env.header, tx,
+			&env.header.GasUsed, *b.chain.GetVMConfig(), core.NewReceiptBloomGenerator())
+		if err != nil {
+			return fmt.Errorf("failed to replay tx %d %s, %v", i, tx.Hash(), err)
+		}
+		replayReceipts = append(replayReceipts, receipt)
 	}
 
-	// this is the simplest strategy: best for all the delegators.
-	if shouldUpdateBestBid {
-		b.SetBestBid(bidRuntime.bid.ParentHash, bidRuntime)
-		success = true
-		return
-	}
+	var (
+		deleteEmpty     = b.chainConfig.IsEIP158(header.Number)
+		receiptRoot     = types.DeriveSha(receipts, trie.NewStackTrie(nil))
+		replayReceiptRt = types.DeriveSha(replayReceipts, trie.NewStackTrie(nil))
+		stateRoot       = statedb.IntermediateRoot(deleteEmpty)
+		replayStateRoot = env.state.IntermediateRoot(deleteEmpty)
+	)
 
-	// only recommit last best bid when newBidCh is empty
-	if len(b.newBidCh) > 0 {
-		return
+	if receiptRoot == replayReceiptRt && stateRoot == replayStateRoot {
+		return nil
 	}
 
-	select {
-	case b.newBidCh <- newBidPackage{bid: bestBid.bid}:
-		log.Debug("BidSimulator: recommit last bid", "builder", bidRuntime.bid.Builder, "bidHash", bidRuntime.bid.Hash().Hex())
-	default:
+	// dump the divergence of both runs for debugging
+	for i := range receipts {
+		if i >= len(replayReceipts) {
+			break
+		}
+		local, replay := receipts[i], replayReceipts[i]
+		if local.Status != replay.Status || local.GasUsed != replay.GasUsed || len(local.Logs) != len(replay.Logs) {
+			log.Error("BidSimulator: verification diverged", "index", i, "tx", txs[i].Hash(),
+				"status", local.Status, "replayStatus", replay.Status,
+				"gasUsed", local.GasUsed, "replayGasUsed", replay.GasUsed,
+				"logs", len(local.Logs), "replayLogs", len(replay.Logs))
+		}
 	}
+
+	return fmt.Errorf("execution mismatch, receiptRoot %s vs %s, stateRoot %s vs %s",
+		receiptRoot, replayReceiptRt, stateRoot, replayStateRoot)
 }
 
-// reportIssue reports the issue to the mev-sentry
+// reportIssue records the failure of the builder, and queues the issue to report it to the
+// mev-sentry, see issueLoop.
 func (b *bidSimulator) reportIssue(bidRuntime *BidRuntime, err error) {
-	metrics.GetOrRegisterCounter(fmt.Sprintf("bid/err/%v", bidRuntime.bid.Builder), nil).Inc(1)
-
-	cli := b.builders[bidRuntime.bid.Builder]
-	if cli != nil {
-		err = cli.ReportIssue(context.Background(), &types.BidIssue{
-			Validator: bidRuntime.env.header.Coinbase,
-			Builder:   bidRuntime.bid.Builder,
-			BidHash:   bidRuntime.bid.Hash(),
-			Message:   err.Error(),
-		})
+	b.incBuilderCounter(builderErrCounterPrefix, bidRuntime.bid.Builder)
+	b.recordError(bidRuntime.bid.Builder)
 
-		if err != nil {
-			log.Warn("BidSimulator: failed to report issue", "builder", bidRuntime.bid.Builder, "err", err)
+	if cli, _ := b.GetBuilder(bidRuntime.bid.Builder); cli != nil {
+		// env is nil if the simulation failed to prepare
+		validator := b.bidWorker.etherbase()
+		if bidRuntime.env != nil {
+			validator = bidRuntime.env.header.Coinbase
 		}
+
+		b.issues.push(newBidIssue(validator, bidRuntime.bid, err))
+	}
+}
+
+// newBidIssue creates the issue of the failed bid, with the failed tx if there is one.
+func newBidIssue(validator common.Address, bid *types.Bid, err error) *types.BidIssue {
+	issue := &types.BidIssue{
+		Validator: validator,
+		Builder:   bid.Builder,
+		BidHash:   bid.Hash(),
+		Message:   err.Error(),
+		Code:      bidIssueCode(err),
+	}
+
+	var txErr *bidTxError
+	if errors.As(err, &txErr) {
+		index := uint64(txErr.index)
+		issue.TxHash, issue.TxIndex = &txErr.txHash, &index
+	}
+
+	var revertErr *txRevertedError
+	if errors.As(err, &revertErr) {
+		issue.RevertReason = revertErr.reason
 	}
+
+	return issue
 }
 
 type BidRuntime struct {
 	bid *types.Bid
 
-	env *environment
+	env         *environment
+	sidecarSize uint32 // serialized size of the blob sidecars of the bid txs, not counted in env.size
 
 	packedBlockRewardPreBEP95Builder *uint256.Int
 	packedBlockRewardPreBEP95Final   *uint256.Int
@@ -735,7 +2248,26 @@ type BidRuntime struct {
 	finished chan struct{}
 	duration time.Duration
 
+	// verified is closed when the paranoid verification is done, nil if not verified
+	verified  chan struct{}
+	verifyErr error
+
 	directBribe *big.Int
+
+	// penaltyBps discounts the expected reward when comparing with other bids, for the failures
+	// and the inaccurate declarations of the builder
+	penaltyBps uint64
+
+	fastPath    bool         // accepted by the fast path after bidBetterBefore
+	timing      bidTiming    // timestamps at the RPC layer
+	preMergeEnv *environment // snapshot before the greedy merge, only kept if the fast path is enabled
+	mergedFrom  *types.Bid   // the second best bid whose txs are merged into the env, nil if not merged
+
+	// the env is sealed from by the worker while the bid simulator may discard it, the discard
+	// is deferred until the references acquired by the worker are released
+	envMu        sync.Mutex
+	envRefs      int
+	envDiscarded bool // discarded, or to be discarded once envRefs drops to 0
 }
 
 func newBidRuntime(bid *types.Bid) *BidRuntime {
@@ -746,6 +2278,66 @@ func newBidRuntime(bid *types.Bid) *BidRuntime {
 	}
 }
 
+// Acquire holds the env of the bid from being discarded until Release is called, it returns
+// false if the env is discarded already, so it can't be sealed from.
+func (r *BidRuntime) Acquire() bool {
+	r.envMu.Lock()
+	defer r.envMu.Unlock()
+
+	if r.envDiscarded {
+		return false
+	}
+	r.envRefs++
+
+	return true
+}
+
+// Release drops the reference acquired by Acquire, the env is discarded if it is requested meanwhile.
+func (r *BidRuntime) Release() {
+	r.envMu.Lock()
+	defer r.envMu.Unlock()
+
+	r.envRefs--
+	if r.envRefs == 0 && r.envDiscarded && r.env != nil {
+		r.env.discard()
+	}
+}
+
+// discardEnv discards the env of the bid, or defers it to the last Release if the env is in use.
+func (r *BidRuntime) discardEnv() {
+	r.envMu.Lock()
+	defer r.envMu.Unlock()
+
+	if r.envDiscarded {
+		return
+	}
+	r.envDiscarded = true
+
+	if r.envRefs == 0 && r.env != nil {
+		r.env.discard()
+	}
+}
+
+// waitVerified waits for the paranoid verification of the bid at most timeout, and returns
+// the verification error. If the verification doesn't finish in time, errBidNotVerified is
+// returned, and the callers seal the bid unverified rather than miss the slot.
+func (r *BidRuntime) waitVerified(timeout time.Duration) error {
+	if r.verified == nil {
+		return nil
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-r.verified:
+		return r.verifyErr
+	case <-timer.C:
+		bidVerifySkipCounter.Inc(1)
+		return errBidNotVerified
+	}
+}
+
 func (r *BidRuntime) updatePackReward(isRawBid bool) {
 	r.packedBlockRewardPreBEP95Final = r.env.state.GetBalance(consensus.SystemAddress)
 	if isRawBid {
@@ -759,32 +2351,181 @@ func (r *BidRuntime) validReward() bool {
 }
 
 func (r *BidRuntime) expectedRewardFromBuilder() *big.Int {
-	return new(big.Int).Add(calcRewardAfterBEP95(r.bid.GasFee), r.bid.NontaxableFee)
+	return rewardValuator{}.ExpectedValue(r.bid)
+}
+
+// penalizedExpectedValue returns the expected value of the bid discounted by the penalty of
+// the builder, it equals to the expected value if no penalty is applied.
+func (r *BidRuntime) penalizedExpectedValue(v BidValuator) *big.Int {
+	value := v.ExpectedValue(r.bid)
+	if r.penaltyBps == 0 {
+		return value
+	}
+
+	value.Mul(value, new(big.Int).SetUint64(10000-r.penaltyBps))
+	return value.Div(value, big.NewInt(10000))
+}
+
+func (r *BidRuntime) isExpectedBetterThanSimulatingBid(simBid *BidRuntime, v BidValuator) bool {
+	return r.penalizedExpectedValue(v).Cmp(simBid.penalizedExpectedValue(v)) > 0
+}
+
+// interruptsSimulatingBid reports whether the expected value of the bid exceeds the one of the
+// simulating bid by more than BidInterruptMinIncreaseBps, to interrupt its simulation.
+func (r *BidRuntime) interruptsSimulatingBid(simBid *BidRuntime, config *MevConfig, v BidValuator) bool {
+	var (
+		value    = r.penalizedExpectedValue(v)
+		simValue = simBid.penalizedExpectedValue(v)
+		margin   = new(big.Int).Mul(simValue, new(big.Int).SetUint64(config.BidInterruptMinIncreaseBps))
+	)
+	margin.Div(margin, big.NewInt(10000))
+
+	return value.Sub(value, simValue).Cmp(margin) > 0
+}
+
+// isExpectedBetterThanBestBid compares with the simulated value of the best bid, which
+// is certain, so no penalty is applied to it.
+func (r *BidRuntime) isExpectedBetterThanBestBid(bestBid *BidRuntime, config *MevConfig, v BidValuator) bool {
+	return beatsBestReward(config, r.penalizedExpectedValue(v), v.RealizedValue(bestBid, true))
+}
+
+// beatsBestReward returns true if the reward beats the best reward by the minimum improvement,
+// the larger of MinBidImprovement and MinBidImprovementBps of the best reward. Without minimum
+// improvement any positive delta beats the best.
+func beatsBestReward(config *MevConfig, reward, bestReward *big.Int) bool {
+	delta := new(big.Int).Sub(reward, bestReward)
+	if delta.Sign() <= 0 {
+		return false
+	}
+
+	margin := new(big.Int)
+	if config.MinBidImprovement != nil {
+		margin.Set(config.MinBidImprovement)
+	}
+	if config.MinBidImprovementBps > 0 {
+		bpsMargin := new(big.Int).Mul(bestReward, new(big.Int).SetUint64(config.MinBidImprovementBps))
+		if bpsMargin.Div(bpsMargin, big.NewInt(10000)); bpsMargin.Cmp(margin) > 0 {
+			margin = bpsMargin
+		}
+	}
+
+	return delta.Cmp(margin) >= 0
 }
 
-func (r *BidRuntime) isExpectedBetterThanSimulatingBid(simBid *BidRuntime) bool {
-	return r.expectedRewardFromBuilder().Cmp(simBid.expectedRewardFromBuilder()) > 0
+// uniqueBribeEOAs returns the configured bribe EOAs without the duplicates in their order, the
+// bribes to a duplicated EOA would be counted once per occurrence otherwise.
+func uniqueBribeEOAs(eoas []common.Address) []common.Address {
+	unique := make([]common.Address, 0, len(eoas))
+	for _, eoa := range eoas {
+		if slices.Contains(unique, eoa) {
+			log.Warn("BidSimulator: duplicated bribe EOA ignored, check ValidatorBribeEOAs", "eoa", eoa)
+			continue
+		}
+		unique = append(unique, eoa)
+	}
+
+	return unique
 }
 
-func (r *BidRuntime) isExpectedBetterThanBestBid(bestBid *BidRuntime) bool {
-	return r.expectedRewardFromBuilder().Cmp(bestBid.totalRewardFromBuilder()) > 0
+// validatorBribeEOAs returns the configured bribe EOAs of the validator and the payout address
+// rotated in for the block. The payout address is never the coinbase of the block, which stays
+// the signer, so the builders pay it directly like a bribe EOA.
+func (b *bidSimulator) validatorBribeEOAs(number uint64) []common.Address {
+	eoas := b.config.ValidatorBribeEOAs
+
+	payout := b.bidWorker.etherbaseForBlock(number)
+	if payout == (common.Address{}) || payout == b.bidWorker.etherbase() || slices.Contains(eoas, payout) {
+		return eoas
+	}
+
+	return append(slices.Clone(eoas), payout)
 }
 
-func (r *BidRuntime) checkValidatorBribe(acceptBribeEOAs []common.Address, tx *types.Transaction, receipt *types.Receipt) {
+// bribeBalances returns the balances of the bribe EOAs in the bid state,
+// it should be taken before each tx is committed.
+func (r *BidRuntime) bribeBalances(acceptBribeEOAs []common.Address) []*uint256.Int {
 	if len(acceptBribeEOAs) == 0 {
+		return nil
+	}
+
+	balances := make([]*uint256.Int, len(acceptBribeEOAs))
+	for i, acceptBribeEOA := range acceptBribeEOAs {
+		balances[i] = r.env.state.GetBalance(acceptBribeEOA).Clone()
+	}
+
+	return balances
+}
+
+// checkValidatorBribe accumulates the balance increase of the bribe EOAs caused by the committed tx,
+// so that the bribes paid via internal transfers of contracts are counted as well.
+func (r *BidRuntime) checkValidatorBribe(acceptBribeEOAs []common.Address, prevBalances []*uint256.Int, receipt *types.Receipt) {
+	if len(acceptBribeEOAs) == 0 || receipt.Status != types.ReceiptStatusSuccessful {
 		return
 	}
 
-	if to := tx.To(); to != nil && receipt.Status == types.ReceiptStatusSuccessful &&
-		tx.Value() != nil && tx.Value().Cmp(common.Big0) > 0 {
+	for i, acceptBribeEOA := range acceptBribeEOAs {
+		balance := r.env.state.GetBalance(acceptBribeEOA)
+		if balance.Cmp(prevBalances[i]) > 0 {
+			r.directBribe.Add(r.directBribe, new(uint256.Int).Sub(balance, prevBalances[i]).ToBig())
+		}
+	}
+}
 
-		for _, acceptBribeEOA := range acceptBribeEOAs {
-			if acceptBribeEOA == *to {
-				r.directBribe.Add(r.directBribe, tx.Value())
-				break
-			}
+// bribeEOAs returns the bribe EOAs of the validator but the senders of the bid txs. The balance
+// of a sender also changes by its own gas refund and the value flowing back to it, which must
+// not be credited as bribe, so the bribes to a sender are not counted at all.
+func (b *bidSimulator) bribeEOAs(bidRuntime *BidRuntime) []common.Address {
+	eoas := b.validatorBribeEOAs(bidRuntime.bid.BlockNumber)
+	if len(eoas) == 0 {
+		return nil
+	}
+
+	senders := make(map[common.Address]struct{})
+	for _, tx := range bidRuntime.bid.Txs {
+		if from, err := types.Sender(bidRuntime.env.signer, tx); err == nil {
+			senders[from] = struct{}{}
+		}
+	}
+
+	filtered := make([]common.Address, 0, len(eoas))
+	for _, eoa := range eoas {
+		if _, ok := senders[eoa]; !ok {
+			filtered = append(filtered, eoa)
+			continue
+		}
+
+		bidBribeSenderCounter.Inc(1)
+		// a bribe EOA sending txs is a misconfiguration, it is warned once
+		if _, warned := b.warnedBribeSenders.LoadOrStore(eoa, struct{}{}); !warned {
+			log.Warn("BidSimulator: bribe EOA sends txs in bids, its bribes are not counted, check ValidatorBribeEOAs",
+				"eoa", eoa, "builder", bidRuntime.bid.Builder, "bidHash", bidRuntime.bid.Hash().TerminalString())
 		}
 	}
+
+	return filtered
+}
+
+// checkPayment recomputes the realized reward after the payBidTx is committed, and checks
+// it only paid the builder fee out of the block reward and left the bribes untouched.
+func (r *BidRuntime) checkPayment(acceptBribeEOAs []common.Address, prePayReward *uint256.Int, prePayBribes []*uint256.Int) error {
+	var (
+		realized = r.env.state.GetBalance(consensus.SystemAddress).ToBig()
+		expected = new(big.Int).Sub(prePayReward.ToBig(), r.bid.BuilderFee)
+	)
+
+	if realized.Cmp(expected) < 0 {
+		return fmt.Errorf("payBidTx took more than the builder fee %s, block reward %s is less than expected %s",
+			weiToEtherStringF6(r.bid.BuilderFee), weiToEtherStringF6(realized), weiToEtherStringF6(expected))
+	}
+
+	for i, acceptBribeEOA := range acceptBribeEOAs {
+		if balance := r.env.state.GetBalance(acceptBribeEOA); balance.Cmp(prePayBribes[i]) < 0 {
+			return fmt.Errorf("payBidTx took %s from the bribe EOA %s",
+				weiToEtherStringF6(new(uint256.Int).Sub(prePayBribes[i], balance).ToBig()), acceptBribeEOA)
+		}
+	}
+
+	return nil
 }
 
 func (r *BidRuntime) directBribeBNB() *big.Int {
@@ -810,7 +2551,7 @@ func calcRewardAfterBEP95(preBEP95 *big.Int) *big.Int {
 	)
 }
 
-func (r *BidRuntime) commitTransaction(chain *core.BlockChain, chainConfig *params.ChainConfig, tx *types.Transaction, unRevertible bool) (*types.Receipt, error) {
+func (r *BidRuntime) commitTransaction(ctx context.Context, chain *core.BlockChain, chainConfig *params.ChainConfig, tx *types.Transaction, unRevertible bool) (*types.Receipt, error) {
 	var (
 		env = r.env
 		sc  *types.BlobSidecar
@@ -833,32 +2574,89 @@ func (r *BidRuntime) commitTransaction(chain *core.BlockChain, chainConfig *para
 		}
 	}
 
-	receipt, err := core.ApplyTransaction(chainConfig, chain, &env.coinbase, env.gasPool, env.state, env.header, tx,
+	// the tx failed to apply is rolled back, so the env is still usable without it. The reverted
+	// tx is finalised into the state and can not be rolled back by the snapshot.
+	var (
+		snap = env.state.Snapshot()
+		gp   = env.gasPool.Gas()
+	)
+
+	receipt, result, err := core.ApplyTransactionWithContext(ctx, chainConfig, chain, &env.coinbase, env.gasPool, env.state, env.header, tx,
 		&env.header.GasUsed, *chain.GetVMConfig(), core.NewReceiptBloomGenerator())
+	if errors.Is(err, core.ErrExecutionAborted) {
+		// the aborted tx may be finalised already, which invalidates the snapshot, so the state
+		// is left half-applied and the callers drop the env
+		return nil, err
+	}
 	if err != nil {
+		env.state.RevertToSnapshot(snap)
+		env.gasPool.SetGas(gp)
 		return nil, err
 	} else if unRevertible && receipt.Status == types.ReceiptStatusFailed {
-		return nil, errors.New("no revertible transaction failed")
+		return nil, newTxRevertedError(result)
 	}
 
 	if tx.Type() == types.BlobTxType {
+		txWithoutSidecar := tx.WithoutBlobTxSidecar()
+
 		sc.TxIndex = uint64(len(env.txs))
-		env.txs = append(env.txs, tx.WithoutBlobTxSidecar())
+		env.txs = append(env.txs, txWithoutSidecar)
 		env.receipts = append(env.receipts, receipt)
 		env.sidecars = append(env.sidecars, sc)
 		env.blobs += len(sc.Blobs)
 		*env.header.BlobGasUsed += receipt.BlobGasUsed
+
+		// the sidecars are stored apart from the block, but sent along with it
+		r.env.size += uint32(txWithoutSidecar.Size())
+		r.sidecarSize += uint32(tx.Size() - txWithoutSidecar.Size())
 	} else {
 		env.txs = append(env.txs, tx)
 		env.receipts = append(env.receipts, receipt)
+		r.env.size += uint32(tx.Size())
 	}
 
 	r.env.tcount++
-	r.env.size += uint32(tx.Size())
+	recordTouchedAccounts(env, chainConfig, tx)
 
 	return receipt, nil
 }
 
+// commitOptionalTransaction commits the optional tx of the bid. The tx failed or reverted is
+// rolled back and skipped with a nil receipt, the bid goes on without it.
+func (r *BidRuntime) commitOptionalTransaction(ctx context.Context, chain *core.BlockChain, chainConfig *params.ChainConfig, tx *types.Transaction) (*types.Receipt, error) {
+	// the reverted tx is finalised into the state, so the env is copied to roll it back, the
+	// snapshot of commitTransaction only covers the tx which fails to apply
+	var (
+		saved       = r.env.copy()
+		sidecarSize = r.sidecarSize
+	)
+
+	receipt, err := r.commitTransaction(ctx, chain, chainConfig, tx, false)
+	switch {
+	case err == nil && receipt.Status == types.ReceiptStatusSuccessful:
+		saved.discard()
+		return receipt, nil
+
+	case errors.Is(err, core.ErrExecutionAborted):
+		saved.discard()
+		return nil, err
+
+	case err != nil:
+		// rolled back by commitTransaction already, the copy is not needed
+		saved.discard()
+
+	default:
+		err = errors.New("optional tx reverted")
+		r.env.discard()
+		r.env, r.sidecarSize = saved, sidecarSize
+	}
+
+	bidOptionalSkipCounter.Inc(1)
+	log.Debug("BidSimulator: optional tx skipped", "bidHash", r.bid.Hash().TerminalString(), "tx", tx.Hash(), "err", err)
+
+	return nil, nil
+}
+
 func weiToEtherStringF6(wei *big.Int) string {
 	f, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(params.Ether)).Float64()
 	return strconv.FormatFloat(f, 'f', 6, 64)
@@ -0,0 +1,1076 @@
+package miner
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/miner/builderclient"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+var testBuilder = common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+// newTestBidSimulator creates a bid simulator without chain backend, whose newBidLoop is
+// replaced by a loop accepting every bid.
+func newTestBidSimulator(t *testing.T) *bidSimulator {
+	b := &bidSimulator{
+		config:        &DefaultMevConfig,
+		valuator:      rewardValuator{},
+		exitCh:        make(chan struct{}),
+		newBidCh:      make(chan newBidPackage, 100),
+		pending:       make(map[uint64]map[common.Address]map[common.Hash]struct{}),
+		pendingStats:  make(map[uint64]map[common.Address]*types.PendingBidStats),
+		simReply:      make(map[uint64]map[common.Hash]error),
+		bidStatuses:   make(map[uint64]map[common.Hash]*types.BidStatus),
+		bestBid:       make(map[common.Hash]*BidRuntime),
+		shadowBest:    make(map[common.Hash]*shadowBid),
+		backupBid:     make(map[common.Hash]*BidRuntime),
+		simulatingBid: make(map[common.Hash]*BidRuntime),
+		deferredBids:  make(map[common.Hash]*deferredBid),
+		decisions:     newBidDecisions(0),
+		obligations:   newBidObligations(),
+		selfImproves:  newSelfImprovements(),
+		issues:        newIssueQueue(0),
+		sealBidders:   newSealBidders(),
+		sealReportCh:  make(chan *types.BidSealedResult, sealReportChanSize),
+		simResults:    make(map[simResultKey]*BidRuntime),
+		bidResultCh:   make(chan types.BidResult, bidResultChanSize),
+		dryRunSem:     make(chan struct{}, maxConcurrentDryRuns),
+	}
+
+	go func() {
+		for {
+			select {
+			case newBid := <-b.newBidCh:
+				if newBid.feedback != nil {
+					newBid.feedback <- nil
+				}
+			case <-b.exitCh:
+				return
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		select {
+		case <-b.exitCh: // closed by the test
+		default:
+			close(b.exitCh)
+		}
+	})
+	b.start()
+
+	return b
+}
+
+func newTestBid(t *testing.T, blockNumber uint64, gasUsed uint64) *types.Bid {
+	args := &types.BidArgs{
+		RawBid: &types.RawBid{
+			BlockNumber: blockNumber,
+			ParentHash:  common.Hash{0x01},
+			GasUsed:     gasUsed,
+			GasFee:      big.NewInt(1),
+		},
+	}
+
+	bid, err := args.ToBid(testBuilder, types.LatestSignerForChainID(big.NewInt(1)))
+	if err != nil {
+		t.Fatalf("failed to create bid: %v", err)
+	}
+
+	return bid
+}
+
+func TestSendBidDuplicate(t *testing.T) {
+	b := newTestBidSimulator(t)
+	bid := newTestBid(t, 1, 21000)
+
+	if err := b.sendBid(context.Background(), bid); err != nil {
+		t.Fatalf("first bid rejected: %v", err)
+	}
+
+	if err := b.sendBid(context.Background(), bid); !errors.Is(err, types.ErrBidAlreadyExists) {
+		t.Fatalf("expected duplicate bid error, got %v", err)
+	}
+}
+
+func TestSendBidDuplicateSimulated(t *testing.T) {
+	b := newTestBidSimulator(t)
+	bid := newTestBid(t, 1, 21000)
+	failed := newTestBid(t, 1, 42000)
+
+	for _, sent := range []*types.Bid{bid, failed} {
+		if err := b.sendBid(context.Background(), sent); err != nil {
+			t.Fatalf("first bid rejected: %v", err)
+		}
+	}
+
+	b.cacheSimReply(bid, nil)
+	b.cacheSimReply(failed, errors.New("invalid payBidTx"))
+
+	// the resent bids get the cached replies
+	if err := b.sendBid(context.Background(), bid); err != nil {
+		t.Fatalf("expected cached acceptance, got %v", err)
+	}
+	if err := b.sendBid(context.Background(), failed); err == nil {
+		t.Fatalf("expected cached failure")
+	}
+
+	// the cache is only for the pending bids of the builder
+	if _, ok := b.CachedReply(1, common.Address{0x02}, bid.Hash()); ok {
+		t.Fatalf("unexpected cached reply of another builder")
+	}
+}
+
+func TestSendBidTooMany(t *testing.T) {
+	b := newTestBidSimulator(t)
+
+	for i := 0; i < maxBidPerBuilderPerBlock; i++ {
+		if err := b.sendBid(context.Background(), newTestBid(t, 1, uint64(21000+i))); err != nil {
+			t.Fatalf("bid %d rejected: %v", i, err)
+		}
+		if quota := b.BidQuota(1, testBuilder); quota == nil || quota.Remaining != maxBidPerBuilderPerBlock-i-1 {
+			t.Fatalf("unexpected quota %+v after bid %d", quota, i)
+		}
+	}
+
+	err := b.sendBid(context.Background(), newTestBid(t, 1, 42000))
+	if !errors.Is(err, types.ErrTooManyBids) {
+		t.Fatalf("expected too many bids error, got %v", err)
+	}
+	var rejection *types.BidRejectionError
+	if !errors.As(err, &rejection) || rejection.Quota == nil || rejection.Quota.Remaining != 0 || rejection.Quota.Max != maxBidPerBuilderPerBlock {
+		t.Fatalf("unexpected quota of the rejection %v", err)
+	}
+
+	// a released bid gives its slot back
+	b.RemovePending(1, testBuilder, newTestBid(t, 1, 21000).Hash())
+	if quota := b.BidQuota(1, testBuilder); quota == nil || quota.Remaining != 1 {
+		t.Fatalf("unexpected quota %+v after release", quota)
+	}
+
+	// the cap is per block
+	if err := b.sendBid(context.Background(), newTestBid(t, 2, 42000)); err != nil {
+		t.Fatalf("bid for next block rejected: %v", err)
+	}
+}
+
+func TestSendBidConcurrentDuplicate(t *testing.T) {
+	b := newTestBidSimulator(t)
+	bid := newTestBid(t, 1, 21000)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		accepted int
+	)
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := b.sendBid(context.Background(), bid); err == nil {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if accepted != 1 {
+		t.Fatalf("expected exactly one accepted bid, got %d", accepted)
+	}
+}
+
+func TestPublishBidResultNonBlocking(t *testing.T) {
+	b := newTestBidSimulator(t)
+	go b.bidResultLoop()
+
+	// the subscriber never reads
+	sub := b.SubscribeBidResults(make(chan types.BidResult))
+	defer sub.Unsubscribe()
+
+	bidRuntime := newBidRuntime(newTestBid(t, 1, 21000))
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 2*bidResultChanSize; i++ {
+			b.publishBidResult(bidRuntime, true, big.NewInt(1), time.Millisecond)
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("publishing bid results is blocked by the slow subscriber")
+	}
+}
+
+// TestReportIssueConcurrentBuilderChanges is meant to be run with -race.
+func TestReportIssueConcurrentBuilderChanges(t *testing.T) {
+	b := newTestBidSimulator(t)
+	b.builders = make(map[common.Address]*builderclient.Client)
+	b.builderHealth = make(map[common.Address]*endpointHealth)
+	b.stats = make(map[common.Address]*builderStats)
+	b.statsDirty = make(map[common.Address]struct{})
+
+	bidRuntime := newBidRuntime(newTestBid(t, 1, 21000))
+	bidRuntime.env = &environment{header: &types.Header{Number: big.NewInt(1)}}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_ = b.AddBuilder(testBuilder, "")
+			_ = b.RemoveBuilder(testBuilder)
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			b.reportIssue(bidRuntime, errors.New("simulation failed"))
+		}
+	}()
+
+	wg.Wait()
+}
+
+func TestCheckPayment(t *testing.T) {
+	var (
+		bribeEOA = common.HexToAddress("0x4000000000000000000000000000000000000004")
+		eoas     = []common.Address{bribeEOA}
+	)
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetBalance(consensus.SystemAddress, uint256.NewInt(1000))
+	statedb.SetBalance(bribeEOA, uint256.NewInt(500))
+
+	bid := newTestBid(t, 1, 21000)
+	bid.BuilderFee = big.NewInt(100)
+
+	bidRuntime := newBidRuntime(bid)
+	bidRuntime.env = &environment{state: statedb}
+
+	prePayReward := statedb.GetBalance(consensus.SystemAddress).Clone()
+	prePayBribes := bidRuntime.bribeBalances(eoas)
+
+	// the payBidTx pays the builder fee
+	statedb.SetBalance(consensus.SystemAddress, uint256.NewInt(900))
+	if err := bidRuntime.checkPayment(eoas, prePayReward, prePayBribes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the payBidTx takes more than the builder fee
+	statedb.SetBalance(consensus.SystemAddress, uint256.NewInt(899))
+	if err := bidRuntime.checkPayment(eoas, prePayReward, prePayBribes); err == nil {
+		t.Fatalf("expected error with overpaid builder fee")
+	}
+
+	// the payBidTx takes the bribe
+	statedb.SetBalance(consensus.SystemAddress, uint256.NewInt(900))
+	statedb.SetBalance(bribeEOA, uint256.NewInt(400))
+	if err := bidRuntime.checkPayment(eoas, prePayReward, prePayBribes); err == nil {
+		t.Fatalf("expected error with bribe taken")
+	}
+}
+
+// testMergeWorker is a bidWorker whose greedy merge is blocked until released.
+type testMergeWorker struct {
+	release chan struct{}
+}
+
+func (w *testMergeWorker) prepareWork(*generateParams) (*environment, error) { return nil, nil }
+
+func (w *testMergeWorker) etherbase() common.Address { return common.Address{} }
+
+func (w *testMergeWorker) etherbaseForBlock(uint64) common.Address { return common.Address{} }
+
+func (w *testMergeWorker) fillTransactions(_ chan int32, env *environment, _ *time.Timer, _ mapset.Set[common.Hash], _ int) error {
+	<-w.release
+	env.tcount++
+	return nil
+}
+
+func TestGreedyMergeAbandon(t *testing.T) {
+	var (
+		worker = &testMergeWorker{release: make(chan struct{})}
+		config = DefaultMevConfig
+	)
+	config.GreedyMergeMaxDuration = 10 * time.Millisecond
+
+	b := &bidSimulator{config: &config, bidWorker: worker}
+
+	newRuntime := func() *BidRuntime {
+		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		bidRuntime := newBidRuntime(newTestBid(t, 1, 21000))
+		bidRuntime.env = &environment{state: statedb, header: &types.Header{Number: big.NewInt(1)}, tcount: 1}
+		return bidRuntime
+	}
+
+	// the merge stuck over the budget is abandoned for the pre-merge env
+	bidRuntime := newRuntime()
+	mergeEnv := bidRuntime.env
+	b.greedyMerge(nil, bidRuntime)
+	if bidRuntime.env == mergeEnv || bidRuntime.env.tcount != 1 {
+		t.Fatalf("expected the merge to be abandoned")
+	}
+	close(worker.release)
+
+	// the merge finished in time is kept
+	bidRuntime = newRuntime()
+	mergeEnv = bidRuntime.env
+	b.greedyMerge(nil, bidRuntime)
+	if bidRuntime.env != mergeEnv || bidRuntime.env.tcount != 2 {
+		t.Fatalf("expected the merge to be kept")
+	}
+}
+
+func TestBeatsBestReward(t *testing.T) {
+	config := DefaultMevConfig
+
+	best := big.NewInt(100000)
+	if !beatsBestReward(&config, big.NewInt(100001), best) {
+		t.Fatalf("any positive delta should beat the best without minimum improvement")
+	}
+	if beatsBestReward(&config, big.NewInt(100000), best) {
+		t.Fatalf("equal reward should not beat the best")
+	}
+
+	// the larger of the absolute and the relative margin applies
+	config.MinBidImprovement = big.NewInt(50)
+	config.MinBidImprovementBps = 10 // 100 wei of the best
+	if beatsBestReward(&config, big.NewInt(100099), best) {
+		t.Fatalf("delta below the relative margin should not beat the best")
+	}
+	if !beatsBestReward(&config, big.NewInt(100100), best) {
+		t.Fatalf("delta of the relative margin should beat the best")
+	}
+
+	config.MinBidImprovement = big.NewInt(500)
+	if beatsBestReward(&config, big.NewInt(100499), best) {
+		t.Fatalf("delta below the absolute margin should not beat the best")
+	}
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	cli, err := newHTTPClient(&DefaultMevConfig)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if cli.Timeout != defaultRequestTimeout || cli.Transport.(*http.Transport).MaxConnsPerHost != defaultMaxConnsPerHost {
+		t.Fatalf("unexpected default client: timeout %v", cli.Timeout)
+	}
+
+	config := DefaultMevConfig
+	config.RequestTimeout, config.MaxConnsPerHost = 10*time.Second, 200
+	if cli, err = newHTTPClient(&config); err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if cli.Timeout != 10*time.Second || cli.Transport.(*http.Transport).MaxConnsPerHost != 200 {
+		t.Fatalf("unexpected configured client: timeout %v", cli.Timeout)
+	}
+}
+
+func TestBidIssueCode(t *testing.T) {
+	for err, want := range map[error]types.BidIssueCode{
+		newBidSimError(types.ErrCodeGasExceeded, errors.New("gas used exceeds gas limit")):             types.ErrCodeGasExceeded,
+		fmt.Errorf("wrapped, %w", newBidSimError(types.ErrCodeInvalidTx, errors.New("nonce too low"))): types.ErrCodeInvalidTx,
+		errBidSimulationTimeout: types.ErrCodeTimeout,
+		errBetterBidArrived:     types.ErrCodeAborted,
+		errNewHeadArrived:       types.ErrCodeAborted,
+		errSimMinerExit:         types.ErrCodeAborted,
+		errSimCanceled:          types.ErrCodeAborted,
+		errors.New("unknown"):   "",
+	} {
+		if code := bidIssueCode(err); code != want {
+			t.Errorf("%v: unexpected code %q, want %q", err, code, want)
+		}
+	}
+
+	// the message is kept for the builders reading it only
+	if err := newBidSimError(types.ErrCodeRewardTooLow, errors.New("reward does not achieve the expectation")); err.Error() != "reward does not achieve the expectation" {
+		t.Fatalf("unexpected message %q", err.Error())
+	}
+}
+
+// revertingCode is the runtime code reverting with Error("nope"), it copies the revert data
+// appended to the code into the memory and reverts with it.
+var revertingCode = append(common.FromHex("0x6064600c60003960646000fd"), revertData("nope")...)
+
+func revertData(reason string) []byte {
+	data := common.FromHex("0x08c379a0") // Error(string)
+	data = append(data, common.LeftPadBytes([]byte{0x20}, 32)...)
+	data = append(data, common.LeftPadBytes([]byte{byte(len(reason))}, 32)...)
+	return append(data, common.RightPadBytes([]byte(reason), 32)...)
+}
+
+func TestCommitRevertingUnRevertibleTx(t *testing.T) {
+	var (
+		reverter = common.HexToAddress("0x2000000000000000000000000000000000000002")
+		gspec    = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				testBankAddress: {Balance: testBankFunds},
+				reverter:        {Code: revertingCode},
+			},
+		}
+	)
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	parent := chain.CurrentBlock()
+	statedb, err := chain.StateAt(parent.Root)
+	if err != nil {
+		t.Fatalf("failed to get state: %v", err)
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   parent.GasLimit,
+		Time:       parent.Time + 1,
+		Difficulty: common.Big1,
+		BaseFee:    eip1559.CalcBaseFee(chain.Config(), parent),
+	}
+	r := &BidRuntime{env: &environment{
+		signer:   types.MakeSigner(chain.Config(), header.Number, header.Time),
+		state:    statedb,
+		header:   header,
+		coinbase: testUserAddress,
+		gasPool:  new(core.GasPool).AddGas(header.GasLimit),
+	}}
+
+	tx := types.MustSignNewTx(testBankKey, types.LatestSigner(chain.Config()), &types.LegacyTx{
+		To:       &reverter,
+		Gas:      100000,
+		GasPrice: big.NewInt(10 * params.InitialBaseFee),
+	})
+
+	// the unRevertible tx fails with the revert reason, and is not committed
+	_, err = r.commitTransaction(context.Background(), chain, chain.Config(), tx, true)
+	var revertErr *txRevertedError
+	if !errors.As(err, &revertErr) || revertErr.reason != "nope" {
+		t.Fatalf("unexpected error %v, want revert reason nope", err)
+	}
+	if r.env.tcount != 0 || len(r.env.txs) != 0 {
+		t.Fatalf("reverted unRevertible tx is committed")
+	}
+
+	// the revert reason and the position of the tx are reported to the builder
+	bid := newTestBid(t, 1, 0)
+	err = newBidSimError(types.ErrCodeInvalidTx, &bidTxError{index: 2, txHash: tx.Hash(), err: err})
+	issue := newBidIssue(common.Address{}, bid, err)
+	if issue.Code != types.ErrCodeInvalidTx || issue.RevertReason != "nope" {
+		t.Fatalf("unexpected issue code %q, revert reason %q", issue.Code, issue.RevertReason)
+	}
+	if issue.TxHash == nil || *issue.TxHash != tx.Hash() || issue.TxIndex == nil || *issue.TxIndex != 2 {
+		t.Fatalf("failed tx is not reported, hash %v index %v", issue.TxHash, issue.TxIndex)
+	}
+
+	// the issues without a failed tx leave the fields empty
+	issue = newBidIssue(common.Address{}, bid, newBidSimError(types.ErrCodeRewardTooLow, errors.New("reward does not achieve the expectation")))
+	if issue.TxHash != nil || issue.TxIndex != nil || issue.RevertReason != "" {
+		t.Fatalf("unexpected failed tx in issue %+v", issue)
+	}
+}
+
+// loopingCode is the runtime code jumping back to its start until it runs out of gas.
+var loopingCode = common.FromHex("0x5b600056")
+
+func TestCommitAbortedTx(t *testing.T) {
+	var (
+		looper = common.HexToAddress("0x3000000000000000000000000000000000000003")
+		gspec  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				testBankAddress: {Balance: testBankFunds},
+				looper:          {Code: loopingCode},
+			},
+		}
+	)
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	parent := chain.CurrentBlock()
+	statedb, err := chain.StateAt(parent.Root)
+	if err != nil {
+		t.Fatalf("failed to get state: %v", err)
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   parent.GasLimit,
+		Time:       parent.Time + 1,
+		Difficulty: common.Big1,
+		BaseFee:    eip1559.CalcBaseFee(chain.Config(), parent),
+	}
+	r := &BidRuntime{env: &environment{
+		signer:   types.MakeSigner(chain.Config(), header.Number, header.Time),
+		state:    statedb,
+		header:   header,
+		coinbase: testUserAddress,
+		gasPool:  new(core.GasPool).AddGas(header.GasLimit),
+	}}
+
+	tx := types.MustSignNewTx(testBankKey, types.LatestSigner(chain.Config()), &types.LegacyTx{
+		To:       &looper,
+		Gas:      header.GasLimit - params.SystemTxsGas,
+		GasPrice: big.NewInt(10 * params.InitialBaseFee),
+	})
+
+	// the tx is cancelled in the middle of the loop, after which its state is finalised, the
+	// abort must be returned instead of reverting to the invalidated snapshot
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = r.commitTransaction(ctx, chain, chain.Config(), tx, false); !errors.Is(err, core.ErrExecutionAborted) {
+		t.Fatalf("unexpected error %v, want %v", err, core.ErrExecutionAborted)
+	}
+	if r.env.tcount != 0 || len(r.env.txs) != 0 {
+		t.Fatalf("aborted tx is committed")
+	}
+}
+
+func TestSendBidRunningToggled(t *testing.T) {
+	b := &bidSimulator{
+		config:       &DefaultMevConfig,
+		exitCh:       make(chan struct{}),
+		newBidCh:     make(chan newBidPackage, 100),
+		pending:      make(map[uint64]map[common.Address]map[common.Hash]struct{}),
+		pendingStats: make(map[uint64]map[common.Address]*types.PendingBidStats),
+		simReply:     make(map[uint64]map[common.Hash]error),
+		bidStatuses:  make(map[uint64]map[common.Hash]*types.BidStatus),
+		decisions:    newBidDecisions(0),
+	}
+	defer close(b.exitCh)
+	bid := newTestBid(t, 1, 21000)
+
+	// the bid sent while the miner is stopped is rejected up front
+	if err := b.sendBid(context.Background(), bid); !errors.Is(err, types.ErrMevNotRunning) {
+		t.Fatalf("expected not running error, got %v", err)
+	}
+
+	// the miner is stopped after the bid is queued
+	b.start()
+	replyCh := make(chan error, 1)
+	go func() { replyCh <- b.sendBid(context.Background(), bid) }()
+
+	for len(b.newBidCh) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	b.stop()
+	go b.newBidLoop()
+
+	if err := <-replyCh; !errors.Is(err, types.ErrMevNotRunning) {
+		t.Fatalf("expected not running error, got %v", err)
+	}
+
+	// neither bid holds a pending slot
+	if err := b.CheckPending(bid.BlockNumber, bid.Builder, bid.Hash()); err != nil {
+		t.Fatalf("pending slot of the rejected bid is not released: %v", err)
+	}
+	if n := len(b.pending[bid.BlockNumber][bid.Builder]); n != 0 {
+		t.Fatalf("unexpected pending bids %d", n)
+	}
+}
+
+func TestDryRunBidBusy(t *testing.T) {
+	b := newTestBidSimulator(t)
+	for i := 0; i < maxConcurrentDryRuns; i++ {
+		b.dryRunSem <- struct{}{}
+	}
+
+	if _, err := b.DryRunBid(context.Background(), newTestBid(t, 1, 21000), nil); !errors.Is(err, types.ErrMevBusy) {
+		t.Fatalf("expected %v with the dry runs saturated, got %v", types.ErrMevBusy, err)
+	}
+}
+
+func TestBribeEOASender(t *testing.T) {
+	var (
+		honest = common.HexToAddress("0x3000000000000000000000000000000000000003")
+		funds  = new(big.Int).Mul(big.NewInt(10), big.NewInt(params.Ether))
+		gspec  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				testBankAddress: {Balance: funds},
+				testUserAddress: {Balance: funds},
+			},
+		}
+	)
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	parent := chain.CurrentBlock()
+	statedb, err := chain.StateAt(parent.Root)
+	if err != nil {
+		t.Fatalf("failed to get state: %v", err)
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   parent.GasLimit,
+		Time:       parent.Time + 1,
+		Difficulty: common.Big1,
+		BaseFee:    eip1559.CalcBaseFee(chain.Config(), parent),
+	}
+
+	var (
+		signer = types.LatestSigner(chain.Config())
+		gasTip = big.NewInt(10 * params.InitialBaseFee)
+		newTx  = func(key *ecdsa.PrivateKey, nonce uint64, to common.Address, value *big.Int) *types.Transaction {
+			return types.MustSignNewTx(key, signer, &types.LegacyTx{Nonce: nonce, To: &to, Gas: params.TxGas, GasPrice: gasTip, Value: value})
+		}
+		txs = []*types.Transaction{
+			newTx(testUserKey, 0, testBankAddress, big.NewInt(params.Ether)),                         // to the bribe EOA which is a sender
+			newTx(testBankKey, 0, testUserAddress, big.NewInt(1)),                                    // the misconfigured bribe EOA sends
+			newTx(testUserKey, 1, honest, new(big.Int).Mul(big.NewInt(2), big.NewInt(params.Ether))), // a real bribe
+		}
+		b = &bidSimulator{config: &MevConfig{ValidatorBribeEOAs: []common.Address{testBankAddress, honest}}, bidWorker: &testMergeWorker{}}
+		r = &BidRuntime{
+			bid: newTestBid(t, 1, 0),
+			env: &environment{
+				signer:   types.MakeSigner(chain.Config(), header.Number, header.Time),
+				state:    statedb,
+				header:   header,
+				coinbase: testUserAddress,
+				gasPool:  new(core.GasPool).AddGas(header.GasLimit),
+			},
+			directBribe: big.NewInt(0),
+		}
+	)
+
+	r.bid.Txs = txs
+
+	eoas := b.bribeEOAs(r)
+	if len(eoas) != 1 || eoas[0] != honest {
+		t.Fatalf("unexpected bribe EOAs %v, want only %v", eoas, honest)
+	}
+	if _, warned := b.warnedBribeSenders.Load(testBankAddress); !warned {
+		t.Fatalf("bribe EOA sending txs is not warned")
+	}
+
+	for _, tx := range txs {
+		balances := r.bribeBalances(eoas)
+		receipt, err := r.commitTransaction(context.Background(), chain, chain.Config(), tx, true)
+		if err != nil {
+			t.Fatalf("failed to commit tx: %v", err)
+		}
+		r.checkValidatorBribe(eoas, balances, receipt)
+	}
+
+	// only the transfer to the bribe EOA which doesn't send is credited
+	if want := new(big.Int).Mul(big.NewInt(2), big.NewInt(params.Ether)); r.directBribeBNB().Cmp(want) != 0 {
+		t.Fatalf("unexpected bribe %v, want %v", r.directBribeBNB(), want)
+	}
+}
+
+func TestClearBidsEarlyBlocks(t *testing.T) {
+	if horizon := pruneHorizon(5, 128); horizon != 0 {
+		t.Fatalf("unexpected prune horizon %d, want 0", horizon)
+	}
+	if horizon := pruneHorizon(200, 128); horizon != 72 {
+		t.Fatalf("unexpected prune horizon %d, want 72", horizon)
+	}
+
+	var (
+		b      = newTestBidSimulator(t)
+		sealed = common.Hash{0x04} // the parent of the head, block 5
+		head   = common.Hash{0x05}
+		newBid = func(blockNumber uint64) *BidRuntime {
+			bidRuntime := newBidRuntime(newTestBid(t, blockNumber, 21000))
+			bidRuntime.env = &environment{}
+			return bidRuntime
+		}
+	)
+	b.bestBid[sealed] = newBid(5)
+	b.bestBid[head] = newBid(6)
+	b.backupBid[head] = newBid(6)
+
+	b.clearBids(sealed, 5, pruneHorizon(5, 128))
+
+	if b.bestBid[sealed] != nil {
+		t.Fatal("best bid of the sealed parent is kept")
+	}
+	if b.bestBid[head] == nil || b.backupBid[head] == nil {
+		t.Fatal("bids of the current parent are pruned")
+	}
+}
+
+func TestSendBidQueueFull(t *testing.T) {
+	b := &bidSimulator{
+		config:       &DefaultMevConfig,
+		exitCh:       make(chan struct{}),
+		newBidCh:     make(chan newBidPackage, 1),
+		pending:      make(map[uint64]map[common.Address]map[common.Hash]struct{}),
+		pendingStats: make(map[uint64]map[common.Address]*types.PendingBidStats),
+		simReply:     make(map[uint64]map[common.Hash]error),
+		bidStatuses:  make(map[uint64]map[common.Hash]*types.BidStatus),
+		decisions:    newBidDecisions(0),
+	}
+	defer close(b.exitCh)
+	b.start()
+
+	b.newBidCh <- newBidPackage{bid: newTestBid(t, 1, 21000)}
+	if status := b.BidQueueStatus(); status.Depth != 1 || status.Capacity != 1 {
+		t.Fatalf("unexpected queue status %+v", status)
+	}
+
+	// the bid is rejected without waiting for the timeout, and holds no pending slot
+	bid := newTestBid(t, 1, 42000)
+	start := time.Now()
+	if err := b.sendBid(context.Background(), bid); !errors.Is(err, types.ErrMevBusy) {
+		t.Fatalf("expected busy error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("bid is rejected after %v", elapsed)
+	}
+	if err := b.CheckPending(bid.BlockNumber, bid.Builder, bid.Hash()); err != nil {
+		t.Fatalf("rejected bid holds a pending slot: %v", err)
+	}
+}
+
+// TestSealRacingHeadEvents is meant to be run with -race, the sealer acquires the best bid
+// while the head events replace and clear the best bids.
+func TestSealRacingHeadEvents(t *testing.T) {
+	var (
+		b          = newTestBidSimulator(t)
+		parentHash = common.Hash{0x01}
+		runtimes   = make(chan *BidRuntime, 1000)
+		done       = make(chan struct{})
+		wg         sync.WaitGroup
+	)
+	newRuntime := func(i int) *BidRuntime {
+		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		bidRuntime := newBidRuntime(newTestBid(t, 1, uint64(21000+i)))
+		bidRuntime.env = &environment{state: statedb, header: &types.Header{Number: big.NewInt(1)}}
+		runtimes <- bidRuntime
+		return bidRuntime
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			bestBid := b.GetBestBid(parentHash)
+			if bestBid == nil || !bestBid.Acquire() {
+				continue
+			}
+			_ = bestBid.env.state.GetBalance(testBuilder)
+			_ = bestBid.env.header.Number
+			bestBid.Release()
+		}
+	}()
+
+	for i := 0; i < cap(runtimes)/2; i++ {
+		b.SetBestBid(parentHash, newRuntime(2*i))
+		b.SetBestBid(parentHash, newRuntime(2*i+1))
+		b.clearBids(parentHash, 1, 0)
+	}
+	close(done)
+	wg.Wait()
+	close(runtimes)
+
+	// every bid replaced or cleared is discarded once the sealer released it
+	for bidRuntime := range runtimes {
+		bidRuntime.envMu.Lock()
+		if bidRuntime.envRefs != 0 || !bidRuntime.envDiscarded {
+			t.Fatalf("unexpected env of bid, refs %d discarded %v", bidRuntime.envRefs, bidRuntime.envDiscarded)
+		}
+		bidRuntime.envMu.Unlock()
+	}
+
+	// the discarded env can't be acquired by the sealer any more
+	bidRuntime := newBidRuntime(newTestBid(t, 1, 21000))
+	bidRuntime.discardEnv()
+	if bidRuntime.Acquire() {
+		t.Fatal("discarded env is acquired")
+	}
+}
+
+func TestDrainNewBidsOnExit(t *testing.T) {
+	b := &bidSimulator{
+		newBidCh:      make(chan newBidPackage, 3),
+		pending:       make(map[uint64]map[common.Address]map[common.Hash]struct{}),
+		pendingStats:  make(map[uint64]map[common.Address]*types.PendingBidStats),
+		bestBid:       make(map[common.Hash]*BidRuntime),
+		shadowBest:    make(map[common.Hash]*shadowBid),
+		backupBid:     make(map[common.Hash]*BidRuntime),
+		simulatingBid: make(map[common.Hash]*BidRuntime),
+	}
+
+	bid := newTestBid(t, 1, 21000)
+	if err := b.CheckAndAddPending(bid.BlockNumber, bid.Builder, bid.Hash()); err != nil {
+		t.Fatalf("failed to add pending bid: %v", err)
+	}
+	replyCh := make(chan error, 1)
+	b.newBidCh <- newBidPackage{bid: bid, feedback: replyCh}
+	b.newBidCh <- newBidPackage{bid: newTestBid(t, 1, 42000)} // recommitted, no feedback
+
+	b.drainNewBids()
+	if len(b.newBidCh) != 0 {
+		t.Fatalf("%d bids left in the queue", len(b.newBidCh))
+	}
+	if err := <-replyCh; !errors.Is(err, types.ErrMevShuttingDown) {
+		t.Fatalf("unexpected reply %v, want %v", err, types.ErrMevShuttingDown)
+	}
+	// the builder can resend the bid once the validator is back
+	if err := b.CheckPending(bid.BlockNumber, bid.Builder, bid.Hash()); err != nil {
+		t.Fatalf("pending slot of the queued bid is not released: %v", err)
+	}
+
+	bidRuntime := newBidRuntime(bid)
+	bidRuntime.env = &environment{}
+	b.bestBid[bid.ParentHash] = bidRuntime
+	b.simulatingBid[bid.ParentHash] = bidRuntime
+
+	b.discardBids()
+	if len(b.bestBid) != 0 || len(b.simulatingBid) != 0 || !bidRuntime.envDiscarded {
+		t.Fatal("remaining bids are not discarded")
+	}
+}
+
+func TestCheckTxGasPrice(t *testing.T) {
+	var (
+		to       = common.HexToAddress("0x2")
+		gwei     = big.NewInt(params.GWei)
+		legacyTx = func(price int64) *types.Transaction {
+			return types.NewTx(&types.LegacyTx{To: &to, Gas: 21000, GasPrice: new(big.Int).Mul(big.NewInt(price), gwei)})
+		}
+		dynamicTx = func(feeCap, tipCap int64) *types.Transaction {
+			return types.NewTx(&types.DynamicFeeTx{To: &to, Gas: 21000,
+				GasFeeCap: new(big.Int).Mul(big.NewInt(feeCap), gwei), GasTipCap: new(big.Int).Mul(big.NewInt(tipCap), gwei)})
+		}
+	)
+
+	if err := checkTxGasPrice(legacyTx(1), gwei, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := checkTxGasPrice(legacyTx(0), gwei, nil); !errors.Is(err, txpool.ErrUnderpriced) {
+		t.Fatalf("expected underpriced, got %v", err)
+	}
+	if err := checkTxGasPrice(legacyTx(0), nil, nil); err != nil {
+		t.Fatalf("unexpected error without minimum: %v", err)
+	}
+
+	// the effective price is the base fee plus the tip, capped by the fee cap
+	baseFee := new(big.Int).Mul(big.NewInt(1), gwei)
+	if err := checkTxGasPrice(dynamicTx(3, 1), new(big.Int).Mul(big.NewInt(2), gwei), baseFee); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := checkTxGasPrice(dynamicTx(3, 1), new(big.Int).Mul(big.NewInt(3), gwei), baseFee); !errors.Is(err, txpool.ErrUnderpriced) {
+		t.Fatalf("expected underpriced, got %v", err)
+	}
+	if err := checkTxGasPrice(dynamicTx(3, 3), nil, new(big.Int).Mul(big.NewInt(4), gwei)); !errors.Is(err, core.ErrFeeCapTooLow) {
+		t.Fatalf("expected fee cap too low, got %v", err)
+	}
+}
+
+func TestSimCtxErr(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := simCtxErr(canceled); !errors.Is(err, errSimCanceled) {
+		t.Fatalf("expected the canceled request, got %v", err)
+	}
+
+	expired, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-expired.Done()
+	if err := simCtxErr(expired); !errors.Is(err, errBidSimulationTimeout) {
+		t.Fatalf("expected the timeout, got %v", err)
+	}
+}
+
+func TestSimEndReason(t *testing.T) {
+	tests := []struct {
+		err    error
+		reason string
+	}{
+		{fmt.Errorf("wrapped: %w", errBetterBidArrived), "betterBidArrived"},
+		{errSimMinerExit, "minerExit"},
+		{simInterruptErr(commitInterruptBetterBid), "betterBidArrived"},
+		{simInterruptErr(commitInterruptNewHead), "newHeadArrived"},
+		{simInterruptErr(commitInterruptShutdown), "minerExit"},
+		{simInterruptErr(commitInterruptTimeout), string(types.ErrCodeTimeout)},
+		{errSimCanceled, "canceled"},
+		{errBidSimulationTimeout, string(types.ErrCodeTimeout)},
+		{newBidSimError(types.ErrCodeInvalidPayBidTx, errors.New("invalid")), string(types.ErrCodeInvalidPayBidTx)},
+		{errors.New("unknown"), "failed"},
+	}
+	for i, tt := range tests {
+		if reason := simEndReason(tt.err); reason != tt.reason {
+			t.Errorf("test %d: reason mismatch, want %s, got %s", i, tt.reason, reason)
+		}
+	}
+}
+
+func TestCheckFeeCeil(t *testing.T) {
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  types.GenesisAlloc{testBankAddress: {Balance: testBankFunds}},
+	}
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	var (
+		genesis = chain.CurrentBlock()
+		gasCeil = new(big.Int).Mul(new(big.Int).SetUint64(genesis.GasLimit), DefaultMevConfig.BidGasPriceCeil)
+		above   = func(ceil *big.Int) *big.Int { return new(big.Int).Add(ceil, common.Big1) }
+	)
+
+	tests := []struct {
+		name          string
+		noCeils       bool
+		parentHash    common.Hash
+		gasFee        *big.Int
+		nontaxableFee *big.Int
+		rejected      bool
+	}{
+		{name: "within", parentHash: genesis.Hash(), gasFee: gasCeil, nontaxableFee: DefaultMevConfig.NontaxableFeeCeil},
+		{name: "gas fee", parentHash: genesis.Hash(), gasFee: above(gasCeil), nontaxableFee: big.NewInt(0), rejected: true},
+		{name: "nontaxable fee", parentHash: genesis.Hash(), gasFee: big.NewInt(0), nontaxableFee: above(DefaultMevConfig.NontaxableFeeCeil), rejected: true},
+		{name: "nil parent", parentHash: common.Hash{0x01}, gasFee: above(gasCeil), nontaxableFee: big.NewInt(0)},
+		{name: "nil parent nontaxable fee", parentHash: common.Hash{0x01}, gasFee: big.NewInt(0), nontaxableFee: above(DefaultMevConfig.NontaxableFeeCeil), rejected: true},
+		{name: "nil config", noCeils: true, parentHash: genesis.Hash(), gasFee: above(gasCeil), nontaxableFee: above(DefaultMevConfig.NontaxableFeeCeil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultMevConfig
+			config.PenalizeInaccurateBuilders = true
+			if tt.noCeils {
+				config.BidGasPriceCeil, config.NontaxableFeeCeil = nil, nil
+			}
+			b := &bidSimulator{config: &config, chain: chain, stats: make(map[common.Address]*builderStats)}
+
+			err := b.checkFeeCeil(testBuilder, tt.parentHash, tt.gasFee, tt.nontaxableFee)
+			if !tt.rejected {
+				if err != nil {
+					t.Fatalf("unexpected rejection: %v", err)
+				}
+				if penalty := b.builderPenaltyBps(testBuilder); penalty != 0 {
+					t.Fatalf("unexpected penalty %d", penalty)
+				}
+				return
+			}
+
+			var codeErr interface{ ErrorCode() int }
+			if !errors.As(err, &codeErr) || codeErr.ErrorCode() != types.FeeTooHighError {
+				t.Fatalf("unexpected error %v, want the code %d", err, types.FeeTooHighError)
+			}
+			// the rejection is sampled as a declaration none of which held
+			if stats := b.BuilderStats(testBuilder); stats.FeeCeilRejections != 1 || stats.Accuracy != 0 {
+				t.Fatalf("unexpected stats %+v", stats)
+			}
+			if penalty := b.builderPenaltyBps(testBuilder); penalty != maxAccuracyPenaltyBps {
+				t.Fatalf("unexpected penalty %d", penalty)
+			}
+		})
+	}
+}
+
+func TestBribeEOADuplicated(t *testing.T) {
+	var (
+		bribe     = common.HexToAddress("0x3000000000000000000000000000000000000003")
+		forwarder = common.HexToAddress("0x4000000000000000000000000000000000000004")
+		gspec     = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				testBankAddress: {Balance: testBankFunds},
+				// forwards the value of the call to the bribe EOA
+				forwarder: {Code: append(append(common.FromHex("0x60006000600060003473"), bribe.Bytes()...), common.FromHex("0x5af100")...)},
+			},
+		}
+	)
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	eoas := uniqueBribeEOAs([]common.Address{bribe, forwarder, bribe})
+	if !slices.Equal(eoas, []common.Address{bribe, forwarder}) {
+		t.Fatalf("unexpected bribe EOAs %v", eoas)
+	}
+
+	parent := chain.CurrentBlock()
+	statedb, err := chain.StateAt(parent.Root)
+	if err != nil {
+		t.Fatalf("failed to get state: %v", err)
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   parent.GasLimit,
+		Time:       parent.Time + 1,
+		Difficulty: common.Big1,
+		BaseFee:    eip1559.CalcBaseFee(chain.Config(), parent),
+	}
+	r := &BidRuntime{
+		env: &environment{
+			signer:   types.MakeSigner(chain.Config(), header.Number, header.Time),
+			state:    statedb,
+			header:   header,
+			coinbase: testUserAddress,
+			gasPool:  new(core.GasPool).AddGas(header.GasLimit),
+		},
+		directBribe: big.NewInt(0),
+	}
+
+	tx := types.MustSignNewTx(testBankKey, types.LatestSigner(chain.Config()), &types.LegacyTx{
+		To:       &forwarder,
+		Value:    big.NewInt(params.Ether),
+		Gas:      100000,
+		GasPrice: big.NewInt(10 * params.InitialBaseFee),
+	})
+	balances := r.bribeBalances(eoas)
+	receipt, err := r.commitTransaction(context.Background(), chain, chain.Config(), tx, true)
+	if err != nil {
+		t.Fatalf("failed to commit tx: %v", err)
+	}
+	r.checkValidatorBribe(eoas, balances, receipt)
+
+	// the internal transfer to the bribe EOA is credited once
+	if want := big.NewInt(params.Ether); r.directBribeBNB().Cmp(want) != 0 {
+		t.Fatalf("unexpected bribe %v, want %v", r.directBribeBNB(), want)
+	}
+}
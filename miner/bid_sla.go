@@ -0,0 +1,240 @@
+package miner
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	bidOutcomeWin        = "win"
+	bidOutcomeLose       = "lose"
+	bidOutcomeFail       = "fail"
+	bidOutcomeUnresolved = "unresolved" // no outcome in time, the fault of the validator
+
+	// slaCheckInterval is the interval to force-resolve the overdue obligations
+	slaCheckInterval = 100 * time.Millisecond
+)
+
+var (
+	bidSLATrackedCounter = metrics.NewRegisteredCounter("bid/sla/tracked", nil)
+
+	// bidSLAUnresolvedCounter should be alerted on, it counts the accepted bids left without outcome
+	bidSLAUnresolvedCounter = metrics.NewRegisteredCounter("bid/sla/unresolved", nil)
+)
+
+// bidObligation is an accepted bid waiting for its outcome.
+type bidObligation struct {
+	bid      *types.Bid
+	deadline time.Time // the slot deadline plus BidOutcomeSLA
+}
+
+// bidObligations tracks the accepted bids until their outcomes are recorded, every bid
+// passed the intake must be resolved as win, lose or fail before its deadline, or it is
+// force-resolved as unresolved.
+type bidObligations struct {
+	mu     sync.Mutex
+	blocks map[uint64]map[common.Hash]*bidObligation // blockNumber -> bidHash -> obligation
+}
+
+func newBidObligations() *bidObligations {
+	return &bidObligations{blocks: make(map[uint64]map[common.Hash]*bidObligation)}
+}
+
+func (o *bidObligations) track(bid *types.Bid, deadline time.Time) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	block, ok := o.blocks[bid.BlockNumber]
+	if !ok {
+		block = make(map[common.Hash]*bidObligation)
+		o.blocks[bid.BlockNumber] = block
+	}
+
+	// a resent bid keeps the first obligation
+	if _, ok = block[bid.Hash()]; !ok {
+		block[bid.Hash()] = &bidObligation{bid: bid, deadline: deadline}
+	}
+}
+
+// resolve removes the obligation of the bid, nil if there is none.
+func (o *bidObligations) resolve(blockNumber uint64, bidHash common.Hash) *bidObligation {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	block := o.blocks[blockNumber]
+	ob, ok := block[bidHash]
+	if !ok {
+		return nil
+	}
+
+	delete(block, bidHash)
+	if len(block) == 0 {
+		delete(o.blocks, blockNumber)
+	}
+
+	return ob
+}
+
+// resolveUpTo removes the obligations of the blocks up to the number.
+func (o *bidObligations) resolveUpTo(number uint64) []*bidObligation {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var resolved []*bidObligation
+	for n, block := range o.blocks {
+		if n > number {
+			continue
+		}
+		for _, ob := range block {
+			resolved = append(resolved, ob)
+		}
+		delete(o.blocks, n)
+	}
+
+	return resolved
+}
+
+// expire removes the obligations past their deadlines.
+func (o *bidObligations) expire(now time.Time) []*bidObligation {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var expired []*bidObligation
+	for n, block := range o.blocks {
+		for hash, ob := range block {
+			if now.After(ob.deadline) {
+				expired = append(expired, ob)
+				delete(block, hash)
+			}
+		}
+		if len(block) == 0 {
+			delete(o.blocks, n)
+		}
+	}
+
+	return expired
+}
+
+func (o *bidObligations) len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var n int
+	for _, block := range o.blocks {
+		n += len(block)
+	}
+
+	return n
+}
+
+// trackObligation starts tracking the outcome of the accepted bid.
+func (b *bidSimulator) trackObligation(bid *types.Bid) {
+	if b.config.BidOutcomeSLA == 0 {
+		return
+	}
+
+	parent := b.chain.GetHeaderByHash(bid.ParentHash)
+	if parent == nil {
+		return
+	}
+
+	slotDeadline := time.Unix(int64(parent.Time+b.chainConfig.Parlia.Period), 0)
+	b.obligations.track(bid, slotDeadline.Add(b.config.BidOutcomeSLA))
+	bidSLATrackedCounter.Inc(1)
+}
+
+// resolveObligation records the outcome of the bid if it is tracked.
+func (b *bidSimulator) resolveObligation(bid *types.Bid, outcome string) {
+	if ob := b.obligations.resolve(bid.BlockNumber, bid.Hash()); ob != nil {
+		b.decisions.resolved(ob.bid, outcome)
+	}
+}
+
+// resolveSimulated resolves the bid failed the simulation, the bid interrupted by a newer bid
+// or block, or still being the best bid, waits for the seal.
+func (b *bidSimulator) resolveSimulated(bid *types.Bid, simErr error) {
+	if simErr == nil || isSimInterrupted(simErr) {
+		return
+	}
+
+	if best := b.GetBestBid(bid.ParentHash); best != nil && best.bid.Hash() == bid.Hash() {
+		return
+	}
+
+	b.resolveObligation(bid, bidOutcomeFail)
+}
+
+// resolveSealed resolves the bids of the sealed block, winner is nil if the block is built locally.
+func (b *bidSimulator) resolveSealed(number uint64, winner *types.Bid) {
+	for _, ob := range b.obligations.resolveUpTo(number) {
+		outcome := bidOutcomeLose
+		if winner != nil && ob.bid.Hash() == winner.Hash() {
+			outcome = bidOutcomeWin
+		}
+		b.decisions.resolved(ob.bid, outcome)
+	}
+}
+
+// resolveImported resolves the bids lost to the imported block of another validator, the bids
+// of the blocks sealed by the validator itself are resolved by OnBlockSealed.
+func (b *bidSimulator) resolveImported(block *types.Block) {
+	number := block.NumberU64()
+	if block.Coinbase() == b.bidWorker.etherbase() {
+		// left to OnBlockSealed, or to the next head if it is never called
+		number--
+	}
+
+	b.resolveSealed(number, nil)
+}
+
+// resolveOverdue force-resolves the obligations past their deadlines as unresolved, and
+// reports them to the builders.
+func (b *bidSimulator) resolveOverdue(now time.Time) {
+	for _, ob := range b.obligations.expire(now) {
+		bidSLAUnresolvedCounter.Inc(1)
+		b.decisions.resolved(ob.bid, bidOutcomeUnresolved)
+
+		log.Error("BidSimulator: bid outcome unresolved in time", "block", ob.bid.BlockNumber,
+			"builder", ob.bid.Builder, "bidHash", ob.bid.Hash().TerminalString())
+
+		b.reportUnresolved(ob.bid)
+	}
+}
+
+func (b *bidSimulator) reportUnresolved(bid *types.Bid) {
+	if cli, _ := b.GetBuilder(bid.Builder); cli == nil {
+		return
+	}
+
+	b.issues.push(&types.BidIssue{
+		Validator: b.bidWorker.etherbase(),
+		Builder:   bid.Builder,
+		BidHash:   bid.Hash(),
+		Message:   "bid outcome unresolved in time, validator fault",
+		Code:      types.ErrCodeUnresolved,
+	})
+}
+
+// slaLoop force-resolves the overdue obligations periodically.
+func (b *bidSimulator) slaLoop() {
+	if b.config.BidOutcomeSLA == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(slaCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			b.resolveOverdue(now)
+		case <-b.exitCh:
+			return
+		}
+	}
+}
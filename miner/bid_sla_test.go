@@ -0,0 +1,101 @@
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+)
+
+// TestBidObligationsExitPaths walks through the exit paths of an accepted bid, none of them
+// may leave the obligation dangling.
+func TestBidObligationsExitPaths(t *testing.T) {
+	b := newTestBidSimulator(t)
+	b.decisions = newBidDecisions(10)
+	b.bidWorker = &testMergeWorker{}
+
+	var (
+		deadline = time.Now().Add(time.Minute)
+		gasUsed  = uint64(21000)
+	)
+	newTrackedBid := func(blockNumber uint64) *types.Bid {
+		gasUsed++
+		bid := newTestBid(t, blockNumber, gasUsed)
+		b.obligations.track(bid, deadline)
+		return bid
+	}
+	newBlock := func(number uint64, coinbase common.Address) *types.Block {
+		return types.NewBlockWithHeader(&types.Header{Number: new(big.Int).SetUint64(number), Coinbase: coinbase})
+	}
+	expectOutcome := func(bid *types.Bid, want string) {
+		t.Helper()
+		outcome, err := b.decisions.explain(bid.BlockNumber, bid.Hash())
+		if err != nil {
+			t.Fatalf("no decision record: %v", err)
+		}
+		if outcome.Decision.Outcome != want {
+			t.Fatalf("unexpected outcome %q, want %q", outcome.Decision.Outcome, want)
+		}
+	}
+
+	// simBid exits as the bid simulator is stopped
+	stopped := newTrackedBid(1)
+	b.simBid(nil, newBidRuntime(stopped))
+	expectOutcome(stopped, bidOutcomeFail)
+
+	// the simulation fails
+	failed := newTrackedBid(1)
+	b.resolveSimulated(failed, errors.New("invalid tx in bid"))
+	expectOutcome(failed, bidOutcomeFail)
+
+	// the simulation is interrupted by a better bid, resolved once the block is sealed
+	interrupted := newTrackedBid(1)
+	b.resolveSimulated(interrupted, errBetterBidArrived)
+
+	// the recommit of the best bid fails, but it is still the best
+	best := newTrackedBid(1)
+	bestRuntime := newBidRuntime(best)
+	bestRuntime.env = &environment{}
+	bestRuntime.packedBlockRewardPreBEP95Final = uint256.NewInt(0)
+	b.SetBestBid(best.ParentHash, bestRuntime)
+	b.resolveSimulated(best, errBidSimulationTimeout)
+	if n := b.obligations.len(); n != 2 {
+		t.Fatalf("expected 2 pending obligations, got %d", n)
+	}
+
+	b.OnBlockSealed(newBlock(1, common.Address{}), bestRuntime, big.NewInt(0))
+	expectOutcome(interrupted, bidOutcomeLose)
+	expectOutcome(best, bidOutcomeWin)
+
+	// the local block is sealed
+	local := newTrackedBid(2)
+	b.OnBlockSealed(newBlock(2, common.Address{}), nil, big.NewInt(0))
+	expectOutcome(local, bidOutcomeLose)
+
+	// the block of the validator itself is left to OnBlockSealed, then the next head
+	own := newTrackedBid(3)
+	b.resolveImported(newBlock(3, common.Address{}))
+	if n := b.obligations.len(); n != 1 {
+		t.Fatalf("expected the obligation of the own block pending, got %d", n)
+	}
+
+	// another validator seals the block
+	imported := newTrackedBid(4)
+	b.resolveImported(newBlock(4, common.HexToAddress("0xbeef")))
+	expectOutcome(own, bidOutcomeLose)
+	expectOutcome(imported, bidOutcomeLose)
+
+	// nothing resolves the bid in time
+	overdue := newTestBid(t, 5, 21000)
+	b.obligations.track(overdue, time.Now().Add(-time.Millisecond))
+	b.resolveOverdue(time.Now())
+	expectOutcome(overdue, bidOutcomeUnresolved)
+
+	if n := b.obligations.len(); n != 0 {
+		t.Fatalf("%d obligations dangling", n)
+	}
+}
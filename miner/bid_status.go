@@ -0,0 +1,167 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var errBidStatusNotFound = errors.New("no pending bid of the builder")
+
+// recordBidStatus records the terminal status of the pending bid, it is kept until the bids
+// of the block are cleared.
+func (b *bidSimulator) recordBidStatus(bid *types.Bid, status string, err error) {
+	b.pendingMu.Lock()
+	defer b.pendingMu.Unlock()
+
+	b.recordBidStatusLocked(bid, status, err)
+}
+
+// recordBidStatusLocked must be called with pendingMu held.
+func (b *bidSimulator) recordBidStatusLocked(bid *types.Bid, status string, err error) {
+	if _, ok := b.bidStatuses[bid.BlockNumber]; !ok {
+		b.bidStatuses[bid.BlockNumber] = make(map[common.Hash]*types.BidStatus)
+	}
+
+	record := &types.BidStatus{BidHash: bid.Hash(), BlockNumber: bid.BlockNumber, Status: status}
+	if err != nil {
+		record.Reason = err.Error()
+	}
+	b.bidStatuses[bid.BlockNumber][record.BidHash] = record
+}
+
+// liveBidHashes returns the hashes of the bids in the simulation and the best bids, they are
+// read before pendingMu is taken.
+func (b *bidSimulator) liveBidHashes() (simulating, best map[common.Hash]struct{}) {
+	simulating, best = make(map[common.Hash]struct{}), make(map[common.Hash]struct{})
+
+	b.simBidMu.RLock()
+	for _, bidRuntime := range b.simulatingBid {
+		simulating[bidRuntime.bid.Hash()] = struct{}{}
+	}
+	b.simBidMu.RUnlock()
+
+	b.bestBidMu.RLock()
+	for _, bidRuntime := range b.bestBid {
+		best[bidRuntime.bid.Hash()] = struct{}{}
+	}
+	b.bestBidMu.RUnlock()
+
+	return simulating, best
+}
+
+// bidStatusLocked must be called with pendingMu held.
+func (b *bidSimulator) bidStatusLocked(blockNumber uint64, bidHash common.Hash, simulating, best map[common.Hash]struct{}) *types.BidStatus {
+	status := &types.BidStatus{BidHash: bidHash, BlockNumber: blockNumber, Status: types.BidStatusQueued}
+
+	if _, ok := simulating[bidHash]; ok {
+		status.Status = types.BidStatusSimulating
+	} else if _, ok = best[bidHash]; ok {
+		status.Status = types.BidStatusBest
+	} else if record, ok := b.bidStatuses[blockNumber][bidHash]; ok {
+		copied := *record
+		status = &copied
+	}
+
+	return status
+}
+
+// BidStatus returns the status of the pending bid of the builder.
+func (b *bidSimulator) BidStatus(blockNumber uint64, builder common.Address, bidHash common.Hash) (*types.BidStatus, error) {
+	simulating, best := b.liveBidHashes()
+
+	b.pendingMu.RLock()
+	defer b.pendingMu.RUnlock()
+
+	if _, ok := b.pending[blockNumber][builder][bidHash]; !ok {
+		return nil, errBidStatusNotFound
+	}
+
+	return b.bidStatusLocked(blockNumber, bidHash, simulating, best), nil
+}
+
+// PendingBids returns the snapshot of the pending bids of the block on top of the parent,
+// along with the simulating bid and the best bid of the parent.
+func (b *bidSimulator) PendingBids(args *types.PendingBidsArgs) (*types.PendingBids, error) {
+	var parent *types.Header
+	switch {
+	case args.ParentHash != nil:
+		parent = b.chain.GetHeaderByHash(*args.ParentHash)
+	case args.BlockNumber != nil && *args.BlockNumber > 0:
+		parent = b.chain.GetHeaderByNumber(uint64(*args.BlockNumber) - 1)
+	case args.BlockNumber == nil:
+		parent = b.chain.CurrentHeader()
+	}
+	if parent == nil {
+		return nil, fmt.Errorf("unknown parent of the block")
+	}
+
+	var (
+		blockNumber = parent.Number.Uint64() + 1
+		parentHash  = parent.Hash()
+
+		simulating, best = b.liveBidHashes()
+	)
+
+	result := &types.PendingBids{
+		BlockNumber: blockNumber,
+		ParentHash:  parentHash,
+		Bids:        make(map[common.Address][]*types.BidStatus),
+		BestBid:     b.BestBidInfo(parentHash),
+	}
+	if simulatingBid := b.GetSimulatingBid(parentHash); simulatingBid != nil {
+		hash := simulatingBid.bid.Hash()
+		result.SimulatingBid = &hash
+	}
+
+	b.pendingMu.RLock()
+	defer b.pendingMu.RUnlock()
+
+	for builder, bids := range b.pending[blockNumber] {
+		for bidHash := range bids {
+			result.Bids[builder] = append(result.Bids[builder], b.bidStatusLocked(blockNumber, bidHash, simulating, best))
+		}
+	}
+
+	return result, nil
+}
+
+// countPendingLocked counts the pending bid of the builder seen at the time.
+// countPendingLocked must be called with pendingMu held.
+func (b *bidSimulator) countPendingLocked(blockNumber uint64, builder common.Address, seen time.Time) {
+	stats, ok := b.pendingStats[blockNumber]
+	if !ok {
+		stats = make(map[common.Address]*types.PendingBidStats)
+		b.pendingStats[blockNumber] = stats
+	}
+
+	s, ok := stats[builder]
+	if !ok {
+		s = &types.PendingBidStats{Builder: builder, FirstSeen: seen}
+		stats[builder] = s
+	}
+	s.Count++
+	s.LastSeen = seen
+}
+
+// PendingStats returns the count and the first and the last seen time of the bids of each
+// builder for the block, ordered by the first seen time.
+func (b *bidSimulator) PendingStats(blockNumber uint64) []*types.PendingBidStats {
+	b.pendingMu.RLock()
+	defer b.pendingMu.RUnlock()
+
+	result := make([]*types.PendingBidStats, 0, len(b.pendingStats[blockNumber]))
+	for _, s := range b.pendingStats[blockNumber] {
+		cpy := *s
+		result = append(result, &cpy)
+	}
+	slices.SortFunc(result, func(a, b *types.PendingBidStats) int {
+		return a.FirstSeen.Compare(b.FirstSeen)
+	})
+
+	return result
+}
@@ -0,0 +1,93 @@
+package miner
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestBidStatus(t *testing.T) {
+	var (
+		b        = newTestBidSimulator(t)
+		bid      = newTestBid(t, 1, 21000)
+		failed   = newTestBid(t, 1, 42000)
+		rejected = newTestBid(t, 1, 63000)
+	)
+
+	for _, bid := range []*types.Bid{bid, failed, rejected} {
+		if err := b.CheckAndAddPending(bid.BlockNumber, bid.Builder, bid.Hash()); err != nil {
+			t.Fatalf("failed to add pending bid: %v", err)
+		}
+	}
+
+	expectStatus := func(bid *types.Bid, want string) {
+		t.Helper()
+		status, err := b.BidStatus(bid.BlockNumber, bid.Builder, bid.Hash())
+		if err != nil {
+			t.Fatalf("failed to get status: %v", err)
+		}
+		if status.Status != want {
+			t.Fatalf("unexpected status %q, want %q", status.Status, want)
+		}
+	}
+
+	expectStatus(bid, types.BidStatusQueued)
+
+	b.SetSimulatingBid(bid.ParentHash, newBidRuntime(bid))
+	expectStatus(bid, types.BidStatusSimulating)
+
+	b.RemoveSimulatingBid(bid.ParentHash)
+	b.cacheSimReply(bid, nil)
+	expectStatus(bid, types.BidStatusSimulated)
+
+	b.SetBestBid(bid.ParentHash, newBidRuntime(bid))
+	expectStatus(bid, types.BidStatusBest)
+
+	b.cacheSimReply(failed, errors.New("invalid tx"))
+	expectStatus(failed, types.BidStatusFailed)
+
+	b.recordBidStatus(rejected, types.BidStatusRejected, types.ErrMevNotInTurn)
+	expectStatus(rejected, types.BidStatusRejected)
+
+	// the bids of other builders are not revealed
+	if _, err := b.BidStatus(bid.BlockNumber, common.Address{0x01}, bid.Hash()); !errors.Is(err, errBidStatusNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	// the statuses are dropped with the bids of the block
+	b.clearBids(common.Hash{}, bid.BlockNumber, 0)
+	if _, err := b.BidStatus(bid.BlockNumber, bid.Builder, bid.Hash()); !errors.Is(err, errBidStatusNotFound) {
+		t.Fatalf("expected not found after clear, got %v", err)
+	}
+}
+
+func TestPendingStats(t *testing.T) {
+	var (
+		b     = newTestBidSimulator(t)
+		other = common.Address{0x02}
+		start = time.Now()
+	)
+
+	b.pendingMu.Lock()
+	b.countPendingLocked(1, testBuilder, start.Add(time.Second))
+	b.countPendingLocked(1, other, start)
+	b.countPendingLocked(1, testBuilder, start.Add(2*time.Second))
+	b.countPendingLocked(2, other, start)
+	b.pendingMu.Unlock()
+
+	stats := b.PendingStats(1)
+	if len(stats) != 2 || stats[0].Builder != other || stats[1].Builder != testBuilder {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if s := stats[1]; s.Count != 2 || !s.FirstSeen.Equal(start.Add(time.Second)) || !s.LastSeen.Equal(start.Add(2*time.Second)) {
+		t.Fatalf("unexpected stats of the builder: %+v", s)
+	}
+
+	b.clearBids(common.Hash{}, 1, 0)
+	if len(b.PendingStats(1)) != 0 || len(b.PendingStats(2)) != 1 {
+		t.Fatalf("stats of the block are not cleared")
+	}
+}
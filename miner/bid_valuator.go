@@ -0,0 +1,114 @@
+package miner
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// The built-in strategies to value the bids, selected by MevConfig.BidValuation.
+const (
+	BidValuationReward         = "reward"         // the reward to the validator, the default
+	BidValuationRewardMinusGas = "rewardMinusGas" // the reward minus the gas used at BidValuationGasPrice
+	BidValuationBribeWeighted  = "bribeWeighted"  // the block reward plus the direct bribes weighted by BribeWeightBps
+)
+
+// BidValuator values the bids to select the best one. ExpectedValue values the bid by its
+// declared fees before the simulation, RealizedValue values the simulated bid, only the txs of
+// the builder are counted if builderOnly is set, the mempool txs merged into the bid are not.
+type BidValuator interface {
+	ExpectedValue(bid *types.Bid) *big.Int
+	RealizedValue(r *BidRuntime, builderOnly bool) *big.Int
+}
+
+// newBidValuator returns the strategy selected by the config, the unknown one falls back to
+// the default.
+func newBidValuator(config *MevConfig, minGasPrice *big.Int) BidValuator {
+	switch config.BidValuation {
+	case "", BidValuationReward:
+		return rewardValuator{}
+	case BidValuationRewardMinusGas:
+		gasPrice := config.BidValuationGasPrice
+		if gasPrice == nil {
+			gasPrice = minGasPrice
+		}
+		if gasPrice == nil {
+			gasPrice = new(big.Int)
+		}
+		return &rewardMinusGasValuator{gasPrice: gasPrice}
+	case BidValuationBribeWeighted:
+		weightBps := config.BribeWeightBps
+		if weightBps == 0 {
+			weightBps = 10000
+		}
+		return &bribeWeightedValuator{weightBps: weightBps}
+	default:
+		log.Error("BidSimulator: unknown bid valuation, fall back to the reward", "valuation", config.BidValuation)
+		return rewardValuator{}
+	}
+}
+
+// rewardValuator values the bids by the reward to the validator.
+type rewardValuator struct{}
+
+func (rewardValuator) ExpectedValue(bid *types.Bid) *big.Int {
+	return new(big.Int).Add(calcRewardAfterBEP95(bid.GasFee), bid.NontaxableFee)
+}
+
+func (rewardValuator) RealizedValue(r *BidRuntime, builderOnly bool) *big.Int {
+	if builderOnly {
+		return r.totalRewardFromBuilder()
+	}
+	return r.totalReward()
+}
+
+// rewardMinusGasValuator values the bids by the reward minus the cost of the block space
+// they take, so a bid paying little more for much more gas doesn't win.
+type rewardMinusGasValuator struct {
+	gasPrice *big.Int
+}
+
+func (v *rewardMinusGasValuator) ExpectedValue(bid *types.Bid) *big.Int {
+	value := rewardValuator{}.ExpectedValue(bid)
+	return value.Sub(value, v.gasCost(bid.GasUsed))
+}
+
+func (v *rewardMinusGasValuator) RealizedValue(r *BidRuntime, builderOnly bool) *big.Int {
+	// the gas used by the builder txs is the declared one, the simulated one includes the merged txs
+	gasUsed := r.bid.GasUsed
+	if !builderOnly {
+		gasUsed = r.env.header.GasUsed
+	}
+
+	value := rewardValuator{}.RealizedValue(r, builderOnly)
+	return value.Sub(value, v.gasCost(gasUsed))
+}
+
+func (v *rewardMinusGasValuator) gasCost(gasUsed uint64) *big.Int {
+	return new(big.Int).Mul(v.gasPrice, new(big.Int).SetUint64(gasUsed))
+}
+
+// bribeWeightedValuator values the bids by the block reward plus the direct bribes weighted,
+// the bribes are not shared with the delegators like the block reward.
+type bribeWeightedValuator struct {
+	weightBps uint64
+}
+
+func (v *bribeWeightedValuator) ExpectedValue(bid *types.Bid) *big.Int {
+	value := calcRewardAfterBEP95(bid.GasFee)
+	return value.Add(value, v.weight(bid.NontaxableFee))
+}
+
+func (v *bribeWeightedValuator) RealizedValue(r *BidRuntime, builderOnly bool) *big.Int {
+	value := r.blockReward()
+	if builderOnly {
+		value = calcRewardAfterBEP95(r.packedBlockRewardPreBEP95Builder.ToBig())
+	}
+	return value.Add(value, v.weight(r.directBribeBNB()))
+}
+
+func (v *bribeWeightedValuator) weight(bribe *big.Int) *big.Int {
+	weighted := new(big.Int).Mul(bribe, new(big.Int).SetUint64(v.weightBps))
+	return weighted.Div(weighted, big.NewInt(10000))
+}
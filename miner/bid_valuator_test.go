@@ -0,0 +1,32 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestBidValuators(t *testing.T) {
+	bid := &types.Bid{GasUsed: 100, GasFee: big.NewInt(1000), NontaxableFee: big.NewInt(500)}
+
+	tests := []struct {
+		config MevConfig
+		want   int64
+	}{
+		{config: MevConfig{}, want: 990 + 500},
+		{config: MevConfig{BidValuation: BidValuationReward}, want: 990 + 500},
+		{config: MevConfig{BidValuation: BidValuationRewardMinusGas}, want: 990 + 500 - 100*2}, // the miner gas price
+		{config: MevConfig{BidValuation: BidValuationRewardMinusGas, BidValuationGasPrice: big.NewInt(1)}, want: 990 + 500 - 100},
+		{config: MevConfig{BidValuation: BidValuationBribeWeighted}, want: 990 + 500},
+		{config: MevConfig{BidValuation: BidValuationBribeWeighted, BribeWeightBps: 5000}, want: 990 + 250},
+		{config: MevConfig{BidValuation: "unknown"}, want: 990 + 500},
+	}
+
+	for i, tt := range tests {
+		v := newBidValuator(&tt.config, big.NewInt(2))
+		if have := v.ExpectedValue(bid); have.Cmp(big.NewInt(tt.want)) != 0 {
+			t.Errorf("test %d: unexpected value %v, want %d", i, have, tt.want)
+		}
+	}
+}
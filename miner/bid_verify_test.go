@@ -0,0 +1,138 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// divergingTestWorker prepares the replay env on a state differing from the simulated one.
+type divergingTestWorker struct {
+	chainTestWorker
+}
+
+func (w *divergingTestWorker) prepareWork(genParams *generateParams) (*environment, error) {
+	env, err := w.chainTestWorker.prepareWork(genParams)
+	if err != nil {
+		return nil, err
+	}
+	env.state.AddBalance(testUserAddress, uint256.NewInt(1))
+
+	return env, nil
+}
+
+// newTestVerifiedBid simulates a bid on top of the genesis in paranoid mode, it is the best bid
+// of the genesis.
+func newTestVerifiedBid(t *testing.T) (*bidSimulator, *BidRuntime) {
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  types.GenesisAlloc{testBankAddress: {Balance: testBankFunds}},
+	}
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	t.Cleanup(chain.Stop)
+
+	config := DefaultMevConfig
+	config.ParanoidMode = true
+	config.SimulateOutOfTurn = true
+
+	b := newTestBidSimulator(t)
+	b.config = &config
+	b.chain = chain
+	b.chainConfig = chain.Config()
+	b.engine = shadowTestEngine{ethash.NewFaker()}
+	b.bidWorker = &chainTestWorker{chain: chain}
+	b.stats = make(map[common.Address]*builderStats)
+	b.bidReceiving.Store(true)
+
+	var (
+		genesis = chain.CurrentBlock()
+		signer  = types.LatestSigner(chain.Config())
+		price   = big.NewInt(10 * params.InitialBaseFee)
+	)
+	bid := newTestBid(t, genesis.Number.Uint64()+1, 2*params.TxGas)
+	bid.ParentHash = genesis.Hash()
+	bid.BuilderFee = big.NewInt(0)
+	bid.Txs = types.Transactions{
+		types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: 0, To: &testUserAddress, Value: big.NewInt(1), Gas: params.TxGas, GasPrice: price}),
+		types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: 1, To: &testBuilder, Gas: params.TxGas, GasPrice: price}),
+	}
+
+	r := newBidRuntime(bid)
+	b.simBid(nil, r)
+	if best := b.GetBestBid(bid.ParentHash); best != r {
+		t.Fatalf("the bid is not the best bid: %v", best)
+	}
+
+	return b, r
+}
+
+func newTestVerifyWorker(b *bidSimulator) (*worker, *types.Header) {
+	w := &worker{
+		bidFetcher: b,
+		config:     &Config{Mev: *b.config, DelayLeftOver: 50 * time.Millisecond},
+	}
+	header := &types.Header{ParentHash: b.chain.CurrentBlock().Hash(), Number: big.NewInt(1), Time: uint64(time.Now().Add(2 * time.Second).Unix())}
+
+	return w, header
+}
+
+func TestVerifyBidAgrees(t *testing.T) {
+	b, r := newTestVerifiedBid(t)
+
+	if err := r.waitVerified(5 * time.Second); err != nil {
+		t.Fatalf("unexpected verification error: %v", err)
+	}
+
+	w, header := newTestVerifyWorker(b)
+	if sealed := w.verifiedBid(header, r, big.NewInt(0)); sealed != r {
+		t.Fatalf("expected the verified bid sealed, got %v", sealed)
+	}
+}
+
+func TestVerifyBidDiverges(t *testing.T) {
+	b, r := newTestVerifiedBid(t)
+	if err := r.waitVerified(5 * time.Second); err != nil {
+		t.Fatalf("unexpected verification error: %v", err)
+	}
+
+	// the replay runs on a state differing from the simulated one
+	b.bidWorker = &divergingTestWorker{chainTestWorker{chain: b.chain}}
+	b.startVerification(r, 0)
+	if err := r.waitVerified(5 * time.Second); err == nil || err == errBidNotVerified {
+		t.Fatalf("expected the replay to diverge, got %v", err)
+	}
+
+	// without a runner-up, the worker falls back to the local block
+	w, header := newTestVerifyWorker(b)
+	if sealed := w.verifiedBid(header, r, big.NewInt(0)); sealed != nil {
+		t.Fatalf("expected the local block sealed, got %v", sealed)
+	}
+}
+
+func TestVerifyBidTimeout(t *testing.T) {
+	b, r := newTestVerifiedBid(t)
+
+	// the verification never finishes, the bid is sealed unverified
+	r.verified = make(chan struct{})
+	w, header := newTestVerifyWorker(b)
+	w.config.DelayLeftOver = 2 * time.Second
+
+	if err := r.waitVerified(10 * time.Millisecond); err != errBidNotVerified {
+		t.Fatalf("unexpected error %v, want %v", err, errBidNotVerified)
+	}
+	if sealed := w.verifiedBid(header, r, big.NewInt(0)); sealed != r {
+		t.Fatalf("expected the bid sealed unverified, got %v", sealed)
+	}
+}
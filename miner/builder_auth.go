@@ -0,0 +1,133 @@
+package miner
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// EndpointAuth is the optional credentials of the sentry or a builder sitting behind an auth
+// proxy, either a bearer token or a basic auth, plus the custom headers of every request.
+type EndpointAuth struct {
+	BearerToken string            // The token sent as "Authorization: Bearer", empty means none
+	Username    string            // The user of the basic auth, empty means none
+	Password    string            // The password of the basic auth
+	Headers     map[string]string // The custom headers, e.g. an API key of the proxy
+}
+
+// String redacts the credentials and the header values, they must never be logged.
+func (a EndpointAuth) String() string {
+	var parts []string
+	switch {
+	case a.BearerToken != "":
+		parts = append(parts, "bearer=<redacted>")
+	case a.Username != "":
+		parts = append(parts, "basic=<redacted>")
+	}
+
+	names := make([]string, 0, len(a.Headers))
+	for name := range a.Headers {
+		names = append(names, http.CanonicalHeaderKey(name))
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		parts = append(parts, name+"=<redacted>")
+	}
+
+	return "{" + strings.Join(parts, " ") + "}"
+}
+
+// GoString redacts the credentials for %#v as well.
+func (a EndpointAuth) GoString() string {
+	return a.String()
+}
+
+// validate checks the credentials are not ambiguous.
+func (a *EndpointAuth) validate() error {
+	if a.BearerToken != "" && (a.Username != "" || a.Password != "") {
+		return errors.New("the bearer token and the basic auth are exclusive")
+	}
+	if a.Username == "" && a.Password != "" {
+		return errors.New("the basic auth password is set without the user")
+	}
+
+	for name := range a.Headers {
+		if name == "" {
+			return errors.New("empty header name")
+		}
+		// the header would be overwritten by the credentials or override them silently
+		if http.CanonicalHeaderKey(name) == "Authorization" && (a.BearerToken != "" || a.Username != "") {
+			return errors.New("the Authorization header conflicts with the credentials")
+		}
+	}
+
+	return nil
+}
+
+// clientOptions returns the rpc client options setting the credentials and headers, none if
+// the auth is empty.
+func (a *EndpointAuth) clientOptions() []rpc.ClientOption {
+	var opts []rpc.ClientOption
+
+	if len(a.Headers) > 0 {
+		headers := make(http.Header, len(a.Headers))
+		for name, value := range a.Headers {
+			headers.Set(name, value)
+		}
+		opts = append(opts, rpc.WithHeaders(headers))
+	}
+
+	var authorization string
+	switch {
+	case a.BearerToken != "":
+		authorization = "Bearer " + a.BearerToken
+	case a.Username != "":
+		authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(a.Username+":"+a.Password))
+	}
+	if authorization != "" {
+		opts = append(opts, rpc.WithHTTPAuth(func(h http.Header) error {
+			h.Set("Authorization", authorization)
+			return nil
+		}))
+	}
+
+	return opts
+}
+
+// validateAuth checks the credentials of the sentry and every configured builder.
+func validateAuth(config *MevConfig) error {
+	if err := config.SentryAuth.validate(); err != nil {
+		return fmt.Errorf("sentry: %w", err)
+	}
+	for _, v := range config.Builders {
+		if err := v.Auth.validate(); err != nil {
+			return fmt.Errorf("builder %s: %w", v.Address, err)
+		}
+	}
+
+	return nil
+}
+
+// dialOptions returns the options to dial an endpoint with, the shared http client carrying
+// the TLS config and the connection pool, and the credentials of the endpoint.
+func (b *bidSimulator) dialOptions(auth *EndpointAuth) []rpc.ClientOption {
+	return append([]rpc.ClientOption{rpc.WithHTTPClient(b.httpClient)}, auth.clientOptions()...)
+}
+
+// builderAuth returns the credentials configured for the builder, the builders added at runtime
+// are dialed without unless they are in the config as well.
+func (b *bidSimulator) builderAuth(builder common.Address) *EndpointAuth {
+	for i := range b.config.Builders {
+		if b.config.Builders[i].Address == builder {
+			return &b.config.Builders[i].Auth
+		}
+	}
+
+	return &EndpointAuth{}
+}
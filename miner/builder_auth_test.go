@@ -0,0 +1,73 @@
+package miner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/miner/builderclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestEndpointAuth(t *testing.T) {
+	for name, auth := range map[string]EndpointAuth{
+		"bearer and basic":   {BearerToken: "token", Username: "user"},
+		"password only":      {Password: "secret"},
+		"empty header":       {Headers: map[string]string{"": "value"}},
+		"conflicting header": {BearerToken: "token", Headers: map[string]string{"authorization": "other"}},
+	} {
+		if err := auth.validate(); err == nil {
+			t.Errorf("%s: expected the auth to be rejected", name)
+		}
+	}
+
+	auth := EndpointAuth{Username: "user", Password: "secret", Headers: map[string]string{"x-api-key": "apikey"}}
+	if err := auth.validate(); err != nil {
+		t.Fatalf("failed to validate auth: %v", err)
+	}
+	if s := auth.String(); strings.Contains(s, "user") || strings.Contains(s, "secret") || strings.Contains(s, "apikey") {
+		t.Fatalf("credentials are not redacted: %s", s)
+	}
+
+	// the proxy sees the credentials and the headers of the endpoint on every request
+	var got http.Header
+	rpcServer := rpc.NewServer()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		rpcServer.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	b := &bidSimulator{httpClient: &http.Client{}}
+	cli, err := builderclient.DialOptions(context.Background(), server.URL, b.dialOptions(&auth)...)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer cli.Close()
+
+	if err = cli.Ping(context.Background()); err != nil {
+		t.Fatalf("failed to ping: %v", err)
+	}
+	if user, password, ok := (&http.Request{Header: got}).BasicAuth(); !ok || user != "user" || password != "secret" {
+		t.Fatalf("unexpected basic auth %q %q", user, password)
+	}
+	if key := got.Get("X-Api-Key"); key != "apikey" {
+		t.Fatalf("unexpected custom header %q", key)
+	}
+
+	// unauthenticated by default
+	cli, err = builderclient.DialOptions(context.Background(), server.URL, b.dialOptions(&EndpointAuth{})...)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer cli.Close()
+
+	if err = cli.Ping(context.Background()); err != nil {
+		t.Fatalf("failed to ping: %v", err)
+	}
+	if authorization := got.Get("Authorization"); authorization != "" {
+		t.Fatalf("unexpected authorization %q", authorization)
+	}
+}
@@ -0,0 +1,307 @@
+package miner
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/miner/builderclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// healthCheckTimeout is the timeout of a single ping or dial of an endpoint
+	healthCheckTimeout = 3 * time.Second
+
+	// maxHealthCheckBackoff caps the interval to re-check a failed endpoint
+	maxHealthCheckBackoff = 10 * time.Minute
+)
+
+var (
+	sentryUpGauge = metrics.NewRegisteredGauge("bid/sentry/up", nil)
+
+	// sentryFailoverCounter counts the switches to another sentry as the current one is unreachable
+	sentryFailoverCounter = metrics.NewRegisteredCounter("bid/sentry/failover", nil)
+)
+
+func builderUpGaugeName(builder common.Address) string {
+	return fmt.Sprintf("bid/builder/up/%v", builder)
+}
+
+// endpointHealth is the connectivity status of the sentry or a builder, guarded by bidSimulator.healthMu.
+type endpointHealth struct {
+	url       string
+	viaSentry bool
+
+	healthy   bool
+	failures  uint64 // consecutive failures
+	lastCheck time.Time
+	lastErr   error
+	nextCheck time.Time
+}
+
+func (h *endpointHealth) update(now time.Time, interval time.Duration, err error) {
+	h.lastCheck, h.lastErr = now, err
+	if err == nil {
+		h.healthy, h.failures = true, 0
+		h.nextCheck = now.Add(interval)
+		return
+	}
+
+	h.healthy = false
+	h.failures++
+	h.nextCheck = now.Add(healthCheckBackoff(interval, h.failures))
+}
+
+func (h *endpointHealth) toTypes(builder common.Address) *types.BuilderHealth {
+	health := &types.BuilderHealth{
+		Builder:   builder,
+		ViaSentry: h.viaSentry,
+		Healthy:   h.healthy,
+		Failures:  h.failures,
+		LastCheck: h.lastCheck,
+	}
+	if h.lastErr != nil {
+		health.LastError = h.lastErr.Error()
+	}
+
+	return health
+}
+
+// healthCheckBackoff doubles the interval for each consecutive failure.
+func healthCheckBackoff(interval time.Duration, failures uint64) time.Duration {
+	backoff := interval
+	for i := uint64(1); i < failures && backoff < maxHealthCheckBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxHealthCheckBackoff {
+		backoff = maxHealthCheckBackoff
+	}
+
+	return backoff
+}
+
+// trackBuilderHealth starts the health check of the builder, the builder without
+// endpoint is not checked.
+func (b *bidSimulator) trackBuilderHealth(builder common.Address, url string, viaSentry bool) {
+	if url == "" && !viaSentry {
+		return
+	}
+
+	b.healthMu.Lock()
+	defer b.healthMu.Unlock()
+
+	// assume healthy until the first check
+	b.builderHealth[builder] = &endpointHealth{url: url, viaSentry: viaSentry, healthy: true}
+}
+
+// untrackBuilderHealth stops the health check of the builder.
+func (b *bidSimulator) untrackBuilderHealth(builder common.Address) {
+	b.healthMu.Lock()
+	delete(b.builderHealth, builder)
+	b.healthMu.Unlock()
+
+	metrics.Unregister(builderUpGaugeName(builder))
+}
+
+// BuilderHealth returns the connectivity status of the builders with endpoints.
+func (b *bidSimulator) BuilderHealth() []*types.BuilderHealth {
+	b.healthMu.Lock()
+	defer b.healthMu.Unlock()
+
+	health := make([]*types.BuilderHealth, 0, len(b.builderHealth))
+	for builder, h := range b.builderHealth {
+		health = append(health, h.toTypes(builder))
+	}
+
+	return health
+}
+
+// healthCheckLoop pings the sentry and the builders periodically, and re-dials the failed
+// ones with exponential backoff.
+func (b *bidSimulator) healthCheckLoop() {
+	interval := b.config.BuilderHealthCheckInterval
+	if interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.checkHealth(interval)
+		case <-b.exitCh:
+			return
+		}
+	}
+}
+
+func (b *bidSimulator) checkHealth(interval time.Duration) {
+	now := time.Now()
+
+	if len(b.sentryURLs()) > 0 {
+		b.checkSentryHealth(now, interval)
+	}
+
+	b.buildersMu.RLock()
+	builders := make(map[common.Address]*builderclient.Client, len(b.builders))
+	for builder, cli := range b.builders {
+		builders[builder] = cli
+	}
+	b.buildersMu.RUnlock()
+
+	for builder, cli := range builders {
+		b.healthMu.Lock()
+		h := b.builderHealth[builder]
+		if h == nil || now.Before(h.nextCheck) {
+			b.healthMu.Unlock()
+			continue
+		}
+		viaSentry, url := h.viaSentry, h.url
+		b.healthMu.Unlock()
+
+		var err error
+		if viaSentry {
+			// the builder is as healthy as the sentry
+			b.healthMu.Lock()
+			if b.sentryHealth != nil {
+				err = b.sentryHealth.lastErr
+			}
+			b.healthMu.Unlock()
+		} else {
+			var newCli *builderclient.Client
+			if newCli, err = pingOrRedial(cli, url, b.dialOptions(b.builderAuth(builder))); err == nil && newCli != cli {
+				b.buildersMu.Lock()
+				if _, ok := b.builders[builder]; ok {
+					b.builders[builder] = newCli
+				}
+				b.buildersMu.Unlock()
+				log.Info("BidSimulator: builder re-dialed", "builder", builder)
+			}
+		}
+
+		b.healthMu.Lock()
+		if h, ok := b.builderHealth[builder]; ok {
+			h.update(now, interval, err)
+			if err != nil {
+				log.Warn("BidSimulator: builder is unhealthy", "builder", builder, "failures", h.failures, "err", err)
+			}
+		}
+		b.healthMu.Unlock()
+
+		if err == nil {
+			metrics.GetOrRegisterGauge(builderUpGaugeName(builder), nil).Update(1)
+		} else {
+			metrics.GetOrRegisterGauge(builderUpGaugeName(builder), nil).Update(0)
+		}
+	}
+}
+
+// sentryURLs returns the urls of the sentries in the order to fail over, SentryURL first.
+func (b *bidSimulator) sentryURLs() []string {
+	urls := make([]string, 0, 1+len(b.config.SentryURLs))
+	for _, url := range append([]string{b.config.SentryURL}, b.config.SentryURLs...) {
+		if url != "" && !slices.Contains(urls, url) {
+			urls = append(urls, url)
+		}
+	}
+
+	return urls
+}
+
+// checkSentryHealth pings the sentry, and fails over to the first reachable one of the others
+// if it is unreachable. The sentry client of all the builders is replaced once it is re-dialed.
+func (b *bidSimulator) checkSentryHealth(now time.Time, interval time.Duration) {
+	urls := b.sentryURLs()
+
+	b.buildersMu.RLock()
+	sentryCli, url := b.sentryCli, b.sentryURL
+	b.buildersMu.RUnlock()
+	if url == "" {
+		url = urls[0]
+	}
+
+	b.healthMu.Lock()
+	if b.sentryHealth == nil {
+		b.sentryHealth = &endpointHealth{url: url}
+	}
+	due := !now.Before(b.sentryHealth.nextCheck)
+	b.healthMu.Unlock()
+
+	if !due {
+		return
+	}
+
+	opts := b.dialOptions(&b.config.SentryAuth)
+	newCli, err := pingOrRedial(sentryCli, url, opts)
+	if err != nil {
+		for _, other := range urls {
+			if other == url {
+				continue
+			}
+			if otherCli, otherErr := pingOrRedial(nil, other, opts); otherErr == nil {
+				sentryFailoverCounter.Inc(1)
+				log.Warn("BidSimulator: sentry failed over", "from", url, "to", other, "err", err)
+				newCli, url, err = otherCli, other, nil
+				break
+			}
+		}
+	}
+
+	if err == nil && newCli != sentryCli {
+		b.buildersMu.Lock()
+		b.sentryCli, b.sentryURL = newCli, url
+		// all the builders are reached through the sentry if it is configured
+		for builder := range b.builders {
+			b.builders[builder] = newCli
+		}
+		b.buildersMu.Unlock()
+		log.Info("BidSimulator: sentry re-dialed", "url", url)
+	}
+
+	b.healthMu.Lock()
+	b.sentryHealth.url = url
+	b.sentryHealth.update(now, interval, err)
+	if err != nil {
+		log.Warn("BidSimulator: sentry is unhealthy", "failures", b.sentryHealth.failures, "err", err)
+	}
+	b.healthMu.Unlock()
+
+	if err == nil {
+		sentryUpGauge.Update(1)
+	} else {
+		sentryUpGauge.Update(0)
+	}
+}
+
+// pingOrRedial pings the endpoint with the client, and re-dials it with the options if the
+// client is nil or the ping fails. The returned client is the one to use afterwards.
+func pingOrRedial(cli *builderclient.Client, url string, opts []rpc.ClientOption) (*builderclient.Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	if cli != nil {
+		if err := cli.Ping(ctx); err == nil {
+			return cli, nil
+		}
+	}
+
+	newCli, err := builderclient.DialOptions(ctx, url, opts...)
+	if err != nil {
+		return cli, err
+	}
+
+	if err = newCli.Ping(ctx); err != nil {
+		newCli.Close()
+		return cli, err
+	}
+
+	return newCli, nil
+}
@@ -0,0 +1,109 @@
+package miner
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/miner/builderclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestHealthCheckBackoff(t *testing.T) {
+	var (
+		h   = &endpointHealth{}
+		now = time.Now()
+		err = errors.New("connection refused")
+	)
+
+	for i, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		h.update(now, time.Second, err)
+		if got := h.nextCheck.Sub(now); got != want {
+			t.Fatalf("failure %d: unexpected backoff %v, want %v", i+1, got, want)
+		}
+	}
+	if h.healthy || h.failures != 3 {
+		t.Fatalf("unexpected health %+v", h)
+	}
+
+	if backoff := healthCheckBackoff(time.Minute, 100); backoff != maxHealthCheckBackoff {
+		t.Fatalf("backoff is not capped: %v", backoff)
+	}
+
+	h.update(now, time.Second, nil)
+	if !h.healthy || h.failures != 0 || h.nextCheck.Sub(now) != time.Second {
+		t.Fatalf("unexpected health after recovery %+v", h)
+	}
+}
+
+func TestRemoveBuilderStopsHealthCheck(t *testing.T) {
+	config := DefaultMevConfig
+	config.SentryURL = "http://sentry"
+
+	b := &bidSimulator{
+		config:        &config,
+		builders:      make(map[common.Address]*builderclient.Client),
+		urls:          make(map[common.Address]string),
+		builderHealth: make(map[common.Address]*endpointHealth),
+	}
+
+	if err := b.AddBuilder(testBuilder, ""); err != nil {
+		t.Fatalf("failed to add builder: %v", err)
+	}
+	if health := b.BuilderHealth(); len(health) != 1 || !health[0].ViaSentry {
+		t.Fatalf("unexpected health %+v", health)
+	}
+
+	_ = b.RemoveBuilder(testBuilder)
+	if health := b.BuilderHealth(); len(health) != 0 {
+		t.Fatalf("health check is not stopped: %+v", health)
+	}
+}
+
+func TestSentryFailover(t *testing.T) {
+	dead := httptest.NewServer(rpc.NewServer())
+	dead.Close()
+	live := httptest.NewServer(rpc.NewServer())
+	defer live.Close()
+
+	config := DefaultMevConfig
+	config.SentryURL = dead.URL
+	config.SentryURLs = []string{dead.URL, live.URL}
+
+	b := &bidSimulator{
+		config:        &config,
+		httpClient:    &http.Client{},
+		builders:      make(map[common.Address]*builderclient.Client),
+		urls:          make(map[common.Address]string),
+		builderHealth: make(map[common.Address]*endpointHealth),
+	}
+	if urls := b.sentryURLs(); len(urls) != 2 || urls[0] != dead.URL || urls[1] != live.URL {
+		t.Fatalf("unexpected sentry urls %v", urls)
+	}
+
+	// no sentry is dialed yet, the builder is registered without a client
+	if err := b.AddBuilder(testBuilder, ""); err != nil {
+		t.Fatalf("failed to add builder: %v", err)
+	}
+	if cli, ok := b.GetBuilder(testBuilder); !ok || cli != nil {
+		t.Fatalf("unexpected builder client %v, registered %v", cli, ok)
+	}
+	if builders := b.Builders(); len(builders) != 1 || !builders[0].ViaSentry || builders[0].Dialed {
+		t.Fatalf("unexpected builders %+v", builders)
+	}
+
+	b.checkHealth(time.Second)
+
+	if b.sentryCli == nil || b.sentryURL != live.URL {
+		t.Fatalf("sentry is not failed over, url %q", b.sentryURL)
+	}
+	if cli, _ := b.GetBuilder(testBuilder); cli != b.sentryCli {
+		t.Fatalf("sentry client is not attached to the builder")
+	}
+	if b.sentryHealth.url != live.URL || !b.sentryHealth.healthy {
+		t.Fatalf("unexpected sentry health %+v", b.sentryHealth)
+	}
+}
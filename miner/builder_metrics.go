@@ -0,0 +1,109 @@
+package miner
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	builderErrCounterPrefix  = "bid/err"
+	builderCeilCounterPrefix = "bid/ceil"
+	builderSimTimerPrefix    = "bid/sim/duration"
+
+	builderAccuracyGaugePrefix  = "bid/accuracy"  // in basis points, see builderStats.accuracy
+	builderRewardGapGaugePrefix = "bid/rewardgap" // in gwei, see builderStats.rewardGap
+
+	builderReceivedCounterPrefix = "bid/received"
+	builderSimulateCounterPrefix = "bid/simulate"
+	builderSimOkCounterPrefix    = "bid/sim/ok"
+	builderBestCounterPrefix     = "bid/best"
+	builderSealedCounterPrefix   = "bid/sealed"
+	builderRewardCounterPrefix   = "bid/reward" // in gwei, the wei overflows the counter
+)
+
+// builderCounterPrefixes are the counters registered along with the builder.
+var builderCounterPrefixes = []string{
+	builderReceivedCounterPrefix,
+	builderSimulateCounterPrefix,
+	builderSimOkCounterPrefix,
+	builderBestCounterPrefix,
+	builderSealedCounterPrefix,
+	builderRewardCounterPrefix,
+}
+
+// bidUnknownBuilderCounter aggregates the failures of the bids from the builders not in
+// the builder list, so spoofed addresses can't create metrics at will.
+var bidUnknownBuilderCounter = metrics.NewRegisteredCounter("bid/unknown", nil)
+
+func builderCounterName(prefix string, builder common.Address) string {
+	return fmt.Sprintf("%s/%v", prefix, builder)
+}
+
+// registerBuilderMetrics registers the counters of the builder, so the builders never winning
+// report zeros instead of missing.
+func registerBuilderMetrics(builder common.Address) {
+	for _, prefix := range builderCounterPrefixes {
+		metrics.GetOrRegisterCounter(builderCounterName(prefix, builder), nil)
+	}
+}
+
+// incBuilderCounter increases the counter of the builder, the counters are only created
+// for the builders in the builder list.
+func (b *bidSimulator) incBuilderCounter(prefix string, builder common.Address) {
+	if !b.ExistBuilder(builder) {
+		bidUnknownBuilderCounter.Inc(1)
+		return
+	}
+
+	metrics.GetOrRegisterCounter(builderCounterName(prefix, builder), nil).Inc(1)
+}
+
+// addBuilderReward adds the validator reward of the sealed bid to the reward counter of the
+// builder.
+func (b *bidSimulator) addBuilderReward(builder common.Address, reward *big.Int) {
+	if reward == nil || !b.ExistBuilder(builder) {
+		return
+	}
+
+	gwei := new(big.Int).Div(reward, big.NewInt(params.GWei))
+	if !gwei.IsInt64() {
+		return
+	}
+	metrics.GetOrRegisterCounter(builderCounterName(builderRewardCounterPrefix, builder), nil).Inc(gwei.Int64())
+}
+
+// updateBuilderTimer updates the timer of the builder since start, like the counters the timers
+// are only created for the builders in the builder list.
+func (b *bidSimulator) updateBuilderTimer(prefix string, builder common.Address, start time.Time) {
+	if !b.ExistBuilder(builder) {
+		return
+	}
+
+	metrics.GetOrRegisterTimer(builderCounterName(prefix, builder), nil).UpdateSince(start)
+}
+
+// updateBuilderGauge updates the gauge of the builder, like the counters the gauges are only
+// created for the builders in the builder list.
+func (b *bidSimulator) updateBuilderGauge(prefix string, builder common.Address, value int64) {
+	if !b.ExistBuilder(builder) {
+		return
+	}
+
+	metrics.GetOrRegisterGauge(builderCounterName(prefix, builder), nil).Update(value)
+}
+
+// unregisterBuilderMetrics removes the metrics of the builder from the registry.
+func unregisterBuilderMetrics(builder common.Address) {
+	for _, prefix := range []string{builderErrCounterPrefix, builderCeilCounterPrefix, builderSimTimerPrefix,
+		builderAccuracyGaugePrefix, builderRewardGapGaugePrefix} {
+		metrics.Unregister(builderCounterName(prefix, builder))
+	}
+	for _, prefix := range builderCounterPrefixes {
+		metrics.Unregister(builderCounterName(prefix, builder))
+	}
+}
@@ -0,0 +1,26 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+func TestBuilderMetricsLifecycle(t *testing.T) {
+	builder := common.HexToAddress("0x0b")
+
+	registerBuilderMetrics(builder)
+	for _, prefix := range builderCounterPrefixes {
+		if metrics.DefaultRegistry.Get(builderCounterName(prefix, builder)) == nil {
+			t.Fatalf("metric %s not registered", prefix)
+		}
+	}
+
+	unregisterBuilderMetrics(builder)
+	for _, prefix := range builderCounterPrefixes {
+		if metrics.DefaultRegistry.Get(builderCounterName(prefix, builder)) != nil {
+			t.Fatalf("metric %s not unregistered", prefix)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// defaultBuilderMuteCooldown is the time the bids of a failing builder are rejected if
+// BuilderMuteCooldown is not set
+const defaultBuilderMuteCooldown = time.Minute
+
+var (
+	// builderMuteCounter counts the builders muted for the consecutive simulation failures
+	builderMuteCounter = metrics.NewRegisteredCounter("bid/builder/mute", nil)
+
+	// builderMuteRejectCounter counts the bids rejected as their builders are muted
+	builderMuteRejectCounter = metrics.NewRegisteredCounter("bid/builder/mute/reject", nil)
+)
+
+// muteIfFailingLocked mutes the builder once its consecutive simulation failures reach
+// BuilderMuteFailures, a broken builder would otherwise keep interrupting the simulations
+// of the others. It must be called with statsMu held.
+func (b *bidSimulator) muteIfFailingLocked(builder common.Address, stats *builderStats) {
+	if b.config.BuilderMuteFailures == 0 || stats.consecutiveFailures < b.config.BuilderMuteFailures {
+		return
+	}
+
+	cooldown := b.config.BuilderMuteCooldown
+	if cooldown <= 0 {
+		cooldown = defaultBuilderMuteCooldown
+	}
+	stats.mutedUntil = time.Now().Add(cooldown)
+
+	builderMuteCounter.Inc(1)
+	log.Warn("BidSimulator: builder muted for consecutive simulation failures", "builder", builder,
+		"failures", stats.consecutiveFailures, "until", stats.mutedUntil)
+
+	// the builder has a clean slate once the cooldown is over
+	stats.consecutiveFailures = 0
+}
+
+// MuteBuilder rejects the bids of the builder for the duration, the builder is kept along with
+// its endpoint, unlike RemoveBuilder.
+func (b *bidSimulator) MuteBuilder(builder common.Address, duration time.Duration) error {
+	if duration <= 0 {
+		return errors.New("mute duration must be positive")
+	}
+
+	b.buildersMu.Lock()
+	defer b.buildersMu.Unlock()
+
+	if _, ok := b.builders[builder]; !ok {
+		return errors.New("builder is not registered")
+	}
+
+	until := time.Now().Add(duration)
+	b.mutedUntil[builder] = until
+	log.Info("BidSimulator: builder muted by the operator", "builder", builder, "until", until)
+
+	return nil
+}
+
+// UnmuteBuilder enables the builder muted by MuteBuilder or for the simulation failures at once.
+func (b *bidSimulator) UnmuteBuilder(builder common.Address) error {
+	b.buildersMu.Lock()
+	if _, ok := b.builders[builder]; !ok {
+		b.buildersMu.Unlock()
+		return errors.New("builder is not registered")
+	}
+	delete(b.mutedUntil, builder)
+	b.buildersMu.Unlock()
+
+	b.statsMu.Lock()
+	if stats, ok := b.stats[builder]; ok {
+		stats.mutedUntil = time.Time{}
+	}
+	b.statsMu.Unlock()
+
+	log.Info("BidSimulator: builder unmuted by the operator", "builder", builder)
+
+	return nil
+}
+
+// checkBuilderMuted rejects the bid of the muted builder, the builder is enabled again
+// once the mute or the cooldown is over.
+func (b *bidSimulator) checkBuilderMuted(builder common.Address) error {
+	b.buildersMu.RLock()
+	until, muted := b.mutedUntil[builder]
+	b.buildersMu.RUnlock()
+
+	if muted {
+		if time.Now().Before(until) {
+			builderMuteRejectCounter.Inc(1)
+			return types.NewInvalidBidError(fmt.Sprintf("builder is muted by the validator until %s",
+				until.Format(time.RFC3339)))
+		}
+
+		b.buildersMu.Lock()
+		if b.mutedUntil[builder].Equal(until) {
+			delete(b.mutedUntil, builder)
+			log.Info("BidSimulator: builder unmuted", "builder", builder)
+		}
+		b.buildersMu.Unlock()
+	}
+
+	b.statsMu.RLock()
+	stats, ok := b.stats[builder]
+	if !ok || stats.mutedUntil.IsZero() {
+		b.statsMu.RUnlock()
+		return nil
+	}
+	mutedUntil := stats.mutedUntil
+	b.statsMu.RUnlock()
+
+	if time.Now().Before(mutedUntil) {
+		builderMuteRejectCounter.Inc(1)
+		return types.NewInvalidBidError(fmt.Sprintf("builder is muted for consecutive simulation failures until %s",
+			mutedUntil.Format(time.RFC3339)))
+	}
+
+	b.statsMu.Lock()
+	if stats.mutedUntil.Equal(mutedUntil) {
+		stats.mutedUntil = time.Time{}
+		log.Info("BidSimulator: builder unmuted", "builder", builder)
+	}
+	b.statsMu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package miner
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// builderRegistryPrefix + builder address -> persistedBuilder
+var builderRegistryPrefix = []byte("mev-registry-")
+
+// persistedBuilder is a builder added at runtime.
+type persistedBuilder struct {
+	URL string
+}
+
+func builderRegistryKey(builder common.Address) []byte {
+	return append(append([]byte{}, builderRegistryPrefix...), builder.Bytes()...)
+}
+
+// persistBuilder records the builder added at runtime, so it survives restarts.
+func (b *bidSimulator) persistBuilder(builder common.Address, url string) {
+	if b.statsDB == nil {
+		return
+	}
+
+	enc, err := rlp.EncodeToBytes(&persistedBuilder{URL: url})
+	if err != nil {
+		log.Warn("BidSimulator: failed to encode builder", "builder", builder, "err", err)
+		return
+	}
+
+	if err = b.statsDB.Put(builderRegistryKey(builder), enc); err != nil {
+		log.Warn("BidSimulator: failed to persist builder", "builder", builder, "err", err)
+	}
+}
+
+func (b *bidSimulator) unpersistBuilder(builder common.Address) {
+	if b.statsDB == nil {
+		return
+	}
+
+	if err := b.statsDB.Delete(builderRegistryKey(builder)); err != nil {
+		log.Warn("BidSimulator: failed to delete persisted builder", "builder", builder, "err", err)
+	}
+}
+
+// loadBuilderRegistry adds the builders persisted before restart, the builders declared
+// in config take precedence over the persisted ones with the same address.
+func (b *bidSimulator) loadBuilderRegistry() {
+	if b.statsDB == nil {
+		return
+	}
+
+	declared := make(map[common.Address]struct{}, len(b.config.Builders))
+	for _, v := range b.config.Builders {
+		declared[v.Address] = struct{}{}
+	}
+
+	it := b.statsDB.NewIterator(builderRegistryPrefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		builder := common.BytesToAddress(it.Key()[len(builderRegistryPrefix):])
+		if _, ok := declared[builder]; ok {
+			continue
+		}
+
+		var persisted persistedBuilder
+		if err := rlp.DecodeBytes(it.Value(), &persisted); err != nil {
+			log.Warn("BidSimulator: failed to decode persisted builder", "builder", builder, "err", err)
+			continue
+		}
+
+		_ = b.addBuilder(builder, persisted.URL)
+	}
+}
@@ -0,0 +1,101 @@
+package miner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/miner/builderclient"
+)
+
+func TestBuilderRegistryPersistence(t *testing.T) {
+	var (
+		db       = memorydb.New()
+		declared = common.HexToAddress("0x2000000000000000000000000000000000000002")
+		removed  = common.HexToAddress("0x3000000000000000000000000000000000000003")
+	)
+
+	config := DefaultMevConfig
+	config.Builders = []BuilderConfig{{Address: declared}}
+
+	newSimulator := func() *bidSimulator {
+		return &bidSimulator{
+			config:   &config,
+			builders: make(map[common.Address]*builderclient.Client),
+			urls:     make(map[common.Address]string),
+			stats:    make(map[common.Address]*builderStats),
+
+			builderHealth: make(map[common.Address]*endpointHealth),
+			statsDB:       db,
+		}
+	}
+
+	b := newSimulator()
+	for _, builder := range []common.Address{testBuilder, declared, removed} {
+		if err := b.AddBuilder(builder, ""); err != nil {
+			t.Fatalf("failed to add builder: %v", err)
+		}
+	}
+	_ = b.RemoveBuilder(removed)
+
+	// restart
+	b = newSimulator()
+	if err := b.dialSentryAndBuilders(); err != nil {
+		t.Fatalf("failed to dial builders: %v", err)
+	}
+
+	if !b.ExistBuilder(testBuilder) || !b.ExistBuilder(declared) {
+		t.Fatalf("builders are not restored")
+	}
+	if b.ExistBuilder(removed) {
+		t.Fatalf("removed builder is restored")
+	}
+	if len(b.builders) != 2 {
+		t.Fatalf("expected 2 builders, got %d", len(b.builders))
+	}
+}
+
+func TestBuilders(t *testing.T) {
+	var (
+		dedicated = common.HexToAddress("0x2000000000000000000000000000000000000002")
+		noURL     = common.HexToAddress("0x3000000000000000000000000000000000000003")
+	)
+
+	b := &bidSimulator{
+		config:        &DefaultMevConfig,
+		builders:      make(map[common.Address]*builderclient.Client),
+		urls:          make(map[common.Address]string),
+		builderHealth: make(map[common.Address]*endpointHealth),
+	}
+
+	// dialing http endpoints doesn't connect
+	_ = b.AddBuilder(dedicated, "http://builder")
+	_ = b.AddBuilder(noURL, "")
+
+	sentryCli, err := builderclient.DialOptions(context.Background(), "http://sentry")
+	if err != nil {
+		t.Fatalf("failed to dial sentry: %v", err)
+	}
+	b.sentryCli = sentryCli
+	_ = b.AddBuilder(testBuilder, "")
+
+	builders := b.Builders()
+	if len(builders) != 3 {
+		t.Fatalf("expected 3 builders, got %d", len(builders))
+	}
+	if info := builders[0]; info.Address != testBuilder || !info.ViaSentry || !info.Dialed {
+		t.Fatalf("unexpected sentry builder %+v", info)
+	}
+	if info := builders[1]; info.Address != dedicated || info.ViaSentry || !info.Dialed || info.URL != "http://builder" {
+		t.Fatalf("unexpected dedicated builder %+v", info)
+	}
+	if info := builders[2]; info.Address != noURL || info.ViaSentry || info.Dialed || info.URL != "" {
+		t.Fatalf("unexpected builder without endpoint %+v", info)
+	}
+
+	_ = b.RemoveBuilder(dedicated)
+	if builders = b.Builders(); len(builders) != 2 || builders[1].Address != noURL {
+		t.Fatalf("removed builder is listed: %+v", builders)
+	}
+}
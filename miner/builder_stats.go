@@ -0,0 +1,396 @@
+package miner
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	// statsEWMAAlpha is the weight of the newest sample in the moving averages of builder stats
+	statsEWMAAlpha = 0.2
+
+	// maxFailurePenaltyBps caps the discount of the expected reward of a failing builder, in basis points
+	maxFailurePenaltyBps = 9000
+
+	// maxAccuracyPenaltyBps caps the discount of the expected reward of an inaccurate builder, in basis points
+	maxAccuracyPenaltyBps = 9000
+
+	// builderStatsPersistInterval is the interval to flush the changed builder stats into the store
+	builderStatsPersistInterval = 10 * time.Second
+)
+
+// builderStatsPrefix + builder address -> persistedBuilderStats
+var builderStatsPrefix = []byte("mev-builder-")
+
+// persistedBuilderStats is the part of builder stats kept across restarts.
+type persistedBuilderStats struct {
+	Accepted      uint64
+	Errors        uint64
+	LastSeenBlock uint64
+}
+
+func builderStatsKey(builder common.Address) []byte {
+	return append(append([]byte{}, builderStatsPrefix...), builder.Bytes()...)
+}
+
+// ewma returns the exponentially weighted moving average updated by the sample.
+func ewma(avg, sample float64, initialized bool) float64 {
+	if !initialized {
+		return sample
+	}
+	return avg + statsEWMAAlpha*(sample-avg)
+}
+
+// builderStats is the runtime statistics of a builder, guarded by bidSimulator.statsMu.
+type builderStats struct {
+	clockSkew   float64 // EWMA of receive time minus bid timestamp, in nanoseconds
+	skewSamples uint64
+
+	simDuration float64 // EWMA of the simulation duration, in nanoseconds
+	failureRate float64 // EWMA of the simulation failures, 1 for a failed one and 0 for a succeeded one
+	simSamples  uint64
+
+	accuracy           float64 // EWMA of the share of the declared reward and gas used realized by the simulations, in [0, 1]
+	rewardGap          float64 // EWMA of the declared reward minus the realized one of the builder txs, in wei
+	declarationSamples uint64
+	feeCeilRejections  uint64 // the bids rejected for declaring fees over the ceiling, sampled at 0 accuracy
+
+	consecutiveFailures uint64    // the simulations failed since the last succeeded one
+	mutedUntil          time.Time // the bids of the builder are rejected until then, see BuilderMuteFailures
+
+	persistedBuilderStats
+}
+
+func (s *builderStats) toTypes(builder common.Address) *types.BuilderStats {
+	stats := &types.BuilderStats{
+		Builder:             builder,
+		ClockSkew:           time.Duration(s.clockSkew),
+		Accepted:            s.Accepted,
+		Errors:              s.Errors,
+		LastSeenBlock:       s.LastSeenBlock,
+		SimDuration:         time.Duration(s.simDuration),
+		FailureRate:         s.failureRate,
+		Accuracy:            1,
+		FeeCeilRejections:   s.feeCeilRejections,
+		ConsecutiveFailures: s.consecutiveFailures,
+	}
+	if s.declarationSamples > 0 {
+		stats.Accuracy = s.accuracy
+		stats.RewardGap, _ = big.NewFloat(s.rewardGap).Int(nil)
+	}
+	stats.Reputation = (1 - s.failureRate) * stats.Accuracy
+	if time.Now().Before(s.mutedUntil) {
+		mutedUntil := s.mutedUntil
+		stats.MutedUntil = &mutedUntil
+	}
+
+	return stats
+}
+
+// getOrNewStats must be called with statsMu held.
+func (b *bidSimulator) getOrNewStats(builder common.Address) *builderStats {
+	stats, ok := b.stats[builder]
+	if !ok {
+		stats = &builderStats{}
+		b.stats[builder] = stats
+	}
+	return stats
+}
+
+// BuilderStats returns the snapshot of the runtime statistics of the builder, nil if unknown.
+func (b *bidSimulator) BuilderStats(builder common.Address) *types.BuilderStats {
+	b.statsMu.RLock()
+	defer b.statsMu.RUnlock()
+
+	stats, ok := b.stats[builder]
+	if !ok {
+		return nil
+	}
+
+	return stats.toTypes(builder)
+}
+
+// BuilderStatsSnapshot returns the snapshot of the runtime statistics of all the known builders.
+func (b *bidSimulator) BuilderStatsSnapshot() []*types.BuilderStats {
+	b.statsMu.RLock()
+	defer b.statsMu.RUnlock()
+
+	snapshot := make([]*types.BuilderStats, 0, len(b.stats))
+	for builder, stats := range b.stats {
+		snapshot = append(snapshot, stats.toTypes(builder))
+	}
+
+	return snapshot
+}
+
+// recordAccepted records a bid of the builder is accepted into simulation.
+func (b *bidSimulator) recordAccepted(builder common.Address, blockNumber uint64) {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	stats := b.getOrNewStats(builder)
+	stats.Accepted++
+	if blockNumber > stats.LastSeenBlock {
+		stats.LastSeenBlock = blockNumber
+	}
+	b.statsDirty[builder] = struct{}{}
+}
+
+// recordError records a bid of the builder failed in simulation.
+func (b *bidSimulator) recordError(builder common.Address) {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	b.getOrNewStats(builder).Errors++
+	b.statsDirty[builder] = struct{}{}
+}
+
+// recordSimResult records the outcome of a finished simulation of the builder's bid,
+// interrupted simulations should not be recorded, the timed out ones go to recordSimTimeout.
+func (b *bidSimulator) recordSimResult(builder common.Address, duration time.Duration, failed bool) {
+	var sample float64
+	if failed {
+		sample = 1
+	}
+
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	stats := b.getOrNewStats(builder)
+	stats.updateSimLocked(duration, sample)
+
+	if !failed {
+		stats.consecutiveFailures = 0
+		return
+	}
+	stats.consecutiveFailures++
+	b.muteIfFailingLocked(builder, stats)
+}
+
+// recordDeclaration records how the declarations of the builder's bid held in its simulation:
+// the accuracy, see BidRuntime.declarationAccuracy, and the gap between the expected reward and
+// the realized one. The gap compares the builder txs only, the merged txs are not the builder's.
+func (b *bidSimulator) recordDeclaration(builder common.Address, r *BidRuntime) {
+	var (
+		accuracy = r.declarationAccuracy()
+		gap, _   = new(big.Float).SetInt(new(big.Int).Sub(r.expectedRewardFromBuilder(), r.totalRewardFromBuilder())).Float64()
+	)
+
+	b.statsMu.Lock()
+	stats := b.getOrNewStats(builder)
+	stats.accuracy = ewma(stats.accuracy, accuracy, stats.declarationSamples > 0)
+	stats.rewardGap = ewma(stats.rewardGap, gap, stats.declarationSamples > 0)
+	stats.declarationSamples++
+	accuracy, gap = stats.accuracy, stats.rewardGap
+	b.statsMu.Unlock()
+
+	b.updateBuilderGauge(builderAccuracyGaugePrefix, builder, int64(math.Round(accuracy*10000)))
+	b.updateBuilderGauge(builderRewardGapGaugePrefix, builder, int64(gap/params.GWei))
+}
+
+// recordFeeCeilRejection records a bid of the builder rejected for declaring fees over the
+// ceiling, as a declaration none of which held, so the rejections feed the accuracy penalty.
+func (b *bidSimulator) recordFeeCeilRejection(builder common.Address) {
+	b.statsMu.Lock()
+	stats := b.getOrNewStats(builder)
+	stats.accuracy = ewma(stats.accuracy, 0, stats.declarationSamples > 0)
+	stats.declarationSamples++
+	stats.feeCeilRejections++
+	accuracy := stats.accuracy
+	b.statsMu.Unlock()
+
+	b.updateBuilderGauge(builderAccuracyGaugePrefix, builder, int64(math.Round(accuracy*10000)))
+}
+
+// builderPenaltyBps returns the discount of the expected reward of the builder's bids in basis
+// points, for both its failures and its inaccurate declarations.
+func (b *bidSimulator) builderPenaltyBps(builder common.Address) uint64 {
+	var (
+		failure  = b.failurePenaltyBps(builder)
+		accuracy = b.accuracyPenaltyBps(builder)
+	)
+
+	// the discounts are applied one after the other
+	return 10000 - (10000-failure)*(10000-accuracy)/10000
+}
+
+// accuracyPenaltyBps returns the discount of the expected reward of the builder's bids by how
+// far their simulations fell short of the declarations, 0 if the penalty is disabled. A builder
+// overstating its bids would otherwise win the simulation slot over the honest ones.
+func (b *bidSimulator) accuracyPenaltyBps(builder common.Address) uint64 {
+	if !b.config.PenalizeInaccurateBuilders {
+		return 0
+	}
+
+	b.statsMu.RLock()
+	defer b.statsMu.RUnlock()
+
+	stats, ok := b.stats[builder]
+	if !ok || stats.declarationSamples == 0 {
+		return 0
+	}
+
+	return min(uint64(math.Round((1-stats.accuracy)*10000)), maxAccuracyPenaltyBps)
+}
+
+// recordSimTimeout records the simulation of the builder's bid timed out, it counts as a failure
+// in the failure rate, but not towards the mute, as the validator may be the slow one.
+func (b *bidSimulator) recordSimTimeout(builder common.Address, duration time.Duration) {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	b.getOrNewStats(builder).updateSimLocked(duration, 1)
+}
+
+// updateSimLocked updates the moving averages of the simulations by the sample of the failure,
+// 1 for a failed one and 0 for a succeeded one. It must be called with statsMu held.
+func (s *builderStats) updateSimLocked(duration time.Duration, failure float64) {
+	s.simDuration = ewma(s.simDuration, float64(duration), s.simSamples > 0)
+	s.failureRate = ewma(s.failureRate, failure, s.simSamples > 0)
+	s.simSamples++
+}
+
+// failurePenaltyBps returns the discount of the expected reward of the builder's bids
+// in basis points, 0 if the penalty is disabled.
+func (b *bidSimulator) failurePenaltyBps(builder common.Address) uint64 {
+	if !b.config.PenalizeFailingBuilders {
+		return 0
+	}
+
+	b.statsMu.RLock()
+	defer b.statsMu.RUnlock()
+
+	stats, ok := b.stats[builder]
+	if !ok {
+		return 0
+	}
+
+	penalty := uint64(math.Round(stats.failureRate * 10000))
+	if penalty > maxFailurePenaltyBps {
+		penalty = maxFailurePenaltyBps
+	}
+
+	return penalty
+}
+
+// loadBuilderStats loads the builder stats persisted before restart.
+func (b *bidSimulator) loadBuilderStats() {
+	if b.statsDB == nil {
+		return
+	}
+
+	it := b.statsDB.NewIterator(builderStatsPrefix, nil)
+	defer it.Release()
+
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	for it.Next() {
+		var persisted persistedBuilderStats
+		if err := rlp.DecodeBytes(it.Value(), &persisted); err != nil {
+			log.Warn("BidSimulator: failed to decode builder stats", "key", common.Bytes2Hex(it.Key()), "err", err)
+			continue
+		}
+
+		builder := common.BytesToAddress(it.Key()[len(builderStatsPrefix):])
+		b.getOrNewStats(builder).persistedBuilderStats = persisted
+	}
+}
+
+// flushBuilderStats writes the changed builder stats into the store.
+func (b *bidSimulator) flushBuilderStats() {
+	batch := b.statsDB.NewBatch()
+
+	b.statsMu.Lock()
+	for builder := range b.statsDirty {
+		enc, err := rlp.EncodeToBytes(&b.stats[builder].persistedBuilderStats)
+		if err != nil {
+			log.Warn("BidSimulator: failed to encode builder stats", "builder", builder, "err", err)
+			continue
+		}
+		_ = batch.Put(builderStatsKey(builder), enc)
+	}
+	b.statsDirty = make(map[common.Address]struct{})
+	b.statsMu.Unlock()
+
+	if err := batch.Write(); err != nil {
+		log.Warn("BidSimulator: failed to persist builder stats", "err", err)
+	}
+}
+
+// persistLoop flushes the builder stats periodically, so the persistence never blocks the bid path.
+func (b *bidSimulator) persistLoop() {
+	ticker := time.NewTicker(builderStatsPersistInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.flushBuilderStats()
+		case <-b.exitCh:
+			b.flushBuilderStats()
+			b.statsDB.Close()
+			return
+		}
+	}
+}
+
+// checkBidTime measures the clock skew of the builder from the bid timestamp, and checks the
+// time-based fields of the bid against our clock with the skew corrected.
+func (b *bidSimulator) checkBidTime(builder common.Address, rawBid *types.RawBid, receivedAt time.Time) error {
+	b.statsMu.Lock()
+	stats := b.getOrNewStats(builder)
+	if rawBid.Timestamp != 0 {
+		sample := float64(receivedAt.Sub(time.UnixMilli(int64(rawBid.Timestamp))))
+		stats.clockSkew = ewma(stats.clockSkew, sample, stats.skewSamples > 0)
+		stats.skewSamples++
+	}
+	skew := time.Duration(stats.clockSkew)
+	b.statsMu.Unlock()
+
+	// cap the correction, so a lying timestamp can't buy extra validity time
+	if maxSkew := b.config.MaxClockSkewCorrection; skew > maxSkew {
+		skew = maxSkew
+	} else if skew < -maxSkew {
+		skew = -maxSkew
+	}
+
+	if rawBid.Expiry != 0 {
+		expiry := time.UnixMilli(int64(rawBid.Expiry)).Add(skew)
+		if receivedAt.After(expiry) {
+			return types.NewInvalidBidError(fmt.Sprintf("bid expired at %s, clock skew correction %s",
+				expiry.Format(time.RFC3339Nano), skew))
+		}
+	}
+
+	return nil
+}
+
+// declarationAccuracy returns the share of the declared reward and gas used realized by the
+// builder txs of the bid, in [0, 1], it must be called once they are committed. The realized
+// reward beyond the declared one doesn't make up for the gas, and the payBidTx, committed later,
+// is allowed for in the gas used.
+func (r *BidRuntime) declarationAccuracy() float64 {
+	accuracy := 1.0
+
+	declared := new(big.Int).Add(r.bid.GasFee, r.bid.NontaxableFee)
+	realized := new(big.Int).Add(r.packedBlockRewardPreBEP95Builder.ToBig(), r.directBribeBNB())
+	if declared.Sign() > 0 && realized.Cmp(declared) < 0 {
+		accuracy, _ = new(big.Rat).SetFrac(realized, declared).Float64()
+	}
+
+	declaredGas, realizedGas := r.bid.GasUsed, r.env.header.GasUsed
+	if low, high := min(declaredGas, realizedGas), max(declaredGas, realizedGas); high-low > params.PayBidTxGasLimit {
+		accuracy = min(accuracy, float64(low)/float64(high))
+	}
+
+	return accuracy
+}
@@ -0,0 +1,282 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/miner/builderclient"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+func TestCheckBidTimeClockSkew(t *testing.T) {
+	config := DefaultMevConfig
+	config.MaxClockSkewCorrection = time.Second
+
+	b := &bidSimulator{
+		config: &config,
+		stats:  make(map[common.Address]*builderStats),
+	}
+
+	// the builder clock is 3s behind ours
+	now := time.Now()
+	builderNow := uint64(now.Add(-3 * time.Second).UnixMilli())
+
+	err := b.checkBidTime(testBuilder, &types.RawBid{Timestamp: builderNow, Expiry: builderNow + 500}, now)
+	if err == nil {
+		t.Fatalf("expected expired bid, the skew correction should be capped")
+	}
+
+	if skew := b.BuilderStats(testBuilder).ClockSkew; skew < 2900*time.Millisecond || skew > 3100*time.Millisecond {
+		t.Fatalf("unexpected clock skew %v", skew)
+	}
+
+	// the builder clock is 500ms behind ours, within the cap
+	b.stats = make(map[common.Address]*builderStats)
+	builderNow = uint64(now.Add(-500 * time.Millisecond).UnixMilli())
+
+	err = b.checkBidTime(testBuilder, &types.RawBid{Timestamp: builderNow, Expiry: builderNow + 100}, now)
+	if err != nil {
+		t.Fatalf("unexpected error with corrected expiry: %v", err)
+	}
+}
+
+func TestBuilderStatsPersistence(t *testing.T) {
+	db := memorydb.New()
+
+	b := &bidSimulator{
+		stats:      make(map[common.Address]*builderStats),
+		statsDirty: make(map[common.Address]struct{}),
+		statsDB:    db,
+	}
+	b.recordAccepted(testBuilder, 100)
+	b.recordAccepted(testBuilder, 99)
+	b.recordError(testBuilder)
+	b.flushBuilderStats()
+
+	// restart
+	b = &bidSimulator{
+		stats:      make(map[common.Address]*builderStats),
+		statsDirty: make(map[common.Address]struct{}),
+		statsDB:    db,
+	}
+	b.loadBuilderStats()
+
+	stats := b.BuilderStats(testBuilder)
+	if stats == nil {
+		t.Fatalf("builder stats are not restored")
+	}
+	if stats.Accepted != 2 || stats.Errors != 1 || stats.LastSeenBlock != 100 {
+		t.Fatalf("unexpected restored stats: %+v", stats)
+	}
+}
+
+func TestFailurePenalty(t *testing.T) {
+	config := DefaultMevConfig
+
+	b := &bidSimulator{
+		config: &config,
+		stats:  make(map[common.Address]*builderStats),
+	}
+	b.recordSimResult(testBuilder, time.Millisecond, true)
+
+	if penalty := b.failurePenaltyBps(testBuilder); penalty != 0 {
+		t.Fatalf("unexpected penalty %d while disabled", penalty)
+	}
+
+	config.PenalizeFailingBuilders = true
+	if penalty := b.failurePenaltyBps(testBuilder); penalty != maxFailurePenaltyBps {
+		t.Fatalf("expected capped penalty, got %d", penalty)
+	}
+
+	b.recordSimResult(testBuilder, time.Millisecond, false)
+	if penalty := b.failurePenaltyBps(testBuilder); penalty != 8000 {
+		t.Fatalf("unexpected penalty %d", penalty)
+	}
+
+	newRuntime := func(gasFee int64, penalty uint64) *BidRuntime {
+		r := newBidRuntime(&types.Bid{GasFee: big.NewInt(gasFee), NontaxableFee: big.NewInt(0)})
+		r.penaltyBps = penalty
+		return r
+	}
+
+	// the higher bid loses to the lower one of a reliable builder after penalty
+	if newRuntime(1000, 8000).isExpectedBetterThanSimulatingBid(newRuntime(300, 0), rewardValuator{}) {
+		t.Fatalf("penalized bid should not be better")
+	}
+	if !newRuntime(1000, 0).isExpectedBetterThanSimulatingBid(newRuntime(300, 0), rewardValuator{}) {
+		t.Fatalf("bid without penalty should be better")
+	}
+}
+
+func TestBuilderMute(t *testing.T) {
+	config := DefaultMevConfig
+	config.BuilderMuteFailures = 3
+	config.BuilderMuteCooldown = time.Hour
+
+	b := &bidSimulator{
+		config: &config,
+		stats:  make(map[common.Address]*builderStats),
+	}
+
+	// a success resets the consecutive failures
+	b.recordSimResult(testBuilder, time.Millisecond, true)
+	b.recordSimResult(testBuilder, time.Millisecond, true)
+	b.recordSimResult(testBuilder, time.Millisecond, false)
+	b.recordSimResult(testBuilder, time.Millisecond, true)
+	if err := b.checkBuilderMuted(testBuilder); err != nil {
+		t.Fatalf("unexpected mute: %v", err)
+	}
+	if failures := b.BuilderStats(testBuilder).ConsecutiveFailures; failures != 1 {
+		t.Fatalf("unexpected consecutive failures %d", failures)
+	}
+
+	b.recordSimResult(testBuilder, time.Millisecond, true)
+	b.recordSimResult(testBuilder, time.Millisecond, true)
+	if err := b.checkBuilderMuted(testBuilder); err == nil {
+		t.Fatalf("expected the builder muted")
+	}
+	if b.BuilderStats(testBuilder).MutedUntil == nil {
+		t.Fatalf("expected the mute in the stats")
+	}
+
+	// enabled again after the cooldown
+	b.stats[testBuilder].mutedUntil = time.Now().Add(-time.Second)
+	if err := b.checkBuilderMuted(testBuilder); err != nil {
+		t.Fatalf("unexpected mute after the cooldown: %v", err)
+	}
+	if !b.stats[testBuilder].mutedUntil.IsZero() {
+		t.Fatalf("mute is not cleared")
+	}
+}
+
+func TestMuteBuilderByOperator(t *testing.T) {
+	b := &bidSimulator{
+		config:     &DefaultMevConfig,
+		builders:   map[common.Address]*builderclient.Client{testBuilder: nil},
+		mutedUntil: make(map[common.Address]time.Time),
+		stats:      make(map[common.Address]*builderStats),
+	}
+
+	if err := b.MuteBuilder(common.Address{0x02}, time.Hour); err == nil {
+		t.Fatalf("expected the unknown builder to be refused")
+	}
+	if err := b.MuteBuilder(testBuilder, 0); err == nil {
+		t.Fatalf("expected the zero duration to be refused")
+	}
+
+	if err := b.MuteBuilder(testBuilder, time.Hour); err != nil {
+		t.Fatalf("failed to mute builder: %v", err)
+	}
+	if err := b.checkBuilderMuted(testBuilder); err == nil {
+		t.Fatalf("expected the builder muted")
+	}
+	if err := b.UnmuteBuilder(testBuilder); err != nil {
+		t.Fatalf("failed to unmute builder: %v", err)
+	}
+	if err := b.checkBuilderMuted(testBuilder); err != nil {
+		t.Fatalf("unexpected mute after unmuting: %v", err)
+	}
+
+	// enabled again after the duration
+	b.mutedUntil[testBuilder] = time.Now().Add(-time.Second)
+	if err := b.checkBuilderMuted(testBuilder); err != nil {
+		t.Fatalf("unexpected mute after the duration: %v", err)
+	}
+	if _, ok := b.mutedUntil[testBuilder]; ok {
+		t.Fatalf("mute is not cleared")
+	}
+	if !b.ExistBuilder(testBuilder) {
+		t.Fatalf("expected the builder to be kept")
+	}
+}
+
+func TestAccuracyPenalty(t *testing.T) {
+	config := DefaultMevConfig
+
+	b := &bidSimulator{
+		config: &config,
+		stats:  make(map[common.Address]*builderStats),
+	}
+
+	newRuntime := func(gasFee, realizedFee int64, gasUsed, realizedGas uint64) *BidRuntime {
+		r := newBidRuntime(&types.Bid{GasFee: big.NewInt(gasFee), NontaxableFee: big.NewInt(0), GasUsed: gasUsed})
+		r.env = &environment{header: &types.Header{GasUsed: realizedGas}}
+		r.packedBlockRewardPreBEP95Builder = uint256.NewInt(uint64(realizedFee))
+		return r
+	}
+
+	// the payBidTx is allowed for, the surplus reward doesn't count
+	if accuracy := newRuntime(1000, 2000, 100000, 100000-params.PayBidTxGasLimit).declarationAccuracy(); accuracy != 1 {
+		t.Fatalf("unexpected accuracy %v of the accurate bid", accuracy)
+	}
+	if accuracy := newRuntime(1000, 250, 100000, 100000).declarationAccuracy(); accuracy != 0.25 {
+		t.Fatalf("unexpected accuracy %v of the overstated reward", accuracy)
+	}
+	if accuracy := newRuntime(1000, 1000, 20000, 100000).declarationAccuracy(); accuracy != 0.2 {
+		t.Fatalf("unexpected accuracy %v of the understated gas used", accuracy)
+	}
+
+	b.recordDeclaration(testBuilder, newRuntime(1000, 250, 100000, 100000))
+	if penalty := b.builderPenaltyBps(testBuilder); penalty != 0 {
+		t.Fatalf("unexpected penalty %d while disabled", penalty)
+	}
+	stats := b.BuilderStats(testBuilder)
+	if stats.Accuracy != 0.25 {
+		t.Fatalf("unexpected accuracy %v in the stats", stats.Accuracy)
+	}
+	// 990 expected after BEP95, 247 realized
+	if stats.RewardGap.Int64() != 743 {
+		t.Fatalf("unexpected reward gap %v in the stats", stats.RewardGap)
+	}
+
+	config.PenalizeInaccurateBuilders = true
+	if penalty := b.builderPenaltyBps(testBuilder); penalty != 7500 {
+		t.Fatalf("unexpected penalty %d", penalty)
+	}
+
+	// the penalties of the failures and the inaccuracy are applied one after the other
+	config.PenalizeFailingBuilders = true
+	b.recordSimResult(testBuilder, time.Millisecond, false)
+	b.stats[testBuilder].failureRate = 0.5
+	if penalty := b.builderPenaltyBps(testBuilder); penalty != 8750 {
+		t.Fatalf("unexpected combined penalty %d", penalty)
+	}
+}
+
+func TestBuilderReputation(t *testing.T) {
+	config := DefaultMevConfig
+	config.BuilderMuteFailures = 2
+
+	b := &bidSimulator{
+		config: &config,
+		stats:  make(map[common.Address]*builderStats),
+	}
+
+	// the timeouts count in the failure rate but not towards the mute
+	b.recordSimResult(testBuilder, time.Millisecond, true)
+	b.recordSimTimeout(testBuilder, time.Millisecond)
+	if err := b.checkBuilderMuted(testBuilder); err != nil {
+		t.Fatalf("unexpected mute for the timeout: %v", err)
+	}
+	stats := b.BuilderStats(testBuilder)
+	if stats.ConsecutiveFailures != 1 || stats.FailureRate != 1 || stats.Reputation != 0 {
+		t.Fatalf("unexpected stats after the timeout: %+v", stats)
+	}
+
+	b.recordSimResult(testBuilder, time.Millisecond, true)
+	if err := b.checkBuilderMuted(testBuilder); err == nil {
+		t.Fatalf("expected the builder muted")
+	}
+
+	// the reputation is the success rate times the accuracy
+	b.stats[testBuilder].failureRate = 0.5
+	b.stats[testBuilder].accuracy, b.stats[testBuilder].declarationSamples = 0.5, 1
+	if reputation := b.BuilderStats(testBuilder).Reputation; reputation != 0.25 {
+		t.Fatalf("unexpected reputation %v", reputation)
+	}
+}
@@ -0,0 +1,80 @@
+package miner
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCert writes a self-signed certificate valid in the window and its key into the dir.
+func writeTestCert(t *testing.T, dir string, notBefore, notAfter time.Time) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "validator"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+
+	return certFile, keyFile
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	if tlsConfig, err := newTLSConfig(&MevConfig{}); tlsConfig != nil || err != nil {
+		t.Fatalf("expected no TLS config, got %v, err %v", tlsConfig, err)
+	}
+
+	now := time.Now()
+	certFile, keyFile := writeTestCert(t, t.TempDir(), now.Add(-time.Hour), now.Add(time.Hour))
+
+	tlsConfig, err := newTLSConfig(&MevConfig{TLSCAFile: certFile, TLSCertFile: certFile, TLSKeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("failed to create TLS config: %v", err)
+	}
+	if tlsConfig.RootCAs == nil || len(tlsConfig.Certificates) != 1 {
+		t.Fatalf("unexpected TLS config, roots %v, certificates %d", tlsConfig.RootCAs, len(tlsConfig.Certificates))
+	}
+
+	expiredCert, expiredKey := writeTestCert(t, t.TempDir(), now.Add(-2*time.Hour), now.Add(-time.Hour))
+	for name, config := range map[string]*MevConfig{
+		"cert without key": {TLSCertFile: certFile},
+		"missing CA":       {TLSCAFile: filepath.Join(t.TempDir(), "missing.pem")},
+		"key as CA":        {TLSCAFile: keyFile},
+		"mismatched key":   {TLSCertFile: certFile, TLSKeyFile: expiredKey},
+		"expired cert":     {TLSCertFile: expiredCert, TLSKeyFile: expiredKey},
+	} {
+		if _, err := newTLSConfig(config); err == nil {
+			t.Errorf("%s: expected the TLS config to be rejected", name)
+		}
+	}
+}
@@ -31,3 +31,29 @@ func newClient(c *rpc.Client) *Client {
 func (ec *Client) ReportIssue(ctx context.Context, args *types.BidIssue) error {
 	return ec.c.CallContext(ctx, nil, "mev_reportIssue", args)
 }
+
+// ReportBidResult reports the result of a sealed block
+func (ec *Client) ReportBidResult(ctx context.Context, args *types.BidSealedResult) error {
+	return ec.c.CallContext(ctx, nil, "mev_reportBidResult", args)
+}
+
+// SendBeacon sends the liveness beacon of the validator
+func (ec *Client) SendBeacon(ctx context.Context, args *types.MevBeacon) error {
+	return ec.c.CallContext(ctx, nil, "mev_beacon", args)
+}
+
+// Ping checks if the endpoint is reachable, an error replied by the endpoint
+// means it is reachable as well.
+func (ec *Client) Ping(ctx context.Context) error {
+	var version string
+	err := ec.c.CallContext(ctx, &version, "web3_clientVersion")
+	if _, ok := err.(rpc.Error); ok {
+		return nil
+	}
+	return err
+}
+
+// Close closes the underlying RPC connection.
+func (ec *Client) Close() {
+	ec.c.Close()
+}
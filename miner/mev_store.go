@@ -0,0 +1,318 @@
+package miner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	// mevSchemaVersionKey -> schema version (uint64 big endian), absent in the stores created before versioning
+	mevSchemaVersionKey = []byte("mev-schema-version")
+
+	// mevQuarantinePrefix + original key -> original value, the records failed the validation
+	mevQuarantinePrefix = []byte("mev-quarantine-")
+)
+
+// mevMigration migrates the store from the previous schema version.
+type mevMigration func(db ethdb.KeyValueStore) error
+
+// mevSchema describes the versioned schema of a persistent store of the miner.
+type mevSchema struct {
+	name     string
+	prefixes [][]byte // key prefixes of the records
+
+	// migrations[v] migrates the store from version v to v+1, so the current version is len(migrations)
+	migrations []mevMigration
+
+	// validate checks if a record of the current version is well-formed
+	validate func(key, value []byte) error
+}
+
+func (s *mevSchema) version() uint64 {
+	return uint64(len(s.migrations))
+}
+
+func (s *mevSchema) owns(key []byte) bool {
+	for _, prefix := range s.prefixes {
+		if bytes.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func readSchemaVersion(db ethdb.KeyValueReader) (uint64, error) {
+	enc, err := db.Get(mevSchemaVersionKey)
+	if err != nil {
+		// stores created before versioning are version 0
+		return 0, nil
+	}
+	if len(enc) != 8 {
+		return 0, fmt.Errorf("invalid schema version %x", enc)
+	}
+	return binary.BigEndian.Uint64(enc), nil
+}
+
+func writeSchemaVersion(db ethdb.KeyValueWriter, version uint64) error {
+	return db.Put(mevSchemaVersionKey, encodeBlockNumber(version))
+}
+
+// openMevStore migrates the store to the current schema version, and quarantines the
+// corrupted records, so the store can be used right after.
+func openMevStore(db ethdb.KeyValueStore, schema *mevSchema) error {
+	if err := migrateMevStore(db, schema); err != nil {
+		return err
+	}
+
+	quarantined, err := quarantineCorrupted(db, schema)
+	if err != nil {
+		return err
+	}
+	if quarantined > 0 {
+		log.Warn("MevStore: quarantined corrupted records", "store", schema.name, "count", quarantined)
+	}
+
+	return nil
+}
+
+func migrateMevStore(db ethdb.KeyValueStore, schema *mevSchema) error {
+	version, err := readSchemaVersion(db)
+	if err != nil {
+		return err
+	}
+
+	if version > schema.version() {
+		return fmt.Errorf("store %s has schema version %d newer than supported %d", schema.name, version, schema.version())
+	}
+
+	for ; version < schema.version(); version++ {
+		log.Info("MevStore: migrating schema", "store", schema.name, "from", version, "to", version+1)
+
+		if err = schema.migrations[version](db); err != nil {
+			return fmt.Errorf("failed to migrate store %s to version %d: %v", schema.name, version+1, err)
+		}
+
+		// record the version after each step, so an interrupted migration resumes from there
+		if err = writeSchemaVersion(db, version+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// quarantineCorrupted moves the records failed the validation out of the schema prefixes.
+func quarantineCorrupted(db ethdb.KeyValueStore, schema *mevSchema) (int, error) {
+	var (
+		batch       = db.NewBatch()
+		quarantined int
+	)
+
+	it := db.NewIterator(nil, nil)
+	for it.Next() {
+		if !schema.owns(it.Key()) {
+			continue
+		}
+
+		if err := schema.validate(it.Key(), it.Value()); err != nil {
+			log.Warn("MevStore: corrupted record", "store", schema.name, "key", common.Bytes2Hex(it.Key()), "err", err)
+
+			_ = batch.Put(append(append([]byte{}, mevQuarantinePrefix...), it.Key()...), common.CopyBytes(it.Value()))
+			_ = batch.Delete(common.CopyBytes(it.Key()))
+			quarantined++
+		}
+	}
+	it.Release()
+
+	if err := it.Error(); err != nil {
+		return 0, err
+	}
+
+	return quarantined, batch.Write()
+}
+
+// exportMevStore dumps the records of the store with its schema version.
+func exportMevStore(db ethdb.KeyValueStore, schema *mevSchema) (*types.MevStoreDump, error) {
+	version, err := readSchemaVersion(db)
+	if err != nil {
+		return nil, err
+	}
+
+	dump := &types.MevStoreDump{
+		Store:   schema.name,
+		Version: version,
+		Records: make([]*types.MevStoreRecord, 0),
+	}
+
+	for _, prefix := range schema.prefixes {
+		it := db.NewIterator(prefix, nil)
+		for it.Next() {
+			dump.Records = append(dump.Records, &types.MevStoreRecord{
+				Key:   common.CopyBytes(it.Key()),
+				Value: common.CopyBytes(it.Value()),
+			})
+		}
+		it.Release()
+
+		if err = it.Error(); err != nil {
+			return nil, err
+		}
+	}
+
+	return dump, nil
+}
+
+// importMevStore migrates the dumped records to the current schema version, and writes
+// them into the store, overwriting the records with the same keys.
+func importMevStore(db ethdb.KeyValueStore, schema *mevSchema, dump *types.MevStoreDump) error {
+	if dump.Store != schema.name {
+		return fmt.Errorf("dump of store %s can't be imported into %s", dump.Store, schema.name)
+	}
+
+	// migrate in a scratch store, so a failed import doesn't leave partial records
+	scratch := memorydb.New()
+	defer scratch.Close()
+
+	for _, record := range dump.Records {
+		if !schema.owns(record.Key) {
+			return fmt.Errorf("unexpected key %x in dump of store %s", record.Key, schema.name)
+		}
+		if err := scratch.Put(record.Key, record.Value); err != nil {
+			return err
+		}
+	}
+
+	if err := writeSchemaVersion(scratch, dump.Version); err != nil {
+		return err
+	}
+	if err := openMevStore(scratch, schema); err != nil {
+		return err
+	}
+
+	batch := db.NewBatch()
+	for _, prefix := range schema.prefixes {
+		it := scratch.NewIterator(prefix, nil)
+		for it.Next() {
+			_ = batch.Put(common.CopyBytes(it.Key()), common.CopyBytes(it.Value()))
+		}
+		it.Release()
+	}
+
+	return batch.Write()
+}
+
+// validateRLP returns a validate function decoding the records with the key length
+// into the type created by newValue.
+func validateRLP(keyLen map[string]int, newValue func(prefix string) interface{}) func(key, value []byte) error {
+	return func(key, value []byte) error {
+		for prefix, length := range keyLen {
+			if !bytes.HasPrefix(key, []byte(prefix)) {
+				continue
+			}
+			if len(key) != len(prefix)+length {
+				return errors.New("invalid key length")
+			}
+			return rlp.DecodeBytes(value, newValue(prefix))
+		}
+		return errors.New("unknown key")
+	}
+}
+
+// bidHistorySchema is the schema of the bid history store.
+var bidHistorySchema = &mevSchema{
+	name:     "history",
+	prefixes: [][]byte{bidHistoryPrefix},
+	migrations: []mevMigration{
+		// v1: versioning is introduced, the records are unchanged
+		func(ethdb.KeyValueStore) error { return nil },
+	},
+	validate: validateRLP(map[string]int{string(bidHistoryPrefix): 8}, func(string) interface{} {
+		return new(bidHistoryRecord)
+	}),
+}
+
+// builderStoreSchema is the schema of the store of the builder stats and the builder registry.
+var builderStoreSchema = &mevSchema{
+	name:     "builders",
+	prefixes: [][]byte{builderStatsPrefix, builderRegistryPrefix},
+	migrations: []mevMigration{
+		// v1: versioning is introduced, the records are unchanged
+		func(ethdb.KeyValueStore) error { return nil },
+	},
+	validate: validateRLP(map[string]int{
+		string(builderStatsPrefix):    common.AddressLength,
+		string(builderRegistryPrefix): common.AddressLength,
+	}, func(prefix string) interface{} {
+		if prefix == string(builderStatsPrefix) {
+			return new(persistedBuilderStats)
+		}
+		return new(persistedBuilder)
+	}),
+}
+
+// mevStore returns the opened store with the name and its schema.
+func (b *bidSimulator) mevStore(name string) (ethdb.KeyValueStore, *mevSchema, error) {
+	var (
+		db     ethdb.KeyValueStore
+		schema *mevSchema
+	)
+
+	switch name {
+	case bidHistorySchema.name:
+		db, schema = b.historyDB, bidHistorySchema
+	case builderStoreSchema.name:
+		db, schema = b.statsDB, builderStoreSchema
+	default:
+		return nil, nil, fmt.Errorf("unknown store %s", name)
+	}
+
+	if db == nil {
+		return nil, nil, fmt.Errorf("store %s is disabled", name)
+	}
+
+	return db, schema, nil
+}
+
+// ExportStore dumps the records of the persistent store with the name.
+func (b *bidSimulator) ExportStore(name string) (*types.MevStoreDump, error) {
+	db, schema, err := b.mevStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return exportMevStore(db, schema)
+}
+
+// ImportStore imports the dumped records into the persistent store, and reloads
+// the in-memory states derived from the store.
+func (b *bidSimulator) ImportStore(dump *types.MevStoreDump) error {
+	db, schema, err := b.mevStore(dump.Store)
+	if err != nil {
+		return err
+	}
+
+	if err = importMevStore(db, schema, dump); err != nil {
+		return err
+	}
+
+	switch schema {
+	case bidHistorySchema:
+		b.history.reload()
+	case builderStoreSchema:
+		b.loadBuilderStats()
+		b.loadBuilderRegistry()
+	}
+
+	log.Info("MevStore: imported", "store", dump.Store, "version", dump.Version, "records", len(dump.Records))
+
+	return nil
+}
@@ -0,0 +1,183 @@
+package miner
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var testStorePrefix = []byte("mev-test-")
+
+type testRecordV1 struct {
+	A uint64
+}
+
+type testRecordV2 struct {
+	A uint64
+	B uint64 // introduced in v2, 2*A for the migrated records
+}
+
+func testStoreKey(n uint64) []byte {
+	return append(append([]byte{}, testStorePrefix...), encodeBlockNumber(n)...)
+}
+
+func migrateTestRecordsToV2(db ethdb.KeyValueStore) error {
+	batch := db.NewBatch()
+
+	it := db.NewIterator(testStorePrefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		var v1 testRecordV1
+		if err := rlp.DecodeBytes(it.Value(), &v1); err != nil {
+			// left to the corruption check
+			continue
+		}
+
+		enc, err := rlp.EncodeToBytes(&testRecordV2{A: v1.A, B: 2 * v1.A})
+		if err != nil {
+			return err
+		}
+		_ = batch.Put(append([]byte{}, it.Key()...), enc)
+	}
+
+	return batch.Write()
+}
+
+func newTestSchemas() (v1, v2 *mevSchema) {
+	noop := func(ethdb.KeyValueStore) error { return nil }
+
+	v1 = &mevSchema{
+		name:       "test",
+		prefixes:   [][]byte{testStorePrefix},
+		migrations: []mevMigration{noop},
+		validate: validateRLP(map[string]int{string(testStorePrefix): 8}, func(string) interface{} {
+			return new(testRecordV1)
+		}),
+	}
+	v2 = &mevSchema{
+		name:       "test",
+		prefixes:   [][]byte{testStorePrefix},
+		migrations: []mevMigration{noop, migrateTestRecordsToV2},
+		validate: validateRLP(map[string]int{string(testStorePrefix): 8}, func(string) interface{} {
+			return new(testRecordV2)
+		}),
+	}
+
+	return v1, v2
+}
+
+func readTestRecordV2(t *testing.T, db ethdb.KeyValueReader, n uint64) *testRecordV2 {
+	enc, err := db.Get(testStoreKey(n))
+	if err != nil {
+		t.Fatalf("record %d is missing: %v", n, err)
+	}
+
+	record := new(testRecordV2)
+	if err = rlp.DecodeBytes(enc, record); err != nil {
+		t.Fatalf("record %d is not migrated: %v", n, err)
+	}
+
+	return record
+}
+
+func TestMevStoreMigration(t *testing.T) {
+	_, schemaV2 := newTestSchemas()
+
+	// a store created before versioning, with a corrupted record
+	db := memorydb.New()
+	enc, _ := rlp.EncodeToBytes(&testRecordV1{A: 1})
+	_ = db.Put(testStoreKey(1), enc)
+	_ = db.Put(testStoreKey(2), []byte{0xff, 0xff})
+
+	if err := openMevStore(db, schemaV2); err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+
+	if version, _ := readSchemaVersion(db); version != 2 {
+		t.Fatalf("unexpected schema version %d", version)
+	}
+	if record := readTestRecordV2(t, db, 1); record.B != 2 {
+		t.Fatalf("unexpected migrated record %+v", record)
+	}
+
+	// the corrupted record is quarantined, not lost
+	if ok, _ := db.Has(testStoreKey(2)); ok {
+		t.Fatalf("corrupted record is not quarantined")
+	}
+	if ok, _ := db.Has(append(append([]byte{}, mevQuarantinePrefix...), testStoreKey(2)...)); !ok {
+		t.Fatalf("corrupted record is lost")
+	}
+
+	// a store of newer version is refused
+	_ = writeSchemaVersion(db, 3)
+	if err := openMevStore(db, schemaV2); err == nil {
+		t.Fatalf("expected error with newer schema version")
+	}
+}
+
+func TestMevStoreExportImport(t *testing.T) {
+	schemaV1, schemaV2 := newTestSchemas()
+
+	// export from a node running the old version
+	src := memorydb.New()
+	if err := openMevStore(src, schemaV1); err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	for n := uint64(1); n <= 3; n++ {
+		enc, _ := rlp.EncodeToBytes(&testRecordV1{A: n})
+		_ = src.Put(testStoreKey(n), enc)
+	}
+
+	dump, err := exportMevStore(src, schemaV1)
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	if dump.Version != 1 || len(dump.Records) != 3 {
+		t.Fatalf("unexpected dump %+v", dump)
+	}
+
+	// move the dump as JSON
+	enc, _ := json.Marshal(dump)
+	dump = new(types.MevStoreDump)
+	if err = json.Unmarshal(enc, dump); err != nil {
+		t.Fatalf("failed to decode dump: %v", err)
+	}
+
+	// import into a node running the new version
+	dst := memorydb.New()
+	if err = openMevStore(dst, schemaV2); err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	if err = importMevStore(dst, schemaV2, dump); err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+	for n := uint64(1); n <= 3; n++ {
+		if record := readTestRecordV2(t, dst, n); record.A != n || record.B != 2*n {
+			t.Fatalf("unexpected imported record %+v", record)
+		}
+	}
+
+	// round trip on the same version keeps the records unchanged
+	dump, _ = exportMevStore(dst, schemaV2)
+	again := memorydb.New()
+	_ = openMevStore(again, schemaV2)
+	if err = importMevStore(again, schemaV2, dump); err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+	if record := readTestRecordV2(t, again, 3); record.A != 3 || record.B != 6 {
+		t.Fatalf("unexpected round trip record %+v", record)
+	}
+
+	// dumps of other stores or newer versions are refused
+	if err = importMevStore(again, schemaV2, &types.MevStoreDump{Store: "history"}); err == nil {
+		t.Fatalf("expected error with dump of another store")
+	}
+	if err = importMevStore(again, schemaV2, &types.MevStoreDump{Store: "test", Version: 3}); err == nil {
+		t.Fatalf("expected error with dump of newer version")
+	}
+}
@@ -57,9 +57,19 @@ type Config struct {
 	NewPayloadTimeout      time.Duration // The maximum time allowance for creating a new payload
 	DisableVoteAttestation bool          // Whether to skip assembling vote attestation
 
+	EtherbaseRotation []EtherbaseRotation `toml:",omitempty"` // Payout address switches by block number, Etherbase stays the signer
+
 	Mev MevConfig // Mev configuration
 }
 
+// EtherbaseRotation is the payout address active from the block number until the next rotation.
+// The block is still sealed and executed with Etherbase as the coinbase, the rotated address is
+// only credited as a bribe recipient of the bids, which the builders pay directly.
+type EtherbaseRotation struct {
+	FromBlock uint64
+	Etherbase common.Address
+}
+
 // DefaultConfig contains default settings for miner.
 var DefaultConfig = Config{
 	GasCeil:  0,
@@ -6,8 +6,10 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 )
@@ -15,6 +17,7 @@ import (
 type BuilderConfig struct {
 	Address common.Address
 	URL     string
+	Auth    EndpointAuth // The credentials of the builder, empty means unauthenticated
 }
 
 type MevConfig struct {
@@ -22,10 +25,68 @@ type MevConfig struct {
 	GreedyMergeTx         bool            // Whether to merge local transactions to the bid
 	BuilderFeeCeil        string          // The maximum builder fee of a bid
 	SentryURL             string          // The url of Mev sentry
+	SentryURLs            []string        // The urls of the fallback sentries, failed over to in order by the health check once the current one is unreachable
+	SentryAuth            EndpointAuth    // The credentials of the sentries, empty means unauthenticated
 	Builders              []BuilderConfig // The list of builders
 	ValidatorCommission   uint64          // 100 means the validator claims 1% from block reward
 	BidSimulationLeftOver time.Duration
 	ValidatorBribeEOAs    []common.Address
+	BidGasPriceCeil       *big.Int // The maximum effective gas price used to bound the declared gas fee of a bid
+	NontaxableFeeCeil     *big.Int // The maximum declared nontaxable fee of a bid
+	ParanoidMode          bool     // Whether to re-execute the best bid on a second state before sealing
+
+	BidSimulationMaxDuration   time.Duration // The maximum wall-clock duration of a single bid simulation, 0 means no limit
+	BidCutoffGrace             time.Duration // The grace after the cutoff of the simulations before the queued bids are rejected as too late, for the clock skew
+	FutureBidsPerBuilder       int           // The maximum number of the bids per builder held for the block being sealed until it is the head, 0 means rejecting them
+	AdaptiveLeftOver           bool          // Whether to adapt BidSimulationLeftOver to the observed time the worker takes to seal after taking the best bid
+	AdaptiveLeftOverMin        time.Duration // The lower bound of the adaptive BidSimulationLeftOver
+	AdaptiveLeftOverMax        time.Duration // The upper bound of the adaptive BidSimulationLeftOver, 0 means 4 times BidSimulationLeftOver
+	BidHistoryPath             string        // The path of the bid history store, empty means disabled
+	BidAuditLogPath            string        // The path of the JSON lines audit log of the bid lifecycle, empty means disabled
+	BidAuditLogMaxSize         int           // The size in megabytes the audit log is rotated at, 0 means 100
+	RedactBestBidBuilder       bool          // Whether to hide the builder of the best bid from the RPC
+	MaxClockSkewCorrection     time.Duration // The maximum clock skew correction applied to the time-based fields of bids
+	BuilderStatsPath           string        // The path to persist the builder stats and the builders added at runtime, empty means only in memory
+	PenalizeFailingBuilders    bool          // Whether to discount the expected reward of bids by the failure rate of their builders
+	PenalizeInaccurateBuilders bool          // Whether to discount the expected reward of bids by how far the simulations of their builders fell short of the declarations
+	BidDecisionRetainBlocks    uint64        // The number of recent blocks to retain the bid decision records for, 0 means disabled
+	BidHistoryBlocks           uint64        // The number of recent sealed blocks kept in memory for mev_bidHistory, 0 means disabled
+	BuilderHealthCheckInterval time.Duration // The interval to check the connectivity of the sentry and builders, 0 means disabled
+	PreferLocalIfBetter        bool          // Whether to seal the local block instead of the best bid if it rewards more
+	ShadowMode                 bool          // Whether to simulate the bids without ever sealing them, for the canary validators
+	DryRunStateOverride        bool          // Whether the dry runs accept the overrides of the parent state, for the staging validators only
+	SimulateOutOfTurn          bool          // Whether to simulate the bids even if the validator is not in-turn, for the out-of-turn backup proposals
+	GreedyMergeMaxDuration     time.Duration // The time budget of the greedy merge, the pre-merge environment is used once exceeded, 0 means no limit
+	GreedyMergeMaxTxs          int           // The maximum mempool txs merged into a bid by the greedy merge, 0 means no limit
+	MinBidImprovement          *big.Int      // The minimum margin in wei a bid must beat the best bid by to replace it
+	MinBidImprovementBps       uint64        // The minimum margin in basis points of the best reward a bid must beat the best bid by to replace it
+	BidInterruptMinIncreaseBps uint64        // The minimum increase in basis points of the expected value of the simulating bid a bid must exceed to interrupt it, the smaller ones wait for the simulation
+	BidDebounceWindow          time.Duration // The window to collect the bids of the same parent arriving in a burst, only the best of them is simulated, 0 means disabled
+	BuilderMuteFailures        uint64        // The consecutive simulation failures, the timeouts aside, after which the bids of a builder are rejected for BuilderMuteCooldown, 0 means disabled
+	BuilderMuteCooldown        time.Duration // The time the bids of a failing builder are rejected, 0 means 1 minute
+	EnableBidMerging           bool          // Whether to append the non-conflicting txs of the second best bid to the best bid, experimental
+	BidStatePrefetch           bool          // Whether to load the accounts touched by a bid ahead of its simulation, warms the caches for cold parents at the cost of memory
+	BackupBidMaxSize           uint32        // The maximum block size of the dethroned best bid kept to fall back at seal time, 0 means disabled
+	DialTimeout                time.Duration // The timeout to dial the sentry and builders, 0 means 1s
+	RequestTimeout             time.Duration // The timeout of a request to the sentry and builders, 0 means 5s
+	MaxConnsPerHost            int           // The maximum connections to the sentry or a builder, 0 means 50
+	TLSCAFile                  string        // The CA certificate file verifying the sentry and builders, empty means the system roots
+	TLSCertFile                string        // The client certificate file for the mutual TLS to the sentry and builders, empty means no client certificate
+	TLSKeyFile                 string        // The key file of the client certificate
+	BidOutcomeSLA              time.Duration // The time after the slot deadline to resolve every accepted bid, the overdue ones are reported as unresolved, 0 means disabled
+	IssueReportRate            float64       // The maximum issue reports sent to the builders per second, 0 means 20
+	ReportBidResults           bool          // Whether to report the result of the sealed block to the builder of the sealed bid, best effort
+	ReportBidResultsToLosers   bool          // Whether to report the result of the sealed block to the builders which lost as well
+	BidResultReportRate        float64       // The maximum bid results reported to the builders per second, 0 means 20
+	LivenessBeaconInterval     time.Duration // The interval to broadcast the signed liveness beacon to the builders, 0 means disabled
+	RejectLegacyBidHash        bool          // Whether to reject the bids signed with the legacy hash instead of the canonical one, ends the compatibility window
+	BidValuation               string        // The strategy to value the bids, "reward", "rewardMinusGas" or "bribeWeighted", empty means "reward"
+	BidValuationGasPrice       *big.Int      // The price per gas deducted from the reward by "rewardMinusGas", nil means the minimum gas price of the miner
+	BribeWeightBps             uint64        // The weight in basis points of the direct bribes valued by "bribeWeighted", 0 means 10000
+
+	FastPathEnabled          bool    // Whether to accept late bids of reliable builders after a partial verification of the payment
+	FastPathMinDeliveryRatio float64 // The minimum ratio of the simulations succeeded of a builder to use the fast path
+	FastPathValueMultiplier  uint64  // The expected reward of a fast path bid must be at least this times of the best bid
 }
 
 var DefaultMevConfig = MevConfig{
@@ -34,6 +95,20 @@ var DefaultMevConfig = MevConfig{
 	Builders:              nil,
 	ValidatorCommission:   100,
 	BidSimulationLeftOver: 50 * time.Millisecond,
+	BidGasPriceCeil:       big.NewInt(100 * params.GWei),
+	NontaxableFeeCeil:     new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether)),
+
+	MaxClockSkewCorrection:     time.Second,
+	BidDecisionRetainBlocks:    1200,
+	BidHistoryBlocks:           1024,
+	BuilderHealthCheckInterval: 30 * time.Second,
+	PreferLocalIfBetter:        true,
+	BidInterruptMinIncreaseBps: 100,
+	BidCutoffGrace:             20 * time.Millisecond,
+	FutureBidsPerBuilder:       3,
+
+	FastPathMinDeliveryRatio: 0.95,
+	FastPathValueMultiplier:  3,
 }
 
 // MevRunning return true if mev is running.
@@ -46,6 +121,11 @@ func (miner *Miner) StartMev() {
 	miner.bidSimulator.startReceivingBid()
 }
 
+// AuthorizeMev sets the validator key signing the liveness beacons.
+func (miner *Miner) AuthorizeMev(validator common.Address, signTextFn func(accounts.Account, []byte) ([]byte, error)) {
+	miner.bidSimulator.authorizeBeacon(validator, signTextFn)
+}
+
 // StopMev stops mev.
 func (miner *Miner) StopMev() {
 	miner.bidSimulator.stopReceivingBid()
@@ -61,12 +141,40 @@ func (miner *Miner) RemoveBuilder(builderAddr common.Address) error {
 	return miner.bidSimulator.RemoveBuilder(builderAddr)
 }
 
+// MuteBuilder rejects the bids of the builder for the duration without removing it.
+func (miner *Miner) MuteBuilder(builder common.Address, duration time.Duration) error {
+	return miner.bidSimulator.MuteBuilder(builder, duration)
+}
+
+// UnmuteBuilder enables the muted builder at once.
+func (miner *Miner) UnmuteBuilder(builder common.Address) error {
+	return miner.bidSimulator.UnmuteBuilder(builder)
+}
+
 // HasBuilder returns true if the builder is in the builder list.
 func (miner *Miner) HasBuilder(builder common.Address) bool {
 	return miner.bidSimulator.ExistBuilder(builder)
 }
 
 func (miner *Miner) SendBid(ctx context.Context, bidArgs *types.BidArgs) (common.Hash, error) {
+	// the transport timestamp is closer to the time the bid is sent
+	receivedAt := bidTimingFromContext(ctx).receivedAt
+
+	// the bid listing several parents proceeds with the one which is the head
+	parentHash := miner.bidSimulator.resolveParent(bidArgs.RawBid)
+
+	// the bid built on top of the block being sealed is held until the block is the head
+	var future bool
+	if err := miner.bidSimulator.checkInTurn(parentHash); err != nil {
+		if future = miner.bidSimulator.isSealingParent(bidArgs.RawBid); !future {
+			return common.Hash{}, err
+		}
+	}
+
+	if err := miner.bidSimulator.checkHashVersion(bidArgs.RawBid); err != nil {
+		return common.Hash{}, err
+	}
+
 	builder, err := bidArgs.EcrecoverSender()
 	if err != nil {
 		return common.Hash{}, types.NewInvalidBidError(fmt.Sprintf("invalid signature:%v", err))
@@ -76,23 +184,69 @@ func (miner *Miner) SendBid(ctx context.Context, bidArgs *types.BidArgs) (common
 		return common.Hash{}, types.NewInvalidBidError("builder is not registered")
 	}
 
+	if err = miner.bidSimulator.checkBuilderMuted(builder); err != nil {
+		return common.Hash{}, err
+	}
+
+	err = miner.bidSimulator.checkBidTime(builder, bidArgs.RawBid, receivedAt)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	err = miner.bidSimulator.checkFeeCeil(builder, parentHash, bidArgs.RawBid.GasFee, bidArgs.NontaxableFee)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	// the resent bid is answered without decoding its txs
+	if reply, ok := miner.bidSimulator.CachedReply(bidArgs.RawBid.BlockNumber, builder, bidArgs.RawBid.Hash()); ok {
+		if reply != nil {
+			return common.Hash{}, reply
+		}
+		return bidArgs.RawBid.Hash(), nil
+	}
+
 	err = miner.bidSimulator.CheckPending(bidArgs.RawBid.BlockNumber, builder, bidArgs.RawBid.Hash())
 	if err != nil {
 		return common.Hash{}, err
 	}
 
 	signer := types.MakeSigner(miner.worker.chainConfig, big.NewInt(int64(bidArgs.RawBid.BlockNumber)), uint64(time.Now().Unix()))
-	bid, err := bidArgs.ToBid(builder, signer)
+	// most bid txs are in the txpool already, whose senders are recovered
+	bid, err := bidArgs.ToBidWithKnownTxs(builder, signer, miner.eth.TxPool().Get)
 	if err != nil {
 		return common.Hash{}, types.NewInvalidBidError(fmt.Sprintf("fail to convert bidArgs to bid, %v", err))
 	}
+	bid.ParentHash = parentHash
+
+	miner.bidSimulator.auditBid(bidAuditReceived, bid, nil, nil)
+
+	if future {
+		if err = miner.bidSimulator.holdFutureBid(bid, signer, bidTimingFromContext(ctx)); err != nil {
+			return common.Hash{}, err
+		}
+		return bid.Hash(), nil
+	}
 
-	bidBetterBefore := miner.bidSimulator.bidBetterBefore(bidArgs.RawBid.ParentHash)
+	if err = miner.bidSimulator.preCheckBid(bid, signer, true); err != nil {
+		miner.bidSimulator.auditBid(bidAuditRejected, bid, nil, err)
+		return common.Hash{}, err
+	}
+
+	bidBetterBefore := miner.bidSimulator.bidBetterBefore(parentHash)
 	timeout := time.Until(bidBetterBefore)
 
 	if timeout <= 0 {
-		return common.Hash{}, fmt.Errorf("too late, expected befor %s, appeared %s later", bidBetterBefore,
-			common.PrettyDuration(timeout))
+		if !miner.bidSimulator.config.FastPathEnabled {
+			return common.Hash{}, types.NewBidTooLateError(bidBetterBefore, -timeout)
+		}
+
+		if err = miner.bidSimulator.sendFastPathBid(ctx, bid); err != nil {
+			return common.Hash{}, fmt.Errorf("too late, expected befor %s, appeared %s later, fast path: %v", bidBetterBefore,
+				common.PrettyDuration(timeout), err)
+		}
+
+		return bid.Hash(), nil
 	}
 
 	err = miner.bidSimulator.sendBid(ctx, bid)
@@ -104,6 +258,37 @@ func (miner *Miner) SendBid(ctx context.Context, bidArgs *types.BidArgs) (common
 	return bid.Hash(), nil
 }
 
+// SimulateBid simulates the bid without sending it to the auction, so the builders can test
+// their bids before going live. The state overrides are only accepted here, never by SendBid,
+// and only if DryRunStateOverride is enabled.
+func (miner *Miner) SimulateBid(ctx context.Context, bidArgs *types.BidArgs, overrides types.BidStateOverride) (*types.BidDryRunResult, error) {
+	if err := miner.bidSimulator.checkHashVersion(bidArgs.RawBid); err != nil {
+		return nil, err
+	}
+
+	builder, err := bidArgs.EcrecoverSender()
+	if err != nil {
+		return nil, types.NewInvalidBidError(fmt.Sprintf("invalid signature:%v", err))
+	}
+
+	if !miner.bidSimulator.ExistBuilder(builder) {
+		return nil, types.NewInvalidBidError("builder is not registered")
+	}
+
+	signer := types.MakeSigner(miner.worker.chainConfig, big.NewInt(int64(bidArgs.RawBid.BlockNumber)), uint64(time.Now().Unix()))
+	bid, err := bidArgs.ToBidWithKnownTxs(builder, signer, miner.eth.TxPool().Get)
+	if err != nil {
+		return nil, types.NewInvalidBidError(fmt.Sprintf("fail to convert bidArgs to bid, %v", err))
+	}
+	bid.ParentHash = miner.bidSimulator.resolveParent(bidArgs.RawBid)
+
+	if err = miner.bidSimulator.preCheckBid(bid, signer, false); err != nil {
+		return nil, err
+	}
+
+	return miner.bidSimulator.DryRunBid(ctx, bid, overrides)
+}
+
 func (miner *Miner) BestPackedBlockReward(parentHash common.Hash) *big.Int {
 	bidRuntime := miner.bidSimulator.GetBestBid(parentHash)
 	if bidRuntime == nil {
@@ -113,6 +298,86 @@ func (miner *Miner) BestPackedBlockReward(parentHash common.Hash) *big.Int {
 	return bidRuntime.totalRewardFromBuilder()
 }
 
+// BestBidInfo returns the summary of the best bid for the parent hash.
+func (miner *Miner) BestBidInfo(parentHash common.Hash) *types.BestBidInfo {
+	return miner.bidSimulator.BestBidInfo(parentHash)
+}
+
+// BuilderStats returns the runtime statistics of the builder.
+func (miner *Miner) BuilderStats(builder common.Address) *types.BuilderStats {
+	return miner.bidSimulator.BuilderStats(builder)
+}
+
+// BuilderStatsSnapshot returns the runtime statistics of all the known builders.
+func (miner *Miner) BuilderStatsSnapshot() []*types.BuilderStats {
+	return miner.bidSimulator.BuilderStatsSnapshot()
+}
+
+// ExplainOutcome returns the decision records of the bid and the winner of its block.
+func (miner *Miner) ExplainOutcome(blockNumber uint64, bidHash common.Hash) (*types.BidOutcome, error) {
+	return miner.bidSimulator.ExplainOutcome(blockNumber, bidHash)
+}
+
+// BidStatus returns the status of the pending bid of the builder.
+func (miner *Miner) BidStatus(blockNumber uint64, builder common.Address, bidHash common.Hash) (*types.BidStatus, error) {
+	return miner.bidSimulator.BidStatus(blockNumber, builder, bidHash)
+}
+
+// PendingBids returns the snapshot of the pending bids of the block selected by the arguments.
+func (miner *Miner) PendingBids(args *types.PendingBidsArgs) (*types.PendingBids, error) {
+	return miner.bidSimulator.PendingBids(args)
+}
+
+// PendingStats returns the count and the timing of the pending bids of each builder for the block.
+func (miner *Miner) PendingStats(blockNumber uint64) []*types.PendingBidStats {
+	return miner.bidSimulator.PendingStats(blockNumber)
+}
+
+// SubscribeBidResults starts delivering the results of the simulated bids to the given channel.
+func (miner *Miner) SubscribeBidResults(ch chan<- types.BidResult) event.Subscription {
+	return miner.bidSimulator.SubscribeBidResults(ch)
+}
+
+// BuilderHealth returns the connectivity status of the builders.
+func (miner *Miner) BuilderHealth() []*types.BuilderHealth {
+	return miner.bidSimulator.BuilderHealth()
+}
+
+// BidQueueStatus returns the depth of the queue of the bids waiting to be compared.
+func (miner *Miner) BidQueueStatus() *types.BidQueueStatus {
+	return miner.bidSimulator.BidQueueStatus()
+}
+
+// Builders returns the builders in the builder list with their endpoints.
+func (miner *Miner) Builders() []*types.BuilderInfo {
+	return miner.bidSimulator.Builders()
+}
+
+// ExportMevStore dumps the records of the persistent mev store with the name.
+func (miner *Miner) ExportMevStore(name string) (*types.MevStoreDump, error) {
+	return miner.bidSimulator.ExportStore(name)
+}
+
+// ImportMevStore imports the dumped records into the persistent mev store.
+func (miner *Miner) ImportMevStore(dump *types.MevStoreDump) error {
+	return miner.bidSimulator.ImportStore(dump)
+}
+
+// BidHistory returns the outcomes of the recent sealed blocks newest first.
+func (miner *Miner) BidHistory(args *types.BidHistoryArgs) (*types.BidHistoryPage, error) {
+	return miner.bidSimulator.BidHistory(args)
+}
+
+// BlockOrigin returns whether the recent sealed block is built from a bid or locally.
+func (miner *Miner) BlockOrigin(blockHash common.Hash) (*types.BlockOrigin, error) {
+	return miner.bidSimulator.BlockOrigin(blockHash)
+}
+
+// MevRevenueReport returns the MEV revenue of the proposed blocks grouped by day or epoch.
+func (miner *Miner) MevRevenueReport(args *types.MevRevenueReportArgs) ([]*types.MevRevenueGroup, error) {
+	return miner.bidSimulator.RevenueReport(args)
+}
+
 func (miner *Miner) MevParams() *types.MevParams {
 	builderFeeCeil, ok := big.NewInt(0).SetString(miner.worker.config.Mev.BuilderFeeCeil, 10)
 	if !ok {
@@ -122,10 +387,13 @@ func (miner *Miner) MevParams() *types.MevParams {
 
 	return &types.MevParams{
 		ValidatorCommission:   miner.worker.config.Mev.ValidatorCommission,
-		BidSimulationLeftOver: miner.worker.config.Mev.BidSimulationLeftOver,
+		BidSimulationLeftOver: miner.bidSimulator.simulationLeftOver(),
 		GasCeil:               miner.worker.config.GasCeil,
 		GasPrice:              miner.worker.config.GasPrice,
 		BuilderFeeCeil:        builderFeeCeil,
+		GasFeeCeil:            miner.bidSimulator.gasFeeCeil(miner.worker.chain.CurrentBlock()),
+		BidGasPriceCeil:       miner.worker.config.Mev.BidGasPriceCeil,
+		NontaxableFeeCeil:     miner.worker.config.Mev.NontaxableFeeCeil,
 		Version:               params.Version,
 	}
 }
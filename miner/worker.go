@@ -19,6 +19,7 @@ package miner
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"math/big"
 	"sync"
 	"sync/atomic"
@@ -82,11 +83,15 @@ var (
 	writeBlockTimer    = metrics.NewRegisteredTimer("worker/writeblock", nil)
 	finalizeBlockTimer = metrics.NewRegisteredTimer("worker/finalizeblock", nil)
 
+	sealLocalWinCounter = metrics.NewRegisteredCounter("worker/seal/local", nil)
+	sealBidWinCounter   = metrics.NewRegisteredCounter("worker/seal/bid", nil)
+
 	errBlockInterruptedByNewHead   = errors.New("new head arrived while building block")
 	errBlockInterruptedByRecommit  = errors.New("recommit interrupt while building block")
 	errBlockInterruptedByTimeout   = errors.New("timeout while building block")
 	errBlockInterruptedByOutOfGas  = errors.New("out of gas while building block")
 	errBlockInterruptedByBetterBid = errors.New("better bid arrived while building block")
+	errBlockInterruptedByShutdown  = errors.New("miner exit while building block")
 )
 
 // environment is the worker's current environment and holds all
@@ -104,6 +109,8 @@ type environment struct {
 	receipts []*types.Receipt
 	sidecars types.BlobSidecars
 	blobs    int
+
+	touched map[common.Hash][]common.Address // the accounts accessed by the bid txs, only recorded for merging the bids
 }
 
 // copy creates a deep copy of environment.
@@ -123,6 +130,7 @@ func (env *environment) copy() *environment {
 	}
 	cpy.txs = make([]*types.Transaction, len(env.txs))
 	copy(cpy.txs, env.txs)
+	cpy.touched = maps.Clone(env.touched)
 
 	if env.sidecars != nil {
 		cpy.sidecars = make(types.BlobSidecars, len(env.sidecars))
@@ -149,6 +157,9 @@ type task struct {
 	state     *state.StateDB
 	block     *types.Block
 	createdAt time.Time
+
+	bid  *BidRuntime // the bid the block built from, nil for local block
+	fees *big.Int
 }
 
 const (
@@ -158,6 +169,7 @@ const (
 	commitInterruptTimeout
 	commitInterruptOutOfGas
 	commitInterruptBetterBid
+	commitInterruptShutdown
 )
 
 // newWorkReq represents a request for new sealing work submitting with relative interrupt notifier.
@@ -183,6 +195,9 @@ type getWorkReq struct {
 type bidFetcher interface {
 	GetBestBid(parentHash common.Hash) *BidRuntime
 	GetSimulatingBid(prevBlockHash common.Hash) *BidRuntime
+	PromoteBackupBid(prevBlockHash common.Hash, timeout time.Duration) *BidRuntime
+	OnBlockSealed(block *types.Block, bid *BidRuntime, fees *big.Int)
+	RecordSealLatency(latency time.Duration)
 }
 
 // worker is the main object which takes care of submitting new work to consensus engine
@@ -335,6 +350,28 @@ func (w *worker) etherbase() common.Address {
 	return w.coinbase
 }
 
+// etherbaseForBlock retrieves the payout address active for the block number according to
+// the configured rotations, the configured etherbase is returned if none is active. It is
+// only a payout address, the block is still authored and executed with the etherbase which
+// is the signer of the consensus engine.
+func (w *worker) etherbaseForBlock(number uint64) common.Address {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var (
+		coinbase = w.coinbase
+		from     uint64
+		found    bool
+	)
+	for _, rotation := range w.config.EtherbaseRotation {
+		if rotation.FromBlock <= number && (!found || rotation.FromBlock >= from) {
+			coinbase, from, found = rotation.Etherbase, rotation.FromBlock, true
+		}
+	}
+
+	return coinbase
+}
+
 func (w *worker) setGasCeil(ceil uint64) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -685,6 +722,9 @@ func (w *worker) resultLoop() {
 			writeBlockTimer.UpdateSince(start)
 			log.Info("Successfully sealed new block", "number", block.Number(), "sealhash", sealhash, "hash", hash,
 				"elapsed", common.PrettyDuration(time.Since(task.createdAt)))
+			if w.bidFetcher != nil {
+				w.bidFetcher.OnBlockSealed(block, task.bid, task.fees)
+			}
 			w.mux.Post(core.NewMinedBlockEvent{Block: block})
 
 		case <-w.exitCh:
@@ -791,8 +831,10 @@ func (w *worker) applyTransaction(env *environment, tx *types.Transaction, recei
 	return receipt, err
 }
 
+// commitTransactions commits the txs until the env is full or interrupted, txLimit is the tx
+// count of the env to stop at, 0 means no limit.
 func (w *worker) commitTransactions(env *environment, plainTxs, blobTxs *transactionsByPriceAndNonce,
-	interruptCh chan int32, stopTimer *time.Timer) error {
+	interruptCh chan int32, stopTimer *time.Timer, txLimit int) error {
 	gasLimit := env.header.GasLimit
 	if env.gasPool == nil {
 		env.gasPool = new(core.GasPool).AddGas(gasLimit)
@@ -853,6 +895,10 @@ LOOP:
 			default:
 			}
 		}
+		if txLimit > 0 && env.tcount >= txLimit {
+			log.Trace("Reached the tx count limit", "txs", env.tcount)
+			break
+		}
 
 		// If we don't have enough blob space for any further blob transactions,
 		// skip that list altogether
@@ -1080,7 +1126,7 @@ func (w *worker) prepareWork(genParams *generateParams) (*environment, error) {
 // fillTransactions retrieves the pending transactions from the txpool and fills them
 // into the given sealing block. The transaction selection and ordering strategy can
 // be customized with the plugin in the future.
-func (w *worker) fillTransactions(interruptCh chan int32, env *environment, stopTimer *time.Timer, bidTxs mapset.Set[common.Hash]) (err error) {
+func (w *worker) fillTransactions(interruptCh chan int32, env *environment, stopTimer *time.Timer, bidTxs mapset.Set[common.Hash], maxTxs int) (err error) {
 	w.mu.RLock()
 	tip := w.tip
 	w.mu.RUnlock()
@@ -1143,11 +1189,15 @@ func (w *worker) fillTransactions(interruptCh chan int32, env *environment, stop
 	//   3.the mining timer has expired, stop adding transactions.
 	//   4.interrupted resubmit timer, which is by default 10s.
 	//     resubmit is for PoW only, can be deleted for PoS consensus later
+	var txLimit int
+	if maxTxs > 0 {
+		txLimit = env.tcount + maxTxs
+	}
 	if len(localPlainTxs) > 0 || len(localBlobTxs) > 0 {
 		plainTxs := newTransactionsByPriceAndNonce(env.signer, localPlainTxs, env.header.BaseFee)
 		blobTxs := newTransactionsByPriceAndNonce(env.signer, localBlobTxs, env.header.BaseFee)
 
-		if err := w.commitTransactions(env, plainTxs, blobTxs, interruptCh, stopTimer); err != nil {
+		if err := w.commitTransactions(env, plainTxs, blobTxs, interruptCh, stopTimer, txLimit); err != nil {
 			return err
 		}
 	}
@@ -1155,7 +1205,7 @@ func (w *worker) fillTransactions(interruptCh chan int32, env *environment, stop
 		plainTxs := newTransactionsByPriceAndNonce(env.signer, remotePlainTxs, env.header.BaseFee)
 		blobTxs := newTransactionsByPriceAndNonce(env.signer, remoteBlobTxs, env.header.BaseFee)
 
-		if err := w.commitTransactions(env, plainTxs, blobTxs, interruptCh, stopTimer); err != nil {
+		if err := w.commitTransactions(env, plainTxs, blobTxs, interruptCh, stopTimer, txLimit); err != nil {
 			return err
 		}
 	}
@@ -1172,7 +1222,7 @@ func (w *worker) generateWork(params *generateParams) *newPayloadResult {
 	defer work.discard()
 
 	if !params.noTxs {
-		err := w.fillTransactions(nil, work, nil, nil)
+		err := w.fillTransactions(nil, work, nil, nil, 0)
 		if errors.Is(err, errBlockInterruptedByTimeout) {
 			log.Warn("Block building is interrupted", "allowance", common.PrettyDuration(w.newpayloadTimeout))
 		}
@@ -1272,7 +1322,7 @@ LOOP:
 
 		// Fill pending transactions from the txpool into the block.
 		fillStart := time.Now()
-		err = w.fillTransactions(interruptCh, work, stopTimer, nil)
+		err = w.fillTransactions(interruptCh, work, stopTimer, nil, 0)
 		fillDuration := time.Since(fillStart)
 		switch {
 		case errors.Is(err, errBlockInterruptedByNewHead):
@@ -1359,8 +1409,13 @@ LOOP:
 
 	// when out-turn, use bestWork to prevent bundle leakage.
 	// when in-turn, compare with remote work.
+	var (
+		sealedBid *BidRuntime
+		takenAt   time.Time // the time the best bid is taken, the later bids no longer count
+	)
 	from := bestWork.coinbase
-	if w.bidFetcher != nil && bestWork.header.Difficulty.Cmp(diffInTurn) == 0 {
+	// no bid becomes the best bid in shadow mode, the simulating one isn't waited for either
+	if w.bidFetcher != nil && !w.config.Mev.ShadowMode && bestWork.header.Difficulty.Cmp(diffInTurn) == 0 {
 		if pendingBid := w.bidFetcher.GetSimulatingBid(bestWork.header.ParentHash); pendingBid != nil {
 			waitBidTimer := time.NewTimer(waitMEVMinerEndTimeLimit)
 			defer waitBidTimer.Stop()
@@ -1371,24 +1426,60 @@ LOOP:
 		}
 
 		bestBid := w.bidFetcher.GetBestBid(bestWork.header.ParentHash)
+		takenAt = time.Now()
+		localReward := calcRewardAfterBEP95(bestReward.ToBig())
 
-		if bestBid != nil && calcRewardAfterBEP95(bestReward.ToBig()).Cmp(bestBid.totalReward()) < 0 {
-			bestWork = bestBid.env
-			from = bestBid.bid.Builder
+		// hold the env of the bid from being discarded by a head event until the block is committed
+		if bestBid != nil {
+			if bestBid.Acquire() {
+				defer bestBid.Release()
+			} else {
+				bestBid = nil
+			}
+		}
 
-			log.Info(" 🔥 bid win",
+		if bestBid != nil && w.config.Mev.PreferLocalIfBetter && localReward.Cmp(bestBid.totalReward()) >= 0 {
+			sealLocalWinCounter.Inc(1)
+			log.Info("local block wins over the best bid",
 				"bn", bestWork.header.Number.Uint64(),
-				"from", from,
-				"blockReward", weiToEtherStringF6(bestBid.blockReward()),
-				"totalReward", weiToEtherStringF6(bestBid.totalReward()),
-				"builderCtb", weiToEtherStringF6(bestBid.totalRewardFromBuilder()),
+				"builder", bestBid.bid.Builder,
+				"localReward", weiToEtherStringF6(localReward),
+				"bidReward", weiToEtherStringF6(bestBid.totalReward()),
 			)
+		} else if bestBid != nil {
+			if verified := w.verifiedBid(bestWork.header, bestBid, localReward); verified != bestBid {
+				bestBid = verified
+				if bestBid != nil {
+					defer bestBid.Release()
+				}
+			}
+
+			if bestBid != nil {
+				bestWork = bestBid.env
+				from = bestBid.bid.Builder
+				sealedBid = bestBid
+
+				sealBidWinCounter.Inc(1)
+				log.Info(" 🔥 bid win",
+					"bn", bestWork.header.Number.Uint64(),
+					"from", from,
+					"blockReward", weiToEtherStringF6(bestBid.blockReward()),
+					"totalReward", weiToEtherStringF6(bestBid.totalReward()),
+					"localReward", weiToEtherStringF6(localReward),
+					"builderCtb", weiToEtherStringF6(bestBid.totalRewardFromBuilder()),
+					"fastPath", bestBid.fastPath,
+				)
+			}
 		}
 	}
 
 	metrics.GetOrRegisterCounter(fmt.Sprintf("block/from/%v", from), nil).Inc(1)
 
-	w.commit(bestWork, w.fullTaskHook, true, start)
+	w.commit(bestWork, w.fullTaskHook, true, start, sealedBid)
+
+	if !takenAt.IsZero() {
+		w.bidFetcher.RecordSealLatency(time.Since(takenAt))
+	}
 
 	// Swap out the old work with the new one, terminating any leftover
 	// prefetcher processes in the mean time and starting a new one.
@@ -1398,6 +1489,60 @@ LOOP:
 	w.current = bestWork
 }
 
+// verifiedBid waits for the paranoid verification of the best bid, and falls back to the backup
+// bid if the verification failed, nil means the local block is sealed. The best bid not verified
+// before the seal deadline is sealed unverified. The returned backup bid is acquired, the caller
+// must release it.
+func (w *worker) verifiedBid(header *types.Header, bestBid *BidRuntime, localReward *big.Int) *BidRuntime {
+	verifyTimeout := time.Until(time.Unix(int64(header.Time), 0)) - w.config.DelayLeftOver
+
+	err := bestBid.waitVerified(verifyTimeout)
+	if err == nil {
+		return bestBid
+	}
+	if errors.Is(err, errBidNotVerified) {
+		log.Warn("Best bid not verified in time, sealing it unverified", "bn", header.Number.Uint64(),
+			"builder", bestBid.bid.Builder)
+		return bestBid
+	}
+	log.Error("Best bid failed verification", "bn", header.Number.Uint64(),
+		"builder", bestBid.bid.Builder, "err", err)
+
+	backup := w.backupBid(header, localReward)
+	if backup == nil {
+		log.Error("No valid backup bid, fallback to local block", "bn", header.Number.Uint64())
+	}
+
+	return backup
+}
+
+// backupBid promotes the runner-up of the best bid failed the verification, nil if there is
+// no valid one in the time left, or the local block rewards more. The returned bid is acquired,
+// the caller must release it.
+func (w *worker) backupBid(header *types.Header, localReward *big.Int) *BidRuntime {
+	timeout := time.Until(time.Unix(int64(header.Time), 0)) - w.config.DelayLeftOver
+	if timeout <= 0 {
+		return nil
+	}
+
+	backup := w.bidFetcher.PromoteBackupBid(header.ParentHash, timeout)
+	if backup != nil && !backup.Acquire() {
+		return nil
+	}
+	if backup != nil && w.config.Mev.PreferLocalIfBetter && localReward.Cmp(backup.totalReward()) >= 0 {
+		sealLocalWinCounter.Inc(1)
+		log.Info("local block wins over the backup bid", "bn", header.Number.Uint64(),
+			"builder", backup.bid.Builder,
+			"localReward", weiToEtherStringF6(localReward),
+			"bidReward", weiToEtherStringF6(backup.totalReward()),
+		)
+		backup.Release()
+		return nil
+	}
+
+	return backup
+}
+
 // inTurn return true if the current worker is in turn.
 func (w *worker) inTurn() bool {
 	validator, _ := w.engine.NextInTurnValidator(w.chain, w.chain.CurrentBlock())
@@ -1408,7 +1553,7 @@ func (w *worker) inTurn() bool {
 // and commits new work if consensus engine is running.
 // Note the assumption is held that the mutation is allowed to the passed env, do
 // the deep copy first.
-func (w *worker) commit(env *environment, interval func(), update bool, start time.Time) error {
+func (w *worker) commit(env *environment, interval func(), update bool, start time.Time, bid *BidRuntime) error {
 	if w.isRunning() {
 		if interval != nil {
 			interval()
@@ -1450,7 +1595,7 @@ func (w *worker) commit(env *environment, interval func(), update bool, start ti
 		// If we're post merge, just ignore
 		if !w.isTTDReached(block.Header()) {
 			select {
-			case w.taskCh <- &task{receipts: receipts, state: env.state, block: block, createdAt: time.Now()}:
+			case w.taskCh <- &task{receipts: receipts, state: env.state, block: block, createdAt: time.Now(), bid: bid, fees: fees}:
 				log.Info("Commit new sealing work", "number", block.Number(), "sealhash", w.engine.SealHash(block.Header()),
 					"txs", env.tcount, "blobs", env.blobs, "gas", block.GasUsed(), "fees", feesInEther, "elapsed", common.PrettyDuration(time.Since(start)))
 
@@ -1514,6 +1659,8 @@ func signalToErr(signal int32) error {
 		return errBlockInterruptedByOutOfGas
 	case commitInterruptBetterBid:
 		return errBlockInterruptedByBetterBid
+	case commitInterruptShutdown:
+		return errBlockInterruptedByShutdown
 	default:
 		panic(fmt.Errorf("undefined signal %d", signal))
 	}
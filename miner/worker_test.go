@@ -18,6 +18,7 @@ package miner // TOFIX
 
 import (
 	"math/big"
+	"slices"
 	"testing"
 	"time"
 
@@ -411,3 +412,142 @@ func testGetSealingWork(t *testing.T, chainConfig *params.ChainConfig, engine co
 		}
 	}
 }
+
+func TestEtherbaseForBlock(t *testing.T) {
+	var (
+		etherbase = common.HexToAddress("0x1")
+		rotated1  = common.HexToAddress("0x2")
+		rotated2  = common.HexToAddress("0x3")
+	)
+
+	w := &worker{
+		coinbase: etherbase,
+		config: &Config{EtherbaseRotation: []EtherbaseRotation{
+			{FromBlock: 200, Etherbase: rotated2},
+			{FromBlock: 100, Etherbase: rotated1},
+		}},
+	}
+
+	for number, want := range map[uint64]common.Address{
+		99:  etherbase,
+		100: rotated1,
+		199: rotated1,
+		200: rotated2,
+		500: rotated2,
+	} {
+		if got := w.etherbaseForBlock(number); got != want {
+			t.Errorf("block %d: unexpected etherbase want %x got %x", number, want, got)
+		}
+	}
+}
+
+func TestValidatorBribeEOAsPayout(t *testing.T) {
+	var (
+		signer = common.HexToAddress("0x1")
+		payout = common.HexToAddress("0x2")
+		bribe  = common.HexToAddress("0x3")
+		b      = &bidSimulator{config: &MevConfig{ValidatorBribeEOAs: []common.Address{bribe}}}
+	)
+
+	// the rotated payout address is paid directly like a bribe EOA
+	b.bidWorker = &worker{coinbase: signer, config: &Config{EtherbaseRotation: []EtherbaseRotation{{FromBlock: 1, Etherbase: payout}}}}
+	if eoas := b.validatorBribeEOAs(1); !slices.Equal(eoas, []common.Address{bribe, payout}) {
+		t.Fatalf("unexpected bribe EOAs %v", eoas)
+	}
+	if len(b.config.ValidatorBribeEOAs) != 1 {
+		t.Fatalf("the configured bribe EOAs are modified: %v", b.config.ValidatorBribeEOAs)
+	}
+
+	// the signer is the coinbase, the payout address is counted once
+	for _, rotated := range []common.Address{signer, bribe} {
+		b.bidWorker = &worker{coinbase: signer, config: &Config{EtherbaseRotation: []EtherbaseRotation{{FromBlock: 1, Etherbase: rotated}}}}
+		if eoas := b.validatorBribeEOAs(1); !slices.Equal(eoas, []common.Address{bribe}) {
+			t.Fatalf("unexpected bribe EOAs %v with payout %v", eoas, rotated)
+		}
+	}
+}
+
+// TestEtherbaseRotationReexecution checks the blocks mined with a rotated payout address are
+// executed with the signer as the coinbase, so the importers re-execute them to the same root.
+func TestEtherbaseRotationReexecution(t *testing.T) {
+	t.Parallel()
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		config = *params.AllCliqueProtocolChanges
+		payout = common.HexToAddress("0xdeadbeef")
+	)
+	config.Clique = &params.CliqueConfig{Period: 1, Epoch: 30000}
+	engine := clique.New(config.Clique, db)
+
+	b := newTestWorkerBackend(t, &config, engine, db, 0)
+	b.txPool.Add(pendingTxs, true, false)
+
+	minerConfig := *testConfig
+	minerConfig.EtherbaseRotation = []EtherbaseRotation{{FromBlock: 1, Etherbase: payout}}
+	w := newWorker(&minerConfig, &config, engine, b, new(event.TypeMux), nil, false)
+	w.setEtherbase(testBankAddress)
+	defer w.close()
+
+	// This test chain imports the mined blocks, the fees go to the author recovered from the seal.
+	chain, _ := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, b.genesis, nil, engine, vm.Config{}, nil, nil)
+	defer chain.Stop()
+
+	w.skipSealHook = func(task *task) bool {
+		return len(task.receipts) == 0
+	}
+
+	sub := w.mux.Subscribe(core.NewMinedBlockEvent{})
+	defer sub.Unsubscribe()
+
+	w.start()
+
+	for i := 0; i < 3; i++ {
+		b.txPool.Add([]*types.Transaction{b.newRandomTx(false)}, true, false)
+
+		select {
+		case ev := <-sub.Chan():
+			block := ev.Data.(core.NewMinedBlockEvent).Block
+			if _, err := chain.InsertChain([]*types.Block{block}); err != nil {
+				t.Fatalf("failed to re-execute mined block %d: %v", block.NumberU64(), err)
+			}
+			if root := chain.CurrentBlock().Root; root != block.Root() {
+				t.Fatalf("block %d re-executed to root %x, sealed %x", block.NumberU64(), root, block.Root())
+			}
+		case <-time.After(3 * time.Second): // Worker needs 1s to include new changes.
+			t.Fatalf("timeout")
+		}
+	}
+	if w.etherbaseForBlock(1) != payout {
+		t.Fatalf("the payout address is not rotated")
+	}
+}
+
+func TestFillTransactionsMaxTxs(t *testing.T) {
+	w, b := newTestWorker(t, ethashChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	for i := 0; i < 3; i++ {
+		b.txPool.Add([]*types.Transaction{b.newRandomTx(false)}, true, true)
+	}
+
+	env, err := w.prepareWork(&generateParams{coinbase: testBankAddress})
+	if err != nil {
+		t.Fatalf("failed to prepare work: %v", err)
+	}
+	defer env.discard()
+
+	if err := w.fillTransactions(nil, env, nil, nil, 2); err != nil {
+		t.Fatalf("failed to fill transactions: %v", err)
+	}
+	if env.tcount != 2 {
+		t.Fatalf("unexpected tx count %d, want 2", env.tcount)
+	}
+
+	// the limit counts the txs added on top of the ones in the env
+	if err := w.fillTransactions(nil, env, nil, nil, 1); err != nil {
+		t.Fatalf("failed to fill transactions: %v", err)
+	}
+	if env.tcount != 3 {
+		t.Fatalf("unexpected tx count %d, want 3", env.tcount)
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	receivedAtKey struct{}
+	decodedAtKey  struct{}
+)
+
+// ReceivedAtFromContext returns the time the request was received by the HTTP transport,
+// before its body is decoded. It is not available for the other transports.
+func ReceivedAtFromContext(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(receivedAtKey{}).(time.Time)
+	return t, ok
+}
+
+// DecodedAtFromContext returns the time the arguments of the method call were decoded.
+func DecodedAtFromContext(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(decodedAtKey{}).(time.Time)
+	return t, ok
+}